@@ -1,8 +1,10 @@
 package govaluate
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 )
 
 const isoDateFormat string = "2006-01-02T15:04:05.999999999Z0700"
@@ -32,9 +34,182 @@ type EvaluableExpression struct {
 	*/
 	ChecksTypes bool
 
+	/*
+		If set, is notified as this expression is evaluated - see the Observer interface.
+		Nil by default, in which case evaluation does no extra work.
+	*/
+	Observer Observer
+
+	/*
+		Which numeric results (if any) are rounded to ResultPrecision decimal places. See PrecisionMode.
+		Defaults to NoRounding.
+	*/
+	PrecisionMode PrecisionMode
+
+	/*
+		The number of decimal places numeric results are rounded to, when PrecisionMode is not NoRounding.
+	*/
+	ResultPrecision int
+
+	/*
+		Whether or not to return an error when exponentiation of finite numbers (such as "10 ** 400") overflows to infinity,
+		rather than letting the infinite result silently carry on through the rest of the expression.
+		False by default.
+	*/
+	ChecksExponentOverflow bool
+
+	/*
+		Whether or not to return an error when a left shift ("<<") of a number overflows, rather than silently losing bits (so that "1 << 64" is 0).
+		A shift overflows if any set bits are shifted past the 64th.
+		Shifting a negative number, or by a negative amount, is also an error, since those wrap around to huge unsigned values.
+		*big.Int values never overflow, so they're unaffected. False by default.
+	*/
+	ChecksShiftOverflow bool
+
+	/*
+		If set, the conditions of "&&", "||", "!", and "?" may be of any type, and are converted to bools by this function
+		(see `DefaultTruthiness`, which treats nil, 0, and "" as false).
+		If nil (the default), those conditions must be bools.
+	*/
+	Truthiness func(value interface{}) bool
+
+	/*
+		If set, is called with the name and value of each parameter as it's read (before it's converted to float64, if it's a number),
+		and its result is used instead. This allows values from other sources (like strings of digits, or json.Number) to be converted
+		to the types expressions work with, without converting every parameter beforehand. If it returns an error, evaluation stops with that error.
+		Only parameters themselves are given to it, not the fields or methods of them which accessors read.
+		The values of `PositionalParameters` are converted when they're given, so those are already float64.
+	*/
+	Coercer func(name string, value interface{}) (interface{}, error)
+
+	/*
+		If true, comparisons which can't compare nil (">", ">=", "<", "<=", "=~", and "!~") are false when either side is nil,
+		rather than a type error. This is a simple kind of null-safety for rules which filter values, so that a nil value just fails the condition.
+		"==" and "!=" already compare nil like any other value, so they're unchanged. False by default.
+	*/
+	NilComparisonsAreFalse bool
+
+	/*
+		If true, a []byte compared with a string (by "==", "!=", ">", ">=", "<", "<=", "=~", or "!~") is compared as a string.
+		If false (the default), comparing them is an error, since they'd otherwise never be equal.
+		Two []byte values are always compared by their bytes, whether or not this is set.
+	*/
+	BytesAsStrings bool
+
+	/*
+		If set, is called when a pattern given to "=~" or "!~" at evaluation (such as from a parameter) can't be compiled,
+		with that pattern and the reason it couldn't be. Its result is used as the result of the comparison, rather than evaluation failing,
+		so a malformed pattern can just fail its condition (and be recorded somewhere) without stopping a whole batch of evaluations.
+		If it returns an error, evaluation stops with that error. If nil (the default), a pattern which can't be compiled is an error.
+		Regexp functions (like "matchNamed") are unaffected.
+	*/
+	RegexErrorHandler func(pattern string, err error) (bool, error)
+
+	/*
+		The type that numeric results are returned as, such as int when they're whole numbers. See NumericResultKind.
+		Defaults to AlwaysFloat64.
+	*/
+	NumericResultKind NumericResultKind
+
+	/*
+		If true, every numeric result of each step of evaluation which is negative zero (like "-x" when x is 0) is made positive zero,
+		so that it's written as "0" rather than "-0" when converted to a string. This also means that dividing by it gives +Inf, never -Inf.
+		False by default, since negative zero is already equal to zero.
+	*/
+	NormalizesNegativeZero bool
+
+	/*
+		If true, arithmetic ("+", "-", "*", "/", and negation) between numbers is exact, and gives a *big.Rat, so that "1 / 3 * 3" is exactly 1.
+		Numbers are read as the decimals they're written as, so 0.1 is exactly 1/10. Comparisons between *big.Rat values and numbers are exact too.
+		Other operators (like "%" and "**") and functions don't accept *big.Rat values, and dividing by zero is an error.
+		False by default, since this is much slower than float64 arithmetic.
+	*/
+	ExactRationals bool
+
+	/*
+		If more than zero, "==" and "!=" treat two float64 values as equal when they're within this tolerance of each other,
+		relative to the larger of them (or absolutely, for numbers between -1 and 1), so that "0.1 + 0.2 == 0.3" is true.
+		`DefaultEqualityTolerance` suits most rules. Other comparisons, and "in", are unaffected.
+		Zero (the default) compares floats exactly.
+	*/
+	EqualityTolerance float64
+
+	/*
+		If set, orders two strings for ">", ">=", "<", and "<=", instead of comparing their bytes.
+		It returns a negative number if a sorts before b, zero if they sort the same, or a positive number if a sorts after b,
+		as `strings.Compare` does; for the ordering of a particular language, use the CompareString method of a golang.org/x/text/collate Collator.
+		"==", "!=", and "in" still compare strings exactly. Nil (the default) compares strings byte-wise.
+	*/
+	Collation func(a, b string) int
+
+	/*
+		The most evaluations which may be running when this expression starts being evaluated (including itself),
+		such as when a function evaluates another expression which calls that function again.
+		Evaluating any deeper returns ErrRecursionTooDeep, rather than eventually crashing the program when its stack runs out.
+		Evaluations are only known to be nested when a function from `ParsingOptions.ContextFunctions` passes the context it was given
+		to `EvaluateWithContext`; any other evaluation begins again from one.
+		Zero (the default) means there is no limit.
+	*/
+	MaxRecursionDepth int
+
+	/*
+		The most bytes of strings which may be built (by concatenation, functions, or method calls) over the course of a single evaluation,
+		counting every intermediate string, not just the result. Going over returns ErrStringBudgetExceeded.
+		Built-ins such as "replace" are checked before they build their result, so they can't use up memory on the way.
+		This protects against untrusted expressions which would otherwise use up memory, like "s + s + s + ...".
+		Zero (the default) means there is no limit.
+	*/
+	MaxStringBytes int
+
+	/*
+		The most elements which a list (or map) returned by any function may have, such as the result of
+		"split(s, '')" or "map(items, 'it * 2')". Going over returns ErrCollectionTooLarge.
+		Each result is checked as its function returns, which bounds how large later steps of the evaluation can grow.
+		Zero (the default) means there is no limit.
+	*/
+	MaxCollectionSize int
+
+	/*
+		If set, holds the results of calls to pure functions (see `ParsingOptions.PureFunctions`) between evaluations,
+		and between any other expressions with the same FunctionCache.
+		If nil (the default), results are only kept for the rest of each evaluation.
+	*/
+	FunctionCache *FunctionCache
+
+	/*
+		The number of goroutines which `EvaluateStream` evaluates with.
+		Zero or one (the default) evaluates one set of parameters at a time.
+	*/
+	StreamWorkers int
+
+	/*
+		If true, `EvaluateStream` sends each result as soon as it's ready, rather than in the order its parameters arrived.
+		Only makes a difference when StreamWorkers is more than one. False by default.
+	*/
+	StreamUnordered bool
+
 	tokens           []ExpressionToken
 	evaluationStages *evaluationStage
 	inputExpression  string
+
+	// the same stages, compiled for `EvaluateFloatFast`. Nil if they can't be.
+	fastFloat *floatProgram
+
+	// the position of each parameter, for `PositionalParameters`.
+	parameterSlots *parameterSlots
+
+	// the result of an empty expression, when parsed with `ParsingOptions.AllowEmpty`.
+	emptyResult interface{}
+
+	// whether any functions called by this expression are pure, and so have their results cached.
+	callsPureFunctions bool
+
+	// only set on the copy of an expression used for a single evaluation. See evaluationState.
+	state *evaluationState
+
+	// set on the copy of an expression used to evaluate a sub-expression within it (see `subExpressionFunction`),
+	// whose comparisons and decisions aren't recorded in the evaluationState, since they're within a different expression string.
+	isSubExpression bool
 }
 
 /*
@@ -50,6 +225,8 @@ func NewEvaluableExpression(expression string) (*EvaluableExpression, error) {
 /*
 	Similar to [NewEvaluableExpression], except that instead of a string, an already-tokenized expression is given.
 	This is useful in cases where you may be generating an expression automatically, or using some other parser (e.g., to parse from a query language)
+	The tokens must be the same as those `DefaultTokenizer` would read from the equivalent expression string.
+	To parse strings in some other syntax with options, see `Tokenizer`.
 */
 func NewEvaluableExpressionFromTokens(tokens []ExpressionToken) (*EvaluableExpression, error) {
 
@@ -74,11 +251,16 @@ func NewEvaluableExpressionFromTokens(tokens []ExpressionToken) (*EvaluableExpre
 		return nil, err
 	}
 
-	ret.evaluationStages, err = planStages(ret.tokens)
+	ret.evaluationStages, err = planStages(ret.tokens, nil, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	ret.parameterSlots = findParameterSlots(ret.tokens)
+	assignParameterSlots(ret.evaluationStages, ret.parameterSlots)
+
+	ret.fastFloat = compileFloatProgram(ret.evaluationStages)
+
 	ret.ChecksTypes = true
 	return ret, nil
 }
@@ -88,19 +270,51 @@ func NewEvaluableExpressionFromTokens(tokens []ExpressionToken) (*EvaluableExpre
 	Functions passed into this will be available to the expression.
 */
 func NewEvaluableExpressionWithFunctions(expression string, functions map[string]ExpressionFunction) (*EvaluableExpression, error) {
+	return NewEvaluableExpressionWithOptions(expression, functions, ParsingOptions{})
+}
+
+/*
+	Similar to [NewEvaluableExpressionWithFunctions], except that the given [options] can change how the expression is parsed.
+	See `ParsingOptions`.
+*/
+func NewEvaluableExpressionWithOptions(expression string, functions map[string]ExpressionFunction, options ParsingOptions) (*EvaluableExpression, error) {
 
 	var ret *EvaluableExpression
+	var metadata []tokenMetadata
 	var err error
 
 	ret = new(EvaluableExpression)
 	ret.QueryDateFormat = isoDateFormat
 	ret.inputExpression = expression
 
-	ret.tokens, err = parseTokens(expression, functions)
+	if options.Tokenizer != nil {
+
+		ret.tokens, err = options.Tokenizer.Tokenize(expression, functions)
+		if err == nil && options.MaxTokens > 0 && len(ret.tokens) > options.MaxTokens {
+			err = ErrTooManyTokens
+		}
+	} else {
+		ret.tokens, metadata, err = parseTokens(expression, functions, options)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	if len(ret.tokens) == 0 {
+
+		if !options.AllowEmpty {
+			return nil, ErrEmptyExpression
+		}
+
+		ret.emptyResult = options.EmptyResult
+		ret.ChecksTypes = true
+		return ret, nil
+	}
+
+	if options.Interner != nil {
+		internTokens(ret.tokens, options.Interner)
+	}
+
 	err = checkBalance(ret.tokens)
 	if err != nil {
 		return nil, err
@@ -116,11 +330,23 @@ func NewEvaluableExpressionWithFunctions(expression string, functions map[string
 		return nil, err
 	}
 
-	ret.evaluationStages, err = planStages(ret.tokens)
+	ret.evaluationStages, err = planStages(ret.tokens, metadata, options.Precedence)
 	if err != nil {
 		return nil, err
 	}
 
+	err = options.checkStages(ret.evaluationStages)
+	if err != nil {
+		return nil, err
+	}
+
+	ret.callsPureFunctions = markPureFunctions(ret.evaluationStages, options.PureFunctions)
+
+	ret.parameterSlots = findParameterSlots(ret.tokens)
+	assignParameterSlots(ret.evaluationStages, ret.parameterSlots)
+
+	ret.fastFloat = compileFloatProgram(ret.evaluationStages)
+
 	ret.ChecksTypes = true
 	return ret, nil
 }
@@ -137,6 +363,89 @@ func (this EvaluableExpression) Evaluate(parameters map[string]interface{}) (int
 	return this.Eval(MapParameters(parameters))
 }
 
+/*
+	Same as `Evaluate`, but returns an error if evaluation takes longer than the given [timeout].
+	Evaluation happens on another goroutine, which stops at the next step of the expression once the timeout passes.
+	Note that a function which is already running when the timeout passes can't be interrupted, and will keep running
+	(though this method will still return immediately).
+*/
+func (this EvaluableExpression) EvaluateWithTimeout(parameters map[string]interface{}, timeout time.Duration) (interface{}, error) {
+
+	type evaluationResult struct {
+		value interface{}
+		err   error
+		panic interface{}
+	}
+
+	state := new(evaluationState)
+	this.state = state
+
+	results := make(chan evaluationResult, 1)
+
+	go func() {
+
+		var result evaluationResult
+
+		// panics (such as from evaluating without type checks) belong to the caller, not this goroutine.
+		defer func() {
+			result.panic = recover()
+			results <- result
+		}()
+
+		result.value, result.err = this.Evaluate(parameters)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-results:
+
+		if result.panic != nil {
+			panic(result.panic)
+		}
+		return result.value, result.err
+
+	case <-timer.C:
+
+		state.cancel()
+		return nil, fmt.Errorf("Evaluation timed out after %v", timeout)
+	}
+}
+
+/*
+	Same as `Evaluate`, but stops at the next step of the expression once [ctx] is cancelled or its deadline passes,
+	returning the context's error. [ctx] is also given to any functions from `ParsingOptions.ContextFunctions` which are called,
+	so that they can stop early too. Unlike `EvaluateWithTimeout`, evaluation happens on the calling goroutine.
+*/
+func (this EvaluableExpression) EvaluateWithContext(ctx context.Context, parameters map[string]interface{}) (interface{}, error) {
+
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	state := new(evaluationState)
+	this.state = state
+
+	finished := make(chan struct{})
+	defer close(finished)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			state.cancel()
+		case <-finished:
+		}
+	}()
+
+	result, err := this.Eval(contextParameters{Parameters: MapParameters(parameters), ctx: withNestedEvaluation(ctx)})
+	if errors.Is(err, errEvaluationCancelled) && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return result, err
+}
+
 /*
 	Runs the entire expression using the given [parameters].
 	e.g., If the expression contains a reference to the variable "foo", it will be taken from `parameters.Get("foo")`.
@@ -151,28 +460,108 @@ func (this EvaluableExpression) Evaluate(parameters map[string]interface{}) (int
 func (this EvaluableExpression) Eval(parameters Parameters) (interface{}, error) {
 
 	if this.evaluationStages == nil {
-		return nil, nil
+		return this.emptyResult, nil
 	}
 
-	if parameters != nil {
-		parameters = &sanitizedParameters{parameters}
-	} else {
+	if this.MaxRecursionDepth > 0 && evaluationDepth(parameters) > this.MaxRecursionDepth {
+		return nil, ErrRecursionTooDeep
+	}
+
+	if (this.MaxStringBytes > 0 || this.callsPureFunctions) && this.state == nil {
+		this.state = new(evaluationState)
+	}
+
+	switch parameters.(type) {
+	case nil:
 		parameters = DUMMY_PARAMETERS
+	case *PositionalParameters:
+
+		// already sanitized, when the values were given, except for the results of any thunks.
+		if this.Coercer != nil || parameters.(*PositionalParameters).lazy {
+			parameters = &sanitizedParameters{orig: parameters, coercer: this.Coercer}
+		}
+	default:
+		parameters = &sanitizedParameters{orig: parameters, coercer: this.Coercer}
+	}
+
+	if this.Observer == nil {
+		return this.evaluateResult(parameters)
+	}
+
+	this.Observer.EvaluationStarted(this.inputExpression)
+	start := time.Now()
+
+	result, err := this.evaluateResult(parameters)
+
+	this.Observer.EvaluationFinished(this.inputExpression, time.Since(start), err)
+	return result, err
+}
+
+/*
+	Evaluates the whole expression, applying any options which affect only the final result.
+*/
+func (this EvaluableExpression) evaluateResult(parameters Parameters) (interface{}, error) {
+
+	result, err := this.evaluateStage(this.evaluationStages, parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	if this.PrecisionMode == RoundResult {
+		result = roundToPrecision(result, this.ResultPrecision)
+		if this.NormalizesNegativeZero {
+			result = normalizeZero(result)
+		}
 	}
 
-	return this.evaluateStage(this.evaluationStages, parameters)
+	if this.NumericResultKind != AlwaysFloat64 {
+		return convertNumericResult(result, this.NumericResultKind)
+	}
+	return result, nil
+}
+
+/*
+	Returns true if any of this expression's options might make the stages which were elided into literals at parse time
+	evaluate differently than they did then.
+*/
+func (this EvaluableExpression) evaluatesElidedStages() bool {
+	return this.PrecisionMode == RoundDivision || this.ChecksExponentOverflow || this.ChecksShiftOverflow || this.ExactRationals || this.EqualityTolerance > 0 || this.Collation != nil
 }
 
 func (this EvaluableExpression) evaluateStage(stage *evaluationStage, parameters Parameters) (interface{}, error) {
 
-	var left, right interface{}
+	var left, right, result interface{}
 	var err error
 
+	if this.state != nil && this.state.isCancelled() {
+		return nil, errEvaluationCancelled
+	}
+
+	if this.state != nil && this.state.recordsConditions && !this.isSubExpression && stage.symbol.isComparator() && stage != this.state.conditionStage {
+		return this.evaluateCondition(stage, parameters)
+	}
+
+	if stage.elided != nil && this.evaluatesElidedStages() {
+		return this.evaluateStage(stage.elided, parameters)
+	}
+
+	if stage.catchesErrors && findArgumentCount(stage.rightStage) == 2 {
+		return this.evaluateTry(stage.rightStage.rightStage, parameters)
+	}
+
 	if stage.leftStage != nil {
 		left, err = this.evaluateStage(stage.leftStage, parameters)
 		if err != nil {
 			return nil, err
 		}
+
+		if this.Truthiness != nil && hasTruthyLeft(stage.symbol) {
+			left = boolIface(this.Truthiness(left))
+		}
+	}
+
+	if this.state != nil && this.state.explaining && !this.isSubExpression {
+		this.state.recordDecision(stage, left)
 	}
 
 	if stage.isShortCircuitable() {
@@ -206,6 +595,44 @@ func (this EvaluableExpression) evaluateStage(stage *evaluationStage, parameters
 		if err != nil {
 			return nil, err
 		}
+
+		if this.Truthiness != nil && hasTruthyRight(stage.symbol) {
+			right = boolIface(this.Truthiness(right))
+		}
+	}
+
+	if this.NilComparisonsAreFalse && stage.symbol.isOrderedComparator() && (isNil(left) || isNil(right)) {
+		return false, nil
+	}
+
+	if (stage.symbol == EQ || stage.symbol == NEQ || stage.symbol.isOrderedComparator()) && isMixedBytes(left, right) {
+
+		if !this.BytesAsStrings {
+			errorMsg := fmt.Sprintf("Value '%v' cannot be compared with '%v' by the comparator '%v', since one is a []byte and the other is a string", left, right, stage.symbol.String())
+			return nil, stage.locateError(errors.New(errorMsg))
+		}
+		left, right = bytesToString(left), bytesToString(right)
+	}
+
+	if this.EqualityTolerance > 0 && (stage.symbol == EQ || stage.symbol == NEQ) && isFloat64(left) && isFloat64(right) {
+		equal := isNearlyEqual(left.(float64), right.(float64), this.EqualityTolerance)
+		return equal == (stage.symbol == EQ), nil
+	}
+
+	if this.Collation != nil && isString(left) && isString(right) {
+
+		result, handled := collateStrings(stage.symbol, this.Collation, left.(string), right.(string))
+		if handled {
+			return result, nil
+		}
+	}
+
+	if this.ExactRationals {
+
+		result, handled, err := evaluateRational(stage.symbol, left, right)
+		if handled {
+			return result, stage.locateError(err)
+		}
 	}
 
 	if this.ChecksTypes {
@@ -213,23 +640,152 @@ func (this EvaluableExpression) evaluateStage(stage *evaluationStage, parameters
 
 			err = typeCheck(stage.leftTypeCheck, left, stage.symbol, stage.typeErrorFormat)
 			if err != nil {
-				return nil, err
+				return nil, stage.locateError(addTypeHint(err, stage.symbol, stage.leftStage, left))
 			}
 
 			err = typeCheck(stage.rightTypeCheck, right, stage.symbol, stage.typeErrorFormat)
 			if err != nil {
-				return nil, err
+				return nil, stage.locateError(addTypeHint(err, stage.symbol, stage.rightStage, right))
 			}
 		} else {
 			// special case where the type check needs to know both sides to determine if the operator can handle it
 			if !stage.typeCheck(left, right) {
 				errorMsg := fmt.Sprintf(stage.typeErrorFormat, left, stage.symbol.String())
-				return nil, errors.New(errorMsg)
+
+				// the hint is about whichever side is the odd one out, which is the right side if the left is a number.
+				if isFloat64(left) {
+					return nil, stage.locateError(addTypeHint(errors.New(errorMsg), stage.symbol, stage.rightStage, right))
+				}
+				return nil, stage.locateError(addTypeHint(errors.New(errorMsg), stage.symbol, stage.leftStage, left))
+			}
+		}
+	}
+
+	var cache *FunctionCache
+	var cacheKey string
+	var cached bool
+
+	if stage.pure {
+		cacheKey, cached = functionCacheKey(stage.name, findArgumentStyle(stage.rightStage), right)
+	}
+
+	if cached {
+
+		cache = this.FunctionCache
+		if cache == nil {
+			cache = this.state.functionCache()
+		}
+
+		// a cached result still counts towards the limits below, the same as if the function had been called.
+		result, cached = cache.get(cacheKey)
+	}
+
+	if !cached {
+
+		if this.MaxStringBytes > 0 && stage.stringSize != nil {
+
+			err = this.state.checkStringSize(stage.stringSize(right), this.MaxStringBytes)
+			if err != nil {
+				return nil, stage.locateError(err)
+			}
+		}
+
+		// built-ins like "map" evaluate their sub-expressions as part of this evaluation.
+		if stage.evaluatesSubExpressions {
+			parameters = &outerEvaluation{Parameters: parameters, expression: this}
+		}
+
+		if this.Observer != nil {
+			result, err = this.observeStage(stage, left, right, parameters)
+		} else {
+			result, err = stage.operator(left, right, parameters)
+		}
+
+		if err != nil && this.RegexErrorHandler != nil && (stage.symbol == REQ || stage.symbol == NREQ) {
+			result, err = this.handlePatternError(err)
+		}
+
+		if this.NormalizesNegativeZero {
+			result = normalizeZero(result)
+		}
+
+		if cache != nil && err == nil {
+			cache.set(cacheKey, result)
+		}
+
+		if err != nil {
+			return nil, stage.locateError(err)
+		}
+	}
+
+	if this.MaxCollectionSize > 0 && stage.symbol == FUNCTIONAL {
+		err = checkCollectionSize(result, this.MaxCollectionSize)
+		if err != nil {
+			return nil, stage.locateError(err)
+		}
+	}
+
+	switch stage.symbol {
+	case PLUS, CONCAT, FUNCTIONAL, ACCESS:
+
+		// accessing a field only passes a string through, but calling a method may build one.
+		if this.MaxStringBytes > 0 && (stage.symbol != ACCESS || stage.rightStage != nil) {
+			err = this.state.allocateString(result, this.MaxStringBytes)
+		}
+	case DIVIDE:
+		if this.PrecisionMode == RoundDivision {
+			result = roundToPrecision(result, this.ResultPrecision)
+			if this.NormalizesNegativeZero {
+				result = normalizeZero(result)
 			}
 		}
+	case EXPONENT:
+		if this.ChecksExponentOverflow {
+			err = checkExponentOverflow(left, right, result)
+		}
+	case BITWISE_LSHIFT:
+		if this.ChecksShiftOverflow {
+			err = checkShiftOverflow(left, right)
+		}
+	}
+	return result, stage.locateError(err)
+}
+
+/*
+	Gives the result of a regexp comparison whose pattern couldn't be compiled, according to `RegexErrorHandler`.
+	Any other error is returned as it is.
+*/
+func (this EvaluableExpression) handlePatternError(err error) (interface{}, error) {
+
+	var compileErr *patternError
+
+	if !errors.As(err, &compileErr) {
+		return nil, err
 	}
 
-	return stage.operator(left, right, parameters)
+	matched, err := this.RegexErrorHandler(compileErr.pattern, compileErr.err)
+	if err != nil {
+		return nil, err
+	}
+	return matched, nil
+}
+
+/*
+	Evaluates the first argument of a call to "try" (whose [arguments] are the separator between them),
+	or if that returns an error, the second argument instead.
+*/
+func (this EvaluableExpression) evaluateTry(arguments *evaluationStage, parameters Parameters) (interface{}, error) {
+
+	result, err := this.evaluateStage(arguments.leftStage, parameters)
+	if err == nil {
+		return result, nil
+	}
+
+	// errors which stop the whole evaluation can't be caught.
+	if errors.Is(err, errEvaluationCancelled) || errors.Is(err, ErrRecursionTooDeep) || errors.Is(err, ErrStringBudgetExceeded) || errors.Is(err, ErrCollectionTooLarge) {
+		return nil, err
+	}
+	return this.evaluateStage(arguments.rightStage, parameters)
 }
 
 func typeCheck(check stageTypeCheck, value interface{}, symbol OperatorSymbol, format string) error {
@@ -274,3 +830,70 @@ func (this EvaluableExpression) Vars() []string {
 	}
 	return varlist
 }
+
+/*
+	Returns the name of each function called by this EvaluableExpression, in the order they appear, including built-ins.
+	Like `Vars()`, a function called more than once appears more than once.
+	Expressions parsed with a `Tokenizer` don't know the names of their functions, so those aren't included.
+*/
+func (this EvaluableExpression) Functions() []string {
+
+	var functions []string
+
+	this.Walk(func(stage StageInfo) bool {
+		if stage.Symbol == FUNCTIONAL && stage.Name != "" {
+			functions = append(functions, stage.Name)
+		}
+		return true
+	})
+	return functions
+}
+
+/*
+	Returns every literal value written in this EvaluableExpression, in the order they appear:
+	numbers (as float64, or time.Duration), strings, bools, dates (as time.Time), and regex patterns (as *regexp.Regexp).
+	Useful for reviewing expressions from elsewhere, such as for suspicious patterns or hardcoded secrets.
+*/
+func (this EvaluableExpression) Literals() []interface{} {
+	var literals []interface{}
+	for _, val := range this.Tokens() {
+		switch val.Kind {
+		case NUMERIC, STRING, BOOLEAN, TIME, PATTERN:
+			literals = append(literals, val.Value)
+		}
+	}
+	return literals
+}
+
+/*
+	Returns true if this expression always evaluates to the same result, since it uses no parameters,
+	and calls no functions except those marked as pure (see `ParsingOptions.PureFunctions`) with constant arguments.
+	Such an expression only needs to be evaluated once, and its result kept.
+*/
+func (this EvaluableExpression) IsConstant() bool {
+
+	if this.evaluationStages == nil {
+		return true
+	}
+	return isConstantStage(this.evaluationStages)
+}
+
+/*
+	Returns true if the given [stage] (and all of its children) always evaluates to the same result.
+*/
+func isConstantStage(stage *evaluationStage) bool {
+
+	if stage == nil {
+		return true
+	}
+
+	switch stage.symbol {
+	case VALUE, ACCESS:
+		return false
+	case FUNCTIONAL:
+		if !stage.pure && !stage.catchesErrors {
+			return false
+		}
+	}
+	return isConstantStage(stage.leftStage) && isConstantStage(stage.rightStage)
+}
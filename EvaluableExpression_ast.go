@@ -0,0 +1,183 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"regexp"
+	"time"
+)
+
+/*
+	Returns the parsed form of this expression as JSON, so that other programs (which needn't be written in Go)
+	can inspect or evaluate it. The result is a tree of JSON objects, each with a "type" which is one of:
+
+		"literal"   - a constant, with its "value" and its "valueType", which is "number", "string", "bool", "null", "array", "map", or "object"
+		              as for the typeof() function, or "pattern" for regex patterns (whose value is the pattern),
+		              or "duration" for durations (whose value is a number of seconds).
+		"parameter" - a parameter, with its "name".
+		"accessor"  - a field or method of a parameter, with its "name" (like "foo.Bar"), and the method's "arguments" if it's called.
+		"function"  - a function call, with the function's "name" and its list of "arguments".
+		"operator"  - an operator, with its "operator" as written (like "+" or "=="), and its "left" and "right" sides.
+		              Prefix operators (like "!") have no "left".
+		"ternary"   - a ternary, with its "condition", "then", and (unless it was left out) "else".
+		"list"      - a comma-separated list (like the right side of "in"), with its "items".
+		"index"     - an index into the result of a call (like "split(s, ',')[0]"), with the "list" being indexed and the "index".
+
+	Parenthesis aren't included, since the structure of the tree already shows what is evaluated first.
+	Parts of the expression which only use literals appear as they were written, even though they're calculated when parsed.
+	An empty expression gives "null".
+*/
+func (this EvaluableExpression) MarshalAST() ([]byte, error) {
+
+	if this.evaluationStages == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(marshalStage(this.evaluationStages))
+}
+
+/*
+	Returns the JSON AST node for the given [stage] (and all of its children). See `MarshalAST`.
+*/
+func marshalStage(stage *evaluationStage) *OrderedMap {
+
+	stage = originalStage(stage)
+	ret := NewOrderedMap()
+
+	switch stage.symbol {
+
+	case NOOP:
+		return marshalStage(stage.rightStage)
+
+	case LITERAL:
+
+		value, _ := stage.operator(nil, nil, nil)
+
+		ret.Set("type", "literal")
+		ret.Set("value", marshalLiteral(value))
+		ret.Set("valueType", describeLiteral(value))
+		return ret
+
+	case VALUE:
+
+		ret.Set("type", "parameter")
+		ret.Set("name", stage.name)
+		return ret
+
+	case ACCESS:
+
+		ret.Set("type", "accessor")
+		ret.Set("name", stage.name)
+
+		if stage.rightStage != nil {
+			ret.Set("arguments", marshalArguments(stage.rightStage))
+		}
+		return ret
+
+	case FUNCTIONAL:
+
+		ret.Set("type", "function")
+		ret.Set("name", stage.name)
+		ret.Set("arguments", marshalArguments(stage.rightStage))
+		return ret
+
+	case INDEX:
+
+		ret.Set("type", "index")
+		ret.Set("list", marshalStage(stage.leftStage))
+		ret.Set("index", marshalStage(stage.rightStage))
+		return ret
+
+	case SEPARATE:
+
+		ret.Set("type", "list")
+		ret.Set("items", marshalList(stage))
+		return ret
+
+	case TERNARY_TRUE:
+
+		ret.Set("type", "ternary")
+		ret.Set("condition", marshalStage(stage.leftStage))
+		ret.Set("then", marshalStage(stage.rightStage))
+		return ret
+
+	case TERNARY_FALSE:
+
+		// "a ? b : c" is planned as a ":" whose left side is "a ? b".
+		condition := originalStage(stage.leftStage)
+		if condition.symbol == TERNARY_TRUE {
+
+			ret.Set("type", "ternary")
+			ret.Set("condition", marshalStage(condition.leftStage))
+			ret.Set("then", marshalStage(condition.rightStage))
+			ret.Set("else", marshalStage(stage.rightStage))
+			return ret
+		}
+	}
+
+	ret.Set("type", "operator")
+	ret.Set("operator", writtenSymbol(stage.symbol))
+
+	if stage.leftStage != nil {
+		ret.Set("left", marshalStage(stage.leftStage))
+	}
+	ret.Set("right", marshalStage(stage.rightStage))
+	return ret
+}
+
+/*
+	Returns the AST nodes for the parenthesized arguments of a function or method call, given by [stage].
+*/
+func marshalArguments(stage *evaluationStage) []*OrderedMap {
+
+	if stage == nil || stage.rightStage == nil {
+		return []*OrderedMap{}
+	}
+	return marshalList(originalStage(stage.rightStage))
+}
+
+/*
+	Returns the AST nodes for each of the items in a comma-separated list, given by its last SEPARATE [stage].
+	Anything other than a SEPARATE stage is a list of one item.
+*/
+func marshalList(stage *evaluationStage) []*OrderedMap {
+
+	if stage.symbol != SEPARATE {
+		return []*OrderedMap{marshalStage(stage)}
+	}
+	return append(marshalList(originalStage(stage.leftStage)), marshalList(originalStage(stage.rightStage))...)
+}
+
+/*
+	Returns the given literal [value] in a form which can be written as JSON.
+*/
+func marshalLiteral(value interface{}) interface{} {
+
+	switch value.(type) {
+	case *regexp.Regexp:
+		return value.(*regexp.Regexp).String()
+	case time.Duration:
+		return value.(time.Duration).Seconds()
+	}
+	return value
+}
+
+func describeLiteral(value interface{}) string {
+
+	switch value.(type) {
+	case *regexp.Regexp:
+		return "pattern"
+	case time.Duration:
+		return "duration"
+	}
+	return friendlyTypeName(value)
+}
+
+/*
+	Returns the given [symbol] as it's written in an expression.
+*/
+func writtenSymbol(symbol OperatorSymbol) string {
+
+	if symbol == EQ {
+		return "=="
+	}
+	return symbol.String()
+}
@@ -0,0 +1,119 @@
+package govaluate
+
+/*
+	Returns a copy of this expression in which the parameter of the given [name] always has the given [value],
+	as if it had been written as a literal. Any parts of the expression which then only use literals are
+	calculated once, here, rather than on every evaluation.
+	This is useful when evaluating the same expression many times while only some of its parameters change.
+
+	Accessors on the parameter (like "foo.Bar") are still evaluated each time, using the bound [value].
+	Values which can be written as literals (numbers, strings, and bools) also replace the parameter in the copy's `Tokens()` and `Vars()`.
+	This expression is left unchanged.
+*/
+func (this EvaluableExpression) BindParameter(name string, value interface{}) *EvaluableExpression {
+
+	value = castToFloat64(value)
+
+	ret := this
+	ret.tokens = bindTokens(this.tokens, name, value)
+	ret.parameterSlots = findParameterSlots(ret.tokens)
+
+	if this.evaluationStages != nil {
+		ret.evaluationStages = elideLiterals(bindStage(this.evaluationStages, name, value))
+		assignParameterSlots(ret.evaluationStages, ret.parameterSlots)
+		ret.fastFloat = compileFloatProgram(ret.evaluationStages)
+	}
+	return &ret
+}
+
+/*
+	Returns a copy of the given [stage] (and all of its children), with every use of the parameter [name] bound to [value].
+*/
+func bindStage(stage *evaluationStage, name string, value interface{}) *evaluationStage {
+
+	ret := *stage
+
+	if stage.leftStage != nil {
+		ret.leftStage = bindStage(stage.leftStage, name, value)
+	}
+	if stage.rightStage != nil {
+		ret.rightStage = bindStage(stage.rightStage, name, value)
+	}
+	if stage.elided != nil {
+		ret.elided = bindStage(stage.elided, name, value)
+	}
+
+	switch stage.symbol {
+	case VALUE:
+		if stage.name == name {
+			ret.symbol = LITERAL
+			ret.name = ""
+			ret.operator = makeLiteralStage(value)
+		}
+	case ACCESS:
+		if stage.accessesParameter(name) {
+			ret.operator = makeBoundOperator(stage.operator, name, value)
+		}
+	}
+	return &ret
+}
+
+/*
+	Returns true if this (ACCESS) stage accesses a field or method of the parameter of the given [name].
+*/
+func (this *evaluationStage) accessesParameter(name string) bool {
+	return len(this.name) > len(name) && this.name[:len(name)] == name && this.name[len(name)] == '.'
+}
+
+/*
+	Wraps the given [operator] so that it always sees the parameter of the given [name] as [value].
+*/
+func makeBoundOperator(operator evaluationOperator, name string, value interface{}) evaluationOperator {
+
+	return func(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+		return operator(left, right, boundParameters{name: name, value: value, parameters: parameters})
+	}
+}
+
+type boundParameters struct {
+	name       string
+	value      interface{}
+	parameters Parameters
+}
+
+func (this boundParameters) Get(name string) (interface{}, error) {
+
+	if name == this.name {
+		return this.value, nil
+	}
+	return this.parameters.Get(name)
+}
+
+/*
+	Returns a copy of the given [tokens], with each use of the parameter [name] replaced by a literal [value] (if it can be written as one).
+*/
+func bindTokens(tokens []ExpressionToken, name string, value interface{}) []ExpressionToken {
+
+	var kind TokenKind
+
+	switch value.(type) {
+	case float64:
+		kind = NUMERIC
+	case string:
+		kind = STRING
+	case bool:
+		kind = BOOLEAN
+	default:
+		return tokens
+	}
+
+	ret := make([]ExpressionToken, len(tokens))
+	for i, token := range tokens {
+
+		if token.Kind == VARIABLE && token.Value == name {
+			token = ExpressionToken{Kind: kind, Value: value}
+		}
+		ret[i] = token
+	}
+	return ret
+}
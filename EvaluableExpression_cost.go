@@ -0,0 +1,53 @@
+package govaluate
+
+/*
+	The relative cost of evaluating each kind of stage, used by EstimateCost().
+	Any symbol not listed here costs 1.
+*/
+var stageCosts = map[OperatorSymbol]int{
+	LITERAL:    0,
+	NOOP:       0,
+	SEPARATE:   0,
+	EXPONENT:   5,
+	IN:         2,
+	REQ:        10,
+	NREQ:       10,
+	ACCESS:     4,
+	FUNCTIONAL: 5,
+}
+
+/*
+	Regex comparisons against a pattern which isn't a literal have to compile that pattern every time they're evaluated,
+	which costs this much more.
+*/
+const regexCompileCost int = 40
+
+/*
+	Returns a rough estimate of how expensive this expression is to evaluate, by adding up a weight for each of its stages.
+	Literals are free, regex comparisons, exponents, accessors and function calls are expensive, and most other operators cost 1.
+	Every stage is counted, even those which may be skipped by short-circuiting, so this is a worst case.
+
+	This is only a static heuristic - it knows nothing about how expensive any given function actually is, for instance -
+	but it's useful for refusing to run expressions which are obviously too complex, such as when they come from untrusted users.
+*/
+func (this EvaluableExpression) EstimateCost() int {
+
+	var cost int
+
+	this.Walk(func(stage StageInfo) bool {
+
+		weight, found := stageCosts[stage.Symbol]
+		if !found {
+			weight = 1
+		}
+
+		if (stage.Symbol == REQ || stage.Symbol == NREQ) && stage.Right.Symbol != LITERAL {
+			weight += regexCompileCost
+		}
+
+		cost += weight
+		return true
+	})
+
+	return cost
+}
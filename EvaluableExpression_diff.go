@@ -0,0 +1,79 @@
+package govaluate
+
+import (
+	"sort"
+)
+
+/*
+	Describes how the parameters and functions used by one expression differ from those used by another. See `EvaluableExpression.Diff()`.
+	Each list is sorted, and has each name only once. A list is empty (nil) if nothing was added or removed.
+*/
+type ExpressionDiff struct {
+
+	// parameters which are used by the other expression, but not by this one.
+	AddedParameters []string
+
+	// parameters which are used by this expression, but not by the other one.
+	RemovedParameters []string
+
+	// functions which are called by the other expression, but not by this one.
+	AddedFunctions []string
+
+	// functions which are called by this expression, but not by the other one.
+	RemovedFunctions []string
+}
+
+/*
+	Returns true if neither expression uses any parameter or function that the other doesn't.
+*/
+func (this ExpressionDiff) IsEmpty() bool {
+
+	return len(this.AddedParameters) == 0 && len(this.RemovedParameters) == 0 &&
+		len(this.AddedFunctions) == 0 && len(this.RemovedFunctions) == 0
+}
+
+/*
+	Compares the parameters (from `ParameterSlots()`, so including those only used through accessors, like "user" in "user.Age")
+	and functions (from `Functions()`) used by this expression with those used by [other], as when reviewing a change to a rule,
+	where this is the rule before the change and [other] is the rule after it. Only which names are used is compared, not how they're used.
+*/
+func (this EvaluableExpression) Diff(other *EvaluableExpression) ExpressionDiff {
+
+	var ret ExpressionDiff
+
+	ret.AddedParameters, ret.RemovedParameters = diffNames(this.ParameterSlots(), other.ParameterSlots())
+	ret.AddedFunctions, ret.RemovedFunctions = diffNames(this.Functions(), other.Functions())
+	return ret
+}
+
+/*
+	Returns the names which are in [after] but not in [before], and those which are in [before] but not in [after],
+	each sorted and without duplicates.
+*/
+func diffNames(before []string, after []string) ([]string, []string) {
+
+	return namesMissingFrom(after, before), namesMissingFrom(before, after)
+}
+
+/*
+	Returns the sorted, unique names of [names] which aren't in [from].
+*/
+func namesMissingFrom(names []string, from []string) []string {
+
+	var ret []string
+
+	found := make(map[string]bool)
+	for _, name := range from {
+		found[name] = true
+	}
+
+	for _, name := range names {
+		if !found[name] {
+			ret = append(ret, name)
+			found[name] = true
+		}
+	}
+
+	sort.Strings(ret)
+	return ret
+}
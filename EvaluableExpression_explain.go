@@ -0,0 +1,152 @@
+package govaluate
+
+/*
+	Records a single decision made while evaluating an expression, by a ternary, "&&", or "||". See `EvaluateExplained`.
+*/
+type Decision struct {
+
+	/*
+		The byte offsets in the expression string of the condition the decision was made on,
+		so that expression[Start:End] is the condition itself.
+		Both are zero for expressions made with `NewEvaluableExpressionFromTokens`, which don't know where their tokens came from.
+	*/
+	Start, End int
+
+	/*
+		The operator which made the decision; TERNARY_TRUE for ternaries, or AND or OR.
+	*/
+	Symbol OperatorSymbol
+
+	/*
+		The value of the condition (after any `Truthiness` conversion).
+	*/
+	Condition interface{}
+
+	/*
+		Whether the condition let evaluation go on to the branch after it.
+		For ternaries, this means the "then" branch was taken (and otherwise the "else" branch was).
+		For "&&" and "||", this means the right side was evaluated (and otherwise the condition alone decided the result).
+	*/
+	Taken bool
+}
+
+/*
+	Same as `Evaluate`, but also returns every decision that was made on the way to the result, in the order they were made.
+	Useful for explaining why an expression (such as a rule) gave the result it did.
+	Decisions which were made before an error are still returned along with it.
+*/
+func (this EvaluableExpression) EvaluateExplained(parameters map[string]interface{}) (interface{}, []Decision, error) {
+
+	state := new(evaluationState)
+	state.explaining = true
+	this.state = state
+
+	result, err := this.Evaluate(parameters)
+	return result, state.decisions, err
+}
+
+/*
+	Records the decision made by [stage] (if it makes one) with the given value of its [condition].
+*/
+func (this *evaluationState) recordDecision(stage *evaluationStage, condition interface{}) {
+
+	var taken bool
+
+	switch stage.symbol {
+	case AND:
+		fallthrough
+	case TERNARY_TRUE:
+		taken = condition != false
+	case OR:
+		taken = condition != true
+	default:
+		return
+	}
+
+	decision := Decision{
+		Symbol:    stage.symbol,
+		Condition: condition,
+		Taken:     taken,
+	}
+
+	if stage.leftStage != nil {
+		decision.Start = stage.leftStage.start
+		decision.End = stage.leftStage.end
+	}
+
+	this.decisions = append(this.decisions, decision)
+}
+
+/*
+	The outcome of a single comparison ("==", "!=", ">", ">=", "<", "<=", "=~", "!~", or "in") within an expression. See `EvaluateConditions`.
+*/
+type Condition struct {
+
+	/*
+		The byte offsets in the expression string of the comparison, so that expression[Start:End] is the comparison itself.
+		Both are zero for expressions made with `NewEvaluableExpressionFromTokens`, which don't know where their tokens came from.
+	*/
+	Start, End int
+
+	/*
+		The source text of the comparison (such as "temperature > 30"), or an empty string if it isn't known.
+	*/
+	Text string
+
+	/*
+		The comparison's operator.
+	*/
+	Symbol OperatorSymbol
+
+	/*
+		Whether the comparison was true.
+	*/
+	Result bool
+}
+
+/*
+	Same as `Evaluate`, but also returns the outcome of every comparison which was evaluated on the way to the result, in the order they finished.
+	Useful for showing which thresholds of a rule were tripped, rather than just whether the whole rule was.
+	Comparisons which weren't evaluated (because they were short-circuited), or whose sides were all literals, aren't included.
+	Conditions which were evaluated before an error are still returned along with it.
+*/
+func (this EvaluableExpression) EvaluateConditions(parameters map[string]interface{}) (interface{}, []Condition, error) {
+
+	state := new(evaluationState)
+	state.recordsConditions = true
+	this.state = state
+
+	result, err := this.Evaluate(parameters)
+	return result, state.conditions, err
+}
+
+/*
+	Evaluates the comparison [stage], and records its outcome.
+*/
+func (this EvaluableExpression) evaluateCondition(stage *evaluationStage, parameters Parameters) (interface{}, error) {
+
+	// the stage is evaluated as usual, except that it mustn't be recorded again; any comparisons within it still are.
+	outer := this.state.conditionStage
+	this.state.conditionStage = stage
+
+	result, err := this.evaluateStage(stage, parameters)
+
+	this.state.conditionStage = outer
+	if err != nil {
+		return nil, err
+	}
+
+	condition := Condition{
+		Start:  stage.start,
+		End:    stage.end,
+		Symbol: stage.symbol,
+		Result: result == true,
+	}
+
+	if stage.end > 0 && stage.end <= len(this.inputExpression) {
+		condition.Text = this.inputExpression[stage.start:stage.end]
+	}
+
+	this.state.conditions = append(this.state.conditions, condition)
+	return result, nil
+}
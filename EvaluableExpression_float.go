@@ -0,0 +1,371 @@
+package govaluate
+
+import (
+	"errors"
+	"math"
+)
+
+type floatNumberStage func(parameters map[string]float64) (float64, error)
+type floatBoolStage func(parameters map[string]float64) (bool, error)
+
+/*
+	An expression compiled so that it can be evaluated entirely with float64 and bool values, without boxing any of them in an interface{}.
+	Exactly one of [number] and [boolean] is set, depending on what the whole expression returns.
+*/
+type floatProgram struct {
+	number  floatNumberStage
+	boolean floatBoolStage
+}
+
+/*
+	Evaluates this expression with parameters which are all float64, which (for expressions that only need numbers) is much faster than `Evaluate`.
+	Returns either a float64 or a bool, as `Evaluate` would.
+
+	The fast path is used for expressions which only contain numeric literals, parameters, arithmetic, comparisons, logical operators,
+	and ternaries (but not functions, accessors, strings, etc.), and which don't use any options that change evaluation (like PrecisionMode).
+	Any other expression is evaluated exactly as `Evaluate` would, which is slower than calling `Evaluate` directly,
+	since the parameters need to be copied. See `CanEvaluateFloatFast`.
+*/
+func (this EvaluableExpression) EvaluateFloatFast(parameters map[string]float64) (interface{}, error) {
+
+	if !this.CanEvaluateFloatFast() {
+
+		converted := make(map[string]interface{}, len(parameters))
+		for name, value := range parameters {
+			converted[name] = value
+		}
+		return this.Evaluate(converted)
+	}
+
+	if this.fastFloat.number != nil {
+		return this.fastFloat.number(parameters)
+	}
+	return this.fastFloat.boolean(parameters)
+}
+
+/*
+	Returns true if `EvaluateFloatFast` is able to use its fast path for this expression, as it is currently configured.
+*/
+func (this EvaluableExpression) CanEvaluateFloatFast() bool {
+
+	// the fast path uses the literals which were calculated at parse time, and computes everything else as Evaluate would
+	// without any options, so any option which would change either of those (as well as what happens around evaluation) rules it out.
+	return this.fastFloat != nil &&
+		!this.evaluatesElidedStages() &&
+		this.Observer == nil &&
+		this.PrecisionMode == NoRounding &&
+		this.MaxRecursionDepth == 0 &&
+		this.Truthiness == nil &&
+		this.Coercer == nil &&
+		!this.NormalizesNegativeZero &&
+		this.NumericResultKind == AlwaysFloat64
+}
+
+/*
+	Compiles the given [stage] (and all of its children) into a floatProgram.
+	Returns nil if any part of it can't be evaluated with only float64 and bool values.
+*/
+func compileFloatProgram(stage *evaluationStage) *floatProgram {
+
+	if stage == nil {
+		return nil
+	}
+
+	number := compileFloatNumber(stage)
+	if number != nil {
+		return &floatProgram{number: number}
+	}
+
+	boolean := compileFloatBool(stage)
+	if boolean != nil {
+		return &floatProgram{boolean: boolean}
+	}
+	return nil
+}
+
+/*
+	Compiles a [stage] which returns a number. Returns nil if it doesn't, or can't be compiled.
+*/
+func compileFloatNumber(stage *evaluationStage) floatNumberStage {
+
+	switch stage.symbol {
+
+	case LITERAL:
+
+		value, err := stage.operator(nil, nil, nil)
+		number, ok := value.(float64)
+		if err != nil || !ok {
+			return nil
+		}
+
+		return func(parameters map[string]float64) (float64, error) {
+			return number, nil
+		}
+
+	case VALUE:
+
+		name := stage.name
+		return func(parameters map[string]float64) (float64, error) {
+
+			value, found := parameters[name]
+			if !found {
+				// located the same as `Evaluate` would, so that both give the same *EvaluationError.
+				return 0, stage.locateError(errors.New("No parameter '" + name + "' found."))
+			}
+			return value, nil
+		}
+
+	case NOOP:
+		if stage.rightStage == nil {
+			return nil
+		}
+		return compileFloatNumber(stage.rightStage)
+
+	case NEGATE:
+
+		right := compileFloatNumber(stage.rightStage)
+		if right == nil {
+			return nil
+		}
+
+		return func(parameters map[string]float64) (float64, error) {
+			value, err := right(parameters)
+			return -value, err
+		}
+
+	case TERNARY_FALSE:
+		return compileFloatTernary(stage)
+	}
+
+	if stage.leftStage == nil || stage.rightStage == nil {
+		return nil
+	}
+
+	var operation func(float64, float64) float64
+
+	switch stage.symbol {
+	case PLUS:
+		operation = func(left float64, right float64) float64 { return left + right }
+	case MINUS:
+		operation = func(left float64, right float64) float64 { return left - right }
+	case MULTIPLY:
+		operation = func(left float64, right float64) float64 { return left * right }
+	case DIVIDE:
+		operation = func(left float64, right float64) float64 { return left / right }
+	case MODULUS:
+		operation = math.Mod
+	case EXPONENT:
+		operation = math.Pow
+	default:
+		return nil
+	}
+
+	left := compileFloatNumber(stage.leftStage)
+	right := compileFloatNumber(stage.rightStage)
+	if left == nil || right == nil {
+		return nil
+	}
+
+	return func(parameters map[string]float64) (float64, error) {
+
+		leftValue, err := left(parameters)
+		if err != nil {
+			return 0, err
+		}
+
+		rightValue, err := right(parameters)
+		if err != nil {
+			return 0, err
+		}
+		return operation(leftValue, rightValue), nil
+	}
+}
+
+/*
+	Compiles a ternary "a ? b : c" [stage] whose branches both return numbers.
+*/
+func compileFloatTernary(stage *evaluationStage) floatNumberStage {
+
+	if stage.leftStage == nil || stage.leftStage.symbol != TERNARY_TRUE || stage.leftStage.rightStage == nil || stage.rightStage == nil {
+		return nil
+	}
+
+	condition := compileFloatBool(stage.leftStage.leftStage)
+	then := compileFloatNumber(stage.leftStage.rightStage)
+	otherwise := compileFloatNumber(stage.rightStage)
+
+	if condition == nil || then == nil || otherwise == nil {
+		return nil
+	}
+
+	return func(parameters map[string]float64) (float64, error) {
+
+		value, err := condition(parameters)
+		if err != nil {
+			return 0, err
+		}
+
+		if value {
+			return then(parameters)
+		}
+		return otherwise(parameters)
+	}
+}
+
+/*
+	Compiles a [stage] which returns a bool. Returns nil if it doesn't, or can't be compiled.
+*/
+func compileFloatBool(stage *evaluationStage) floatBoolStage {
+
+	if stage == nil {
+		return nil
+	}
+
+	switch stage.symbol {
+
+	case LITERAL:
+
+		value, err := stage.operator(nil, nil, nil)
+		boolean, ok := value.(bool)
+		if err != nil || !ok {
+			return nil
+		}
+
+		return func(parameters map[string]float64) (bool, error) {
+			return boolean, nil
+		}
+
+	case NOOP:
+		return compileFloatBool(stage.rightStage)
+
+	case INVERT:
+
+		right := compileFloatBool(stage.rightStage)
+		if right == nil {
+			return nil
+		}
+
+		return func(parameters map[string]float64) (bool, error) {
+			value, err := right(parameters)
+			return !value, err
+		}
+
+	case AND:
+		fallthrough
+	case OR:
+		return compileFloatLogic(stage)
+
+	case EQ:
+		fallthrough
+	case NEQ:
+
+		// equality can also compare two bools.
+		equality := compileFloatBoolEquality(stage)
+		if equality != nil {
+			return equality
+		}
+	}
+
+	if stage.leftStage == nil || stage.rightStage == nil {
+		return nil
+	}
+
+	var comparison func(float64, float64) bool
+
+	switch stage.symbol {
+	case GT:
+		comparison = func(left float64, right float64) bool { return left > right }
+	case LT:
+		comparison = func(left float64, right float64) bool { return left < right }
+	case GTE:
+		comparison = func(left float64, right float64) bool { return left >= right }
+	case LTE:
+		comparison = func(left float64, right float64) bool { return left <= right }
+	case EQ:
+		comparison = func(left float64, right float64) bool { return left == right }
+	case NEQ:
+		comparison = func(left float64, right float64) bool { return left != right }
+	default:
+		return nil
+	}
+
+	left := compileFloatNumber(stage.leftStage)
+	right := compileFloatNumber(stage.rightStage)
+	if left == nil || right == nil {
+		return nil
+	}
+
+	return func(parameters map[string]float64) (bool, error) {
+
+		leftValue, err := left(parameters)
+		if err != nil {
+			return false, err
+		}
+
+		rightValue, err := right(parameters)
+		if err != nil {
+			return false, err
+		}
+		return comparison(leftValue, rightValue), nil
+	}
+}
+
+/*
+	Compiles a logical "&&" or "||" [stage], which short-circuits just as normal evaluation does.
+*/
+func compileFloatLogic(stage *evaluationStage) floatBoolStage {
+
+	if stage.leftStage == nil || stage.rightStage == nil {
+		return nil
+	}
+
+	left := compileFloatBool(stage.leftStage)
+	right := compileFloatBool(stage.rightStage)
+	if left == nil || right == nil {
+		return nil
+	}
+
+	// "&&" can stop as soon as the left side is false, "||" as soon as it's true.
+	shortCircuit := stage.symbol == OR
+
+	return func(parameters map[string]float64) (bool, error) {
+
+		value, err := left(parameters)
+		if err != nil || value == shortCircuit {
+			return value, err
+		}
+		return right(parameters)
+	}
+}
+
+/*
+	Compiles an "==" or "!=" [stage] which compares two bools.
+*/
+func compileFloatBoolEquality(stage *evaluationStage) floatBoolStage {
+
+	if stage.leftStage == nil || stage.rightStage == nil {
+		return nil
+	}
+
+	left := compileFloatBool(stage.leftStage)
+	right := compileFloatBool(stage.rightStage)
+	if left == nil || right == nil {
+		return nil
+	}
+
+	equal := stage.symbol == EQ
+
+	return func(parameters map[string]float64) (bool, error) {
+
+		leftValue, err := left(parameters)
+		if err != nil {
+			return false, err
+		}
+
+		rightValue, err := right(parameters)
+		if err != nil {
+			return false, err
+		}
+		return (leftValue == rightValue) == equal, nil
+	}
+}
@@ -0,0 +1,221 @@
+package govaluate
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*
+	Checks this expression for errors without fully evaluating it, returning every error found rather than just the first.
+
+	Any parameters given are used to work out the values of as much of the expression as possible.
+	Parameters which aren't given (and the results of functions and accessors, which are never called) are treated as unknown;
+	parts of the expression which depend on them can't be checked, and are assumed to be correct.
+	Unlike evaluation, both sides of logical operators and ternaries are always checked.
+
+	Types are always checked, regardless of this expression's `ChecksTypes` setting.
+	Returns nil if no errors were found.
+*/
+func (this EvaluableExpression) Lint(parameters map[string]interface{}) []error {
+
+	var errs []error
+
+	if this.evaluationStages == nil {
+		return nil
+	}
+
+	lintStage(this.evaluationStages, &sanitizedParameters{orig: MapParameters(parameters)}, &errs)
+	return errs
+}
+
+/*
+	Checks the given [stage] and all of its children, adding any errors found to [errs].
+	Returns the value of the stage, and true if that value could be known.
+*/
+func lintStage(stage *evaluationStage, parameters Parameters, errs *[]error) (interface{}, bool) {
+
+	var left, right interface{}
+	var leftKnown, rightKnown bool
+	var err error
+
+	leftKnown = true
+	rightKnown = true
+
+	if stage.leftStage != nil {
+		left, leftKnown = lintStage(stage.leftStage, parameters, errs)
+	}
+
+	if stage.rightStage != nil {
+		right, rightKnown = lintStage(stage.rightStage, parameters, errs)
+	}
+
+	switch stage.symbol {
+
+	case VALUE:
+
+		// missing parameters aren't errors here, they're just unknown.
+		value, err := stage.operator(nil, nil, parameters)
+		return value, err == nil
+
+	case FUNCTIONAL:
+		fallthrough
+	case ACCESS:
+		return nil, false
+	}
+
+	if stage.typeCheck == nil {
+
+		if leftKnown {
+			err = typeCheck(stage.leftTypeCheck, left, stage.symbol, stage.typeErrorFormat)
+			if err != nil {
+				*errs = append(*errs, err)
+				return nil, false
+			}
+		}
+
+		if rightKnown {
+			err = typeCheck(stage.rightTypeCheck, right, stage.symbol, stage.typeErrorFormat)
+			if err != nil {
+				*errs = append(*errs, err)
+				return nil, false
+			}
+		}
+	}
+
+	if !leftKnown || !rightKnown {
+		return nil, false
+	}
+
+	if stage.typeCheck != nil && !stage.typeCheck(left, right) {
+
+		errorMsg := fmt.Sprintf(stage.typeErrorFormat, left, stage.symbol.String())
+		*errs = append(*errs, errors.New(errorMsg))
+		return nil, false
+	}
+
+	value, err := stage.operator(left, right, parameters)
+	if err != nil {
+		*errs = append(*errs, err)
+		return nil, false
+	}
+
+	return value, true
+}
+
+/*
+	Describes a part of an expression which is probably a mistake, as found by `LintDeadLogic`.
+*/
+type Finding struct {
+
+	/*
+		The byte offsets in the expression string of the part this finding is about, as with `EvaluationError`.
+		Both are zero if the expression wasn't parsed from a string.
+	*/
+	Start, End int
+
+	/*
+		A description of the problem.
+	*/
+	Message string
+}
+
+/*
+	Looks for logic in this expression which doesn't depend on any parameters because of a literal,
+	such as "x > 5 || true" (which is always true) or "a && false" (which is always false).
+	These are usually left over from debugging, or copied from elsewhere by mistake, and stop the rest of the logic from mattering.
+
+	Only literals (including literal subexpressions, like "(1 > 2)") are considered; nothing is evaluated.
+	Returns nil if nothing was found.
+*/
+func (this EvaluableExpression) LintDeadLogic() []Finding {
+
+	var findings []Finding
+
+	if this.evaluationStages == nil {
+		return nil
+	}
+
+	findDeadLogic(this.evaluationStages, &findings)
+	return findings
+}
+
+/*
+	Checks the given [stage] and all of its children for dead logic, adding anything found to [findings].
+*/
+func findDeadLogic(stage *evaluationStage, findings *[]Finding) {
+
+	// literals calculated at parse time are checked as they were written.
+	if stage.elided != nil {
+		findDeadLogic(stage.elided, findings)
+		return
+	}
+
+	var message string
+
+	switch stage.symbol {
+	case AND:
+		if isLiteralValue(stage.leftStage, false) || isLiteralValue(stage.rightStage, false) {
+			message = "Logical AND is always false, since one side is the literal false"
+		}
+	case OR:
+		if isLiteralValue(stage.leftStage, true) || isLiteralValue(stage.rightStage, true) {
+			message = "Logical OR is always true, since one side is the literal true"
+		}
+	case TERNARY_TRUE:
+		if isLiteralValue(stage.leftStage, true) || isLiteralValue(stage.leftStage, false) {
+			message = "Ternary condition is a literal, so the same branch is always taken"
+		}
+	case COALESCE:
+		if isLiteral(stage.leftStage) {
+			message = "Null coalescence always returns its left side, since it is a literal"
+		}
+	}
+
+	if message != "" {
+		*findings = append(*findings, Finding{
+			Start:   stage.start,
+			End:     stage.end,
+			Message: message,
+		})
+	}
+
+	if stage.leftStage != nil {
+		findDeadLogic(stage.leftStage, findings)
+	}
+	if stage.rightStage != nil {
+		findDeadLogic(stage.rightStage, findings)
+	}
+}
+
+/*
+	Returns true if the given [stage] is a literal with the given [value].
+*/
+func isLiteralValue(stage *evaluationStage, value interface{}) bool {
+
+	if !isLiteral(stage) {
+		return false
+	}
+
+	literal, err := unwrapClauses(stage).operator(nil, nil, nil)
+	return err == nil && literal == value
+}
+
+/*
+	Returns true if the given [stage] is a literal, even if it's in parenthesis.
+*/
+func isLiteral(stage *evaluationStage) bool {
+
+	stage = unwrapClauses(stage)
+	return stage != nil && stage.symbol == LITERAL
+}
+
+/*
+	Returns the stage within any parenthesis around the given [stage].
+*/
+func unwrapClauses(stage *evaluationStage) *evaluationStage {
+
+	for stage != nil && stage.symbol == NOOP {
+		stage = stage.rightStage
+	}
+	return stage
+}
@@ -0,0 +1,76 @@
+package govaluate
+
+/*
+	Returns a copy of this expression in which every call to a pure function (see `ParsingOptions.PureFunctions`)
+	whose arguments are all literals has been made once, here, and replaced by its result.
+	Any parts of the expression which then only use literals are calculated here too, rather than on every evaluation.
+
+	Calls which return an error are left as they are, so that the error is still returned when the copy is evaluated.
+	The copy's `Tokens()` and `String()` are unchanged. This expression is left unchanged.
+*/
+func (this EvaluableExpression) Simplify() *EvaluableExpression {
+
+	ret := this
+
+	if this.evaluationStages != nil && this.callsPureFunctions {
+		ret.evaluationStages = elideLiterals(simplifyStage(this.evaluationStages))
+		assignParameterSlots(ret.evaluationStages, ret.parameterSlots)
+		ret.fastFloat = compileFloatProgram(ret.evaluationStages)
+	}
+	return &ret
+}
+
+/*
+	Returns a copy of the given [stage] (and all of its children), with calls to pure functions of literals replaced by their results.
+*/
+func simplifyStage(stage *evaluationStage) *evaluationStage {
+
+	ret := *stage
+
+	if stage.leftStage != nil {
+		ret.leftStage = simplifyStage(stage.leftStage)
+	}
+	if stage.rightStage != nil {
+		ret.rightStage = elideLiterals(simplifyStage(stage.rightStage))
+	}
+
+	if !stage.pure || !isLiteralStage(ret.rightStage) {
+		return &ret
+	}
+
+	// arguments are only literals, so there's nothing here which needs any of the expression's options.
+	arguments, err := EvaluableExpression{}.evaluateStage(ret.rightStage, DUMMY_PARAMETERS)
+	if err != nil {
+		return &ret
+	}
+
+	result, err := ret.operator(nil, arguments, DUMMY_PARAMETERS)
+	if err != nil {
+		return &ret
+	}
+
+	return &evaluationStage{
+		symbol:   LITERAL,
+		operator: makeLiteralStage(result),
+		elided:   &ret,
+
+		start: stage.start,
+		end:   stage.end,
+	}
+}
+
+/*
+	Returns true if the given [stage] is made only of literals (such as the parenthesized arguments of a function call).
+*/
+func isLiteralStage(stage *evaluationStage) bool {
+
+	if stage == nil {
+		return true
+	}
+
+	switch stage.symbol {
+	case LITERAL, NOOP, SEPARATE:
+		return isLiteralStage(stage.leftStage) && isLiteralStage(stage.rightStage)
+	}
+	return false
+}
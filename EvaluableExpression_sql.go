@@ -77,6 +77,9 @@ func (this EvaluableExpression) findNextSQLString(stream *tokenStream, transacti
 		ret = fmt.Sprintf("[%s]", token.Value.(string))
 
 	case NUMERIC:
+		if isDuration(token.Value) {
+			return "", errors.New("Duration literals are unsupported in SQL output")
+		}
 		ret = fmt.Sprintf("%g", token.Value.(float64))
 
 	case COMPARATOR:
@@ -148,10 +151,23 @@ func (this EvaluableExpression) findNextSQLString(stream *tokenStream, transacti
 			}
 
 			ret = fmt.Sprintf("MOD(%s, %s)", left, right)
+		case CONCAT:
+
+			left := transactions.rollback()
+			right, err := this.findNextSQLString(stream, transactions)
+			if err != nil {
+				return "", err
+			}
+
+			ret = fmt.Sprintf("CONCAT(%s, %s)", left, right)
 		default:
 			ret = fmt.Sprintf("%s", token.Value.(string))
 		}
 	case CLAUSE:
+
+		if token.Value == '[' {
+			return "", errors.New("Indexes can't be converted to SQL")
+		}
 		ret = "("
 	case CLAUSE_CLOSE:
 		ret = ")"
@@ -0,0 +1,114 @@
+package govaluate
+
+import (
+	"sync"
+)
+
+/*
+	The result of evaluating an expression with one set of parameters from `EvaluateStream`.
+*/
+type Result struct {
+
+	// the parameters which were evaluated.
+	Parameters map[string]interface{}
+
+	Value interface{}
+	Error error
+}
+
+/*
+	Evaluates this expression with each set of parameters received from [in], and sends a Result for each one to the returned channel,
+	which is closed once [in] is closed and every result has been sent.
+
+	Evaluation happens on `StreamWorkers` goroutines. Results are sent in the order their parameters were received,
+	unless `StreamUnordered` is set. Results must be received, or evaluation stops until they are.
+*/
+func (this EvaluableExpression) EvaluateStream(in <-chan map[string]interface{}) <-chan Result {
+
+	out := make(chan Result)
+
+	workers := this.StreamWorkers
+	if workers < 1 {
+		workers = 1
+	}
+
+	if workers == 1 || this.StreamUnordered {
+		go this.streamUnordered(in, out, workers)
+	} else {
+		go this.streamOrdered(in, out, workers)
+	}
+	return out
+}
+
+/*
+	Evaluates this expression with the given [parameters], as the Result which `EvaluateStream` sends.
+*/
+func (this EvaluableExpression) streamResult(parameters map[string]interface{}) Result {
+
+	value, err := this.Evaluate(parameters)
+	return Result{Parameters: parameters, Value: value, Error: err}
+}
+
+/*
+	Evaluates parameters from [in] on the given number of [workers], sending each result to [out] as soon as it's ready.
+	With only one worker, that's also the order the parameters were received in.
+*/
+func (this EvaluableExpression) streamUnordered(in <-chan map[string]interface{}, out chan<- Result, workers int) {
+
+	var waitGroup sync.WaitGroup
+
+	waitGroup.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+
+			defer waitGroup.Done()
+			for parameters := range in {
+				out <- this.streamResult(parameters)
+			}
+		}()
+	}
+
+	waitGroup.Wait()
+	close(out)
+}
+
+/*
+	Evaluates parameters from [in] on the given number of [workers], sending results to [out] in the order the parameters were received.
+	Each set of parameters is given a channel for its result, which are queued (in order) for this goroutine to wait on.
+*/
+func (this EvaluableExpression) streamOrdered(in <-chan map[string]interface{}, out chan<- Result, workers int) {
+
+	type job struct {
+		parameters map[string]interface{}
+		result     chan Result
+	}
+
+	jobs := make(chan job)
+	pending := make(chan chan Result, workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for next := range jobs {
+				next.result <- this.streamResult(next.parameters)
+			}
+		}()
+	}
+
+	go func() {
+
+		for parameters := range in {
+
+			result := make(chan Result, 1)
+			pending <- result
+			jobs <- job{parameters: parameters, result: result}
+		}
+
+		close(jobs)
+		close(pending)
+	}()
+
+	for result := range pending {
+		out <- <-result
+	}
+	close(out)
+}
@@ -0,0 +1,128 @@
+package govaluate
+
+import (
+	"strings"
+)
+
+/*
+	Returns a copy of this expression in which each parameter named by a key of [mapping] is renamed to the corresponding value,
+	without parsing the expression again. So with a mapping of {"age": "user_age"}, "age > 18" becomes "user_age > 18".
+	Parameters which aren't in the mapping keep their names.
+
+	Accessors on renamed parameters (like "age.Years") are renamed too. The copy's `Tokens()` and `Vars()` use the new names,
+	but its `String()` is still the expression as it was originally written, which error positions also refer to.
+	This expression is left unchanged.
+*/
+func (this EvaluableExpression) Substitute(mapping map[string]string) *EvaluableExpression {
+
+	ret := this
+	ret.tokens = substituteTokens(this.tokens, mapping)
+	ret.parameterSlots = findParameterSlots(ret.tokens)
+
+	if this.evaluationStages != nil {
+		ret.evaluationStages = substituteStage(this.evaluationStages, mapping)
+		assignParameterSlots(ret.evaluationStages, ret.parameterSlots)
+		ret.fastFloat = compileFloatProgram(ret.evaluationStages)
+	}
+	return &ret
+}
+
+/*
+	Returns a copy of the given [stage] (and all of its children), with every parameter renamed according to [mapping].
+*/
+func substituteStage(stage *evaluationStage, mapping map[string]string) *evaluationStage {
+
+	ret := *stage
+
+	if stage.leftStage != nil {
+		ret.leftStage = substituteStage(stage.leftStage, mapping)
+	}
+	if stage.rightStage != nil {
+		ret.rightStage = substituteStage(stage.rightStage, mapping)
+	}
+	if stage.elided != nil {
+		ret.elided = substituteStage(stage.elided, mapping)
+	}
+
+	switch stage.symbol {
+	case VALUE:
+
+		newName, found := mapping[stage.name]
+		if found {
+			ret.name = newName
+			ret.operator = makeParameterStage(newName)
+		}
+
+	case ACCESS:
+
+		// the accessor may already be bound by BindParameter, so it's wrapped rather than replaced.
+		parts := strings.Split(stage.name, ".")
+
+		newName, found := mapping[parts[0]]
+		if found {
+			ret.operator = makeRenamedOperator(stage.operator, parts[0], newName)
+
+			parts[0] = newName
+			ret.name = strings.Join(parts, ".")
+		}
+	}
+	return &ret
+}
+
+/*
+	Wraps the given [operator] so that when it reads the parameter [oldName], it gets the parameter [newName] instead.
+*/
+func makeRenamedOperator(operator evaluationOperator, oldName string, newName string) evaluationOperator {
+
+	return func(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+		return operator(left, right, renamedParameters{oldName: oldName, newName: newName, parameters: parameters})
+	}
+}
+
+type renamedParameters struct {
+	oldName    string
+	newName    string
+	parameters Parameters
+}
+
+func (this renamedParameters) Get(name string) (interface{}, error) {
+
+	if name == this.oldName {
+		return this.parameters.Get(this.newName)
+	}
+	return this.parameters.Get(name)
+}
+
+/*
+	Returns a copy of the given [tokens], with every parameter renamed according to [mapping].
+*/
+func substituteTokens(tokens []ExpressionToken, mapping map[string]string) []ExpressionToken {
+
+	ret := make([]ExpressionToken, len(tokens))
+
+	for i, token := range tokens {
+
+		switch token.Kind {
+		case VARIABLE:
+
+			newName, found := mapping[token.Value.(string)]
+			if found {
+				token.Value = newName
+			}
+
+		case ACCESSOR:
+
+			parts := token.Value.([]string)
+
+			newName, found := mapping[parts[0]]
+			if found {
+				renamed := make([]string, len(parts))
+				copy(renamed, parts)
+				renamed[0] = newName
+				token.Value = renamed
+			}
+		}
+		ret[i] = token
+	}
+	return ret
+}
@@ -0,0 +1,288 @@
+package govaluate
+
+import (
+	"fmt"
+)
+
+/*
+	The name of a type (in a schema given to `TypeCheck`) which matches anything, and so is never checked.
+*/
+const anyType = "any"
+
+/*
+	An example value for each type that can be given in a schema, as named by the "typeof" built-in.
+	Type checks are done by running each stage's own (evaluation-time) checks against these.
+*/
+var typeExamples = map[string]interface{}{
+	"number": 0.0,
+	"string": "",
+	"bool":   false,
+	"null":   nil,
+	"array":  []interface{}{},
+	"map":    map[string]interface{}{},
+	"object": struct{}{},
+}
+
+/*
+	The type of a stage, as worked out by `TypeCheck`.
+*/
+type staticType struct {
+
+	// the name of the type, as used in schemas.
+	name string
+
+	// a value of this type, which type checks can be run against. For literals, this is the literal itself.
+	example interface{}
+}
+
+var unknownType = staticType{name: anyType}
+
+/*
+	Checks that this expression would never have a type error, given the types of its parameters in [schema]
+	(such as `{"age": "number", "name": "string"}`). Nothing is evaluated.
+
+	Types are named as they are by the "typeof" built-in: "number", "string", "bool", "null", "array", "map", or "object".
+	A parameter may also be given the type "any", which is never checked. The results of functions and accessors are also never checked,
+	since they can't be known until evaluation. Every parameter used by this expression must be in the schema.
+
+	Returns the first type error found (as an `EvaluationError` giving its position, if the expression was parsed from a string), or nil if there are none.
+*/
+func (this EvaluableExpression) TypeCheck(schema map[string]string) error {
+
+	if this.evaluationStages == nil {
+		return nil
+	}
+
+	_, err := this.inferType(this.evaluationStages, schema)
+	return err
+}
+
+/*
+	Works out the type of the given [stage], or returns an error if the types of its children can't be used with it.
+*/
+func (this EvaluableExpression) inferType(stage *evaluationStage, schema map[string]string) (staticType, error) {
+
+	var left, right staticType
+	var err error
+
+	switch stage.symbol {
+
+	case LITERAL:
+		value, _ := stage.operator(nil, nil, nil)
+		return staticType{name: friendlyTypeName(value), example: value}, nil
+
+	case VALUE:
+
+		name, found := schema[stage.name]
+		if !found {
+			return unknownType, stage.locateError(fmt.Errorf("Parameter '%s' is not in the schema", stage.name))
+		}
+
+		if name == anyType {
+			return unknownType, nil
+		}
+
+		example, found := typeExamples[name]
+		if !found {
+			return unknownType, stage.locateError(fmt.Errorf("Parameter '%s' has unknown type '%s' in the schema", stage.name, name))
+		}
+		return staticType{name: name, example: example}, nil
+
+	case TERNARY_TRUE:
+
+		// on its own, "a ? b" is nil when "a" is false.
+		branch, err := this.inferTernaryBranch(stage, schema)
+		return joinTypes(branch, staticType{name: "null"}), err
+	}
+
+	left = unknownType
+	right = unknownType
+
+	// the left side of "a ? b : c" is "a ? b", but it's the type of "b" which matters.
+	if stage.symbol == TERNARY_FALSE && stage.leftStage != nil && stage.leftStage.symbol == TERNARY_TRUE {
+		left, err = this.inferTernaryBranch(stage.leftStage, schema)
+	} else if stage.leftStage != nil {
+		left, err = this.inferType(stage.leftStage, schema)
+	}
+
+	if err != nil {
+		return unknownType, err
+	}
+
+	if stage.rightStage != nil {
+		right, err = this.inferType(stage.rightStage, schema)
+		if err != nil {
+			return unknownType, err
+		}
+	}
+
+	switch stage.symbol {
+	case NOOP:
+		return right, nil
+	case ACCESS:
+		fallthrough
+	case INDEX:
+		fallthrough
+	case FUNCTIONAL:
+		return unknownType, nil
+	case TERNARY_FALSE:
+		return joinTypes(left, right), nil
+	case COALESCE:
+		if left.name == "null" {
+			return right, nil
+		}
+		return joinTypes(left, right), nil
+	}
+
+	err = this.checkStaticTypes(stage, left, right)
+	if err != nil {
+		return unknownType, err
+	}
+	return findResultType(stage.symbol, left, right), nil
+}
+
+/*
+	Checks the condition of the given TERNARY_TRUE [stage], and returns the type of the branch taken when it's true.
+*/
+func (this EvaluableExpression) inferTernaryBranch(stage *evaluationStage, schema map[string]string) (staticType, error) {
+
+	condition, err := this.inferType(stage.leftStage, schema)
+	if err != nil {
+		return unknownType, err
+	}
+
+	err = this.checkStaticTypes(stage, condition, unknownType)
+	if err != nil {
+		return unknownType, err
+	}
+
+	if stage.rightStage == nil {
+		return staticType{name: "null"}, nil
+	}
+	return this.inferType(stage.rightStage, schema)
+}
+
+/*
+	Runs the type checks of the given [stage] against the types of its [left] and [right] sides.
+	Checks involving types which aren't known are skipped.
+*/
+func (this EvaluableExpression) checkStaticTypes(stage *evaluationStage, left staticType, right staticType) error {
+
+	// conditions which are converted by Truthiness can be of any type.
+	if this.Truthiness != nil && hasTruthyLeft(stage.symbol) {
+		left = unknownType
+	}
+	if this.Truthiness != nil && hasTruthyRight(stage.symbol) {
+		right = unknownType
+	}
+
+	if stage.typeCheck != nil {
+
+		if left.name != anyType && right.name != anyType && !stage.typeCheck(left.example, right.example) {
+			return stage.locateError(fmt.Errorf("Types '%s' and '%s' cannot be used with the operator '%s'%s", left.name, right.name, stage.symbol.String(), this.describeSource(stage)))
+		}
+		return nil
+	}
+
+	if left.name != anyType && stage.leftTypeCheck != nil && !stage.leftTypeCheck(left.example) {
+		return stage.locateError(fmt.Errorf("Type '%s' cannot be used on the left of the operator '%s'%s", left.name, stage.symbol.String(), this.describeSource(stage)))
+	}
+
+	if right.name != anyType && stage.rightTypeCheck != nil && !stage.rightTypeCheck(right.example) {
+		return stage.locateError(fmt.Errorf("Type '%s' cannot be used on the right of the operator '%s'%s", right.name, stage.symbol.String(), this.describeSource(stage)))
+	}
+	return nil
+}
+
+/*
+	Returns the part of the expression which the given [stage] was parsed from, formatted to be appended to an error message.
+	Returns an empty string if that isn't known.
+*/
+func (this EvaluableExpression) describeSource(stage *evaluationStage) string {
+
+	if stage.end == 0 || stage.end > len(this.inputExpression) {
+		return ""
+	}
+	return fmt.Sprintf(", in '%s'", this.inputExpression[stage.start:stage.end])
+}
+
+/*
+	Returns the type of the result of the operator [symbol], given the types of its [left] and [right] sides (which have been checked).
+*/
+func findResultType(symbol OperatorSymbol, left staticType, right staticType) staticType {
+
+	switch symbol {
+	case EQ:
+		fallthrough
+	case NEQ:
+		fallthrough
+	case GT:
+		fallthrough
+	case LT:
+		fallthrough
+	case GTE:
+		fallthrough
+	case LTE:
+		fallthrough
+	case REQ:
+		fallthrough
+	case NREQ:
+		fallthrough
+	case AND:
+		fallthrough
+	case OR:
+		fallthrough
+	case IN:
+		fallthrough
+	case INVERT:
+		return staticType{name: "bool", example: false}
+	case PLUS:
+		if left.name == "string" || right.name == "string" {
+			return staticType{name: "string", example: ""}
+		}
+		if left.name == "number" && right.name == "number" {
+			return staticType{name: "number", example: 0.0}
+		}
+		return unknownType
+	case CONCAT:
+		return staticType{name: "string", example: ""}
+	case SEPARATE:
+		return staticType{name: "array", example: []interface{}{}}
+	case MINUS:
+		fallthrough
+	case MULTIPLY:
+		fallthrough
+	case DIVIDE:
+		fallthrough
+	case MODULUS:
+		fallthrough
+	case EXPONENT:
+		fallthrough
+	case NEGATE:
+		fallthrough
+	case BITWISE_AND:
+		fallthrough
+	case BITWISE_OR:
+		fallthrough
+	case BITWISE_XOR:
+		fallthrough
+	case BITWISE_LSHIFT:
+		fallthrough
+	case BITWISE_RSHIFT:
+		fallthrough
+	case BITWISE_NOT:
+		return staticType{name: "number", example: 0.0}
+	}
+	return unknownType
+}
+
+/*
+	Returns the type of a value which could be of either type [a] or [b].
+*/
+func joinTypes(a staticType, b staticType) staticType {
+
+	if a.name == b.name && a.name != anyType {
+		return staticType{name: a.name, example: typeExamples[a.name]}
+	}
+	return unknownType
+}
@@ -0,0 +1,87 @@
+package govaluate
+
+/*
+	Describes a single stage of a parsed expression, for static analysis of an expression without evaluating it.
+	See `EvaluableExpression.Walk()`.
+*/
+type StageInfo struct {
+
+	/*
+		The operator this stage performs.
+		Parameters are represented by VALUE, constants by LITERAL, parenthesis by NOOP, and function calls by FUNCTIONAL.
+	*/
+	Symbol OperatorSymbol
+
+	/*
+		For parameters, accessors, and functions, the name used to refer to them. Empty for all other stages.
+	*/
+	Name string
+
+	/*
+		For LITERAL stages, the constant value. Nil for all other stages.
+	*/
+	Value interface{}
+
+	/*
+		The stages whose results are used as the left and right sides of this one, or nil if this stage doesn't have that side.
+		For function calls, the arguments are the right side.
+	*/
+	Left, Right *StageInfo
+}
+
+/*
+	Visits every stage of this expression, in evaluation order (a stage's left side, then its right side),
+	starting from the stage which produces the final result.
+	If [visitor] returns false for a stage, none of that stage's children will be visited.
+
+	Note that parts of an expression which only use literals are combined when the expression is parsed,
+	so "1 + 2" will be seen as a single LITERAL stage with the value 3.
+*/
+func (this EvaluableExpression) Walk(visitor func(stage StageInfo) bool) {
+
+	if this.evaluationStages == nil {
+		return
+	}
+
+	walkStageInfo(describeStage(this.evaluationStages), visitor)
+}
+
+func walkStageInfo(info *StageInfo, visitor func(stage StageInfo) bool) {
+
+	if !visitor(*info) {
+		return
+	}
+
+	if info.Left != nil {
+		walkStageInfo(info.Left, visitor)
+	}
+
+	if info.Right != nil {
+		walkStageInfo(info.Right, visitor)
+	}
+}
+
+/*
+	Builds a StageInfo tree describing the given [stage] and all of its children.
+*/
+func describeStage(stage *evaluationStage) *StageInfo {
+
+	ret := &StageInfo{
+		Symbol: stage.symbol,
+		Name:   stage.name,
+	}
+
+	if stage.symbol == LITERAL {
+		ret.Value, _ = stage.operator(nil, nil, nil)
+	}
+
+	if stage.leftStage != nil {
+		ret.Left = describeStage(stage.leftStage)
+	}
+
+	if stage.rightStage != nil {
+		ret.Right = describeStage(stage.rightStage)
+	}
+
+	return ret
+}
@@ -34,6 +34,7 @@ const (
 	DIVIDE
 	MODULUS
 	EXPONENT
+	CONCAT
 
 	NEGATE
 	INVERT
@@ -46,6 +47,7 @@ const (
 	FUNCTIONAL
 	ACCESS
 	SEPARATE
+	INDEX
 )
 
 type operatorPrecedence int
@@ -109,6 +111,8 @@ func findOperatorPrecedenceForSymbol(symbol OperatorSymbol) operatorPrecedence {
 	case PLUS:
 		fallthrough
 	case MINUS:
+		fallthrough
+	case CONCAT:
 		return additivePrecedence
 	case MULTIPLY:
 		fallthrough
@@ -132,6 +136,8 @@ func findOperatorPrecedenceForSymbol(symbol OperatorSymbol) operatorPrecedence {
 		return ternaryPrecedence
 	case ACCESS:
 		fallthrough
+	case INDEX:
+		fallthrough
 	case FUNCTIONAL:
 		return functionalPrecedence
 	case SEPARATE:
@@ -175,8 +181,9 @@ var bitwiseShiftSymbols = map[string]OperatorSymbol{
 }
 
 var additiveSymbols = map[string]OperatorSymbol{
-	"+": PLUS,
-	"-": MINUS,
+	"+":  PLUS,
+	"-":  MINUS,
+	"..": CONCAT,
 }
 
 var multiplicativeSymbols = map[string]OperatorSymbol{
@@ -195,6 +202,18 @@ var prefixSymbols = map[string]OperatorSymbol{
 	"~": BITWISE_NOT,
 }
 
+/*
+	Words which (when parsing with `ParsingOptions.WordOperators`) are read as if they were the symbol they map to.
+*/
+var wordOperatorSymbols = map[string]string{
+	"and": "&&",
+	"AND": "&&",
+	"or":  "||",
+	"OR":  "||",
+	"not": "!",
+	"NOT": "!",
+}
+
 var ternarySymbols = map[string]OperatorSymbol{
 	"?":  TERNARY_TRUE,
 	":":  TERNARY_FALSE,
@@ -209,6 +228,7 @@ var modifierSymbols = map[string]OperatorSymbol{
 	"/":  DIVIDE,
 	"%":  MODULUS,
 	"**": EXPONENT,
+	"..": CONCAT,
 	"&":  BITWISE_AND,
 	"|":  BITWISE_OR,
 	"^":  BITWISE_XOR,
@@ -235,6 +255,30 @@ func (this OperatorSymbol) IsModifierType(candidate []OperatorSymbol) bool {
 	return false
 }
 
+/*
+	Returns true if this is a comparator, whose result is always a bool.
+*/
+func (this OperatorSymbol) isComparator() bool {
+
+	switch this {
+	case EQ, NEQ, GT, GTE, LT, LTE, REQ, NREQ, IN:
+		return true
+	}
+	return false
+}
+
+/*
+	Returns true if this is a comparator which needs both sides to be of a type it can compare, unlike "==" (which can compare anything).
+*/
+func (this OperatorSymbol) isOrderedComparator() bool {
+
+	switch this {
+	case GT, GTE, LT, LTE, REQ, NREQ:
+		return true
+	}
+	return false
+}
+
 /*
 	Generally used when formatting type check errors.
 	We could store the stringified symbol somewhere else and not require a duplicated codeblock to translate
@@ -292,6 +336,8 @@ func (this OperatorSymbol) String() string {
 		return "%"
 	case EXPONENT:
 		return "**"
+	case CONCAT:
+		return ".."
 	case NEGATE:
 		return "-"
 	case INVERT:
@@ -304,6 +350,91 @@ func (this OperatorSymbol) String() string {
 		return ":"
 	case COALESCE:
 		return "??"
+	case INDEX:
+		return "[]"
+	}
+	return ""
+}
+
+/*
+	Returns a short, human-readable description of what this operator does,
+	suitable for showing to people writing expressions (such as in an editor's autocomplete or help text).
+*/
+func (this OperatorSymbol) Description() string {
+
+	switch this {
+	case NOOP:
+		return "Parenthesis, which group part of an expression so that it is evaluated first."
+	case VALUE:
+		return "A parameter, whose value is given when the expression is evaluated."
+	case LITERAL:
+		return "A constant value, such as a number, string, or boolean."
+	case EQ:
+		return "Equal to."
+	case NEQ:
+		return "Not equal to."
+	case GT:
+		return "Greater than. Compares numbers, or strings lexicographically."
+	case LT:
+		return "Less than. Compares numbers, or strings lexicographically."
+	case GTE:
+		return "Greater than or equal to. Compares numbers, or strings lexicographically."
+	case LTE:
+		return "Less than or equal to. Compares numbers, or strings lexicographically."
+	case REQ:
+		return "Matches a regular expression."
+	case NREQ:
+		return "Does not match a regular expression."
+	case IN:
+		return "Membership; true if the left side is equal to any value in the list on the right side."
+	case AND:
+		return "Logical AND. The right side is only evaluated if the left side is true."
+	case OR:
+		return "Logical OR. The right side is only evaluated if the left side is false."
+	case PLUS:
+		return "Addition of numbers, or concatenation if either side is a string."
+	case MINUS:
+		return "Subtraction."
+	case BITWISE_AND:
+		return "Bitwise AND of two integers."
+	case BITWISE_OR:
+		return "Bitwise OR of two integers."
+	case BITWISE_XOR:
+		return "Bitwise exclusive OR of two integers."
+	case BITWISE_LSHIFT:
+		return "Shifts the bits of an integer left."
+	case BITWISE_RSHIFT:
+		return "Shifts the bits of an integer right."
+	case MULTIPLY:
+		return "Multiplication."
+	case DIVIDE:
+		return "Division."
+	case MODULUS:
+		return "Modulus; the remainder after dividing the left side by the right side, which has the same sign as the left side (so -1 % 3 is -1). For a remainder which is never negative, use the mod(a, b) function. This is NOT percent-of; for that, use the percent(x, p) function."
+	case EXPONENT:
+		return "Exponentiation; the left side to the power of the right side."
+	case CONCAT:
+		return "String concatenation, which converts both sides to strings regardless of their types."
+	case NEGATE:
+		return "Negation of a number."
+	case INVERT:
+		return "Logical NOT of a boolean."
+	case BITWISE_NOT:
+		return "Bitwise NOT of an integer."
+	case TERNARY_TRUE:
+		return "Ternary; if the left side is true, the result is the right side."
+	case TERNARY_FALSE:
+		return "Ternary; if the condition before it was false, the result is the right side."
+	case COALESCE:
+		return "Null coalescence; the left side, unless it is nil, in which case the right side."
+	case FUNCTIONAL:
+		return "A function call."
+	case ACCESS:
+		return "Access to a field or method of a parameter."
+	case SEPARATE:
+		return "Separates the values of a list, or the arguments of a function."
+	case INDEX:
+		return "Indexing; the element at the given position (counting from zero) of the list returned by a function, as in split(s, ',')[0]."
 	}
 	return ""
 }
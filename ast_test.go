@@ -0,0 +1,75 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type astTest struct {
+	input    string
+	expected string
+}
+
+func TestMarshalAST(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"max": func(arguments ...interface{}) (interface{}, error) {
+			return nil, nil
+		},
+	}
+
+	astTests := []astTest{
+		astTest{
+			input:    "a + 1 == 2",
+			expected: `{"type":"operator","operator":"==","left":{"type":"operator","operator":"+","left":{"type":"parameter","name":"a"},"right":{"type":"literal","value":1,"valueType":"number"}},"right":{"type":"literal","value":2,"valueType":"number"}}`,
+		},
+		astTest{
+			input:    "!(x > 5m)",
+			expected: `{"type":"operator","operator":"!","right":{"type":"operator","operator":">","left":{"type":"parameter","name":"x"},"right":{"type":"literal","value":300,"valueType":"duration"}}}`,
+		},
+		astTest{
+			input:    "cond ? 'yes' : 'no'",
+			expected: `{"type":"ternary","condition":{"type":"parameter","name":"cond"},"then":{"type":"literal","value":"yes","valueType":"string"},"else":{"type":"literal","value":"no","valueType":"string"}}`,
+		},
+		astTest{
+			input:    "max(a, 2 * 3, foo.Bar())",
+			expected: `{"type":"function","name":"max","arguments":[{"type":"parameter","name":"a"},{"type":"operator","operator":"*","left":{"type":"literal","value":2,"valueType":"number"},"right":{"type":"literal","value":3,"valueType":"number"}},{"type":"accessor","name":"foo.Bar","arguments":[]}]}`,
+		},
+		astTest{
+			input:    "name =~ '^a' && role in ('admin', 'owner')",
+			expected: `{"type":"operator","operator":"&&","left":{"type":"operator","operator":"=~","left":{"type":"parameter","name":"name"},"right":{"type":"literal","value":"^a","valueType":"pattern"}},"right":{"type":"operator","operator":"in","left":{"type":"parameter","name":"role"},"right":{"type":"list","items":[{"type":"literal","value":"admin","valueType":"string"},{"type":"literal","value":"owner","valueType":"string"}]}}}`,
+		},
+		astTest{
+			input:    "max(a)[i + 1]",
+			expected: `{"type":"index","list":{"type":"function","name":"max","arguments":[{"type":"parameter","name":"a"}]},"index":{"type":"operator","operator":"+","left":{"type":"parameter","name":"i"},"right":{"type":"literal","value":1,"valueType":"number"}}}`,
+		},
+	}
+
+	for _, astTest := range astTests {
+
+		expression, err := NewEvaluableExpressionWithFunctions(astTest.input, functions)
+		if err != nil {
+			test.Logf("Failed to parse '%s': %v", astTest.input, err)
+			test.Fail()
+			continue
+		}
+
+		ast, err := expression.MarshalAST()
+		if err != nil {
+			test.Logf("Failed to marshal the AST of '%s': %v", astTest.input, err)
+			test.Fail()
+			continue
+		}
+
+		// compared once decoded, since JSON can escape the same string in different ways.
+		var actual, expected interface{}
+		json.Unmarshal(ast, &actual)
+		json.Unmarshal([]byte(astTest.expected), &expected)
+
+		if !reflect.DeepEqual(actual, expected) {
+			test.Logf("Expected the AST of '%s' to be:\n%s\ngot:\n%s", astTest.input, astTest.expected, ast)
+			test.Fail()
+		}
+	}
+}
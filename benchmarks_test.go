@@ -249,3 +249,34 @@ func BenchmarkNestedAccessors(bench *testing.B) {
 		expression.Evaluate(fooFailureParameters)
 	}
 }
+
+/*
+  Benchmarks the same expression as BenchmarkEvaluationParametersModifiers, but using the float64-only fast path.
+*/
+func BenchmarkEvaluateFloatFast(bench *testing.B) {
+
+	expression, _ := NewEvaluableExpression("(requests_made * requests_succeeded / 100) >= 90")
+	parameters := map[string]float64{
+		"requests_made":      99.0,
+		"requests_succeeded": 90.0,
+	}
+
+	bench.ResetTimer()
+	for i := 0; i < bench.N; i++ {
+		expression.EvaluateFloatFast(parameters)
+	}
+}
+
+/*
+  Benchmarks the same expression as BenchmarkEvaluationParameters, but with parameters found by position rather than by name.
+*/
+func BenchmarkEvaluationPositionalParameters(bench *testing.B) {
+
+	expression, _ := NewEvaluableExpression("requests_made > requests_succeeded")
+	parameters, _ := expression.PositionalParameters(99.0, 90.0)
+
+	bench.ResetTimer()
+	for i := 0; i < bench.N; i++ {
+		expression.Eval(parameters)
+	}
+}
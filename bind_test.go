@@ -0,0 +1,73 @@
+package govaluate
+
+import (
+	"testing"
+)
+
+func TestBindParameter(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("rate * 2 > threshold && (rate + 1) * amount < 100")
+	bound := expression.BindParameter("rate", 5)
+
+	result, err := bound.Evaluate(map[string]interface{}{"threshold": 3, "amount": 10})
+	if err != nil || result != true {
+		test.Logf("Expected true, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	// the bound parameter doesn't need to be given, and is ignored if it is.
+	result, err = bound.Evaluate(map[string]interface{}{"threshold": 3, "amount": 20, "rate": 0})
+	if err != nil || result != false {
+		test.Logf("Expected false, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	// the original expression still needs the parameter.
+	_, err = expression.Evaluate(map[string]interface{}{"threshold": 3, "amount": 10})
+	if err == nil {
+		test.Logf("Expected the original expression to still need 'rate'")
+		test.Fail()
+	}
+
+	vars := bound.Vars()
+	if len(vars) != 2 || vars[0] != "threshold" || vars[1] != "amount" {
+		test.Logf("Expected the bound expression's variables to be [threshold amount], got %v", vars)
+		test.Fail()
+	}
+
+	// literal parts are calculated when bound.
+	folded := 0
+	bound.Walk(func(stage StageInfo) bool {
+
+		if stage.Symbol == LITERAL && stage.Value == 10.0 {
+			folded++
+		}
+		return true
+	})
+
+	if folded != 1 {
+		test.Logf("Expected 'rate * 2' to be calculated when bound")
+		test.Fail()
+	}
+}
+
+func TestBindParameterAccessors(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("foo.Int * 3 + bar")
+	bound := expression.BindParameter("foo", dummyParameter{Int: 1})
+
+	result, err := bound.Evaluate(map[string]interface{}{"bar": 2})
+	if err != nil || result != 5.0 {
+		test.Logf("Expected 5, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	// binding a different parameter leaves accessors alone.
+	bound = expression.BindParameter("bar", 10)
+
+	result, err = bound.Evaluate(map[string]interface{}{"foo": dummyParameter{Int: 2}})
+	if err != nil || result != 16.0 {
+		test.Logf("Expected 16, got %v, %v", result, err)
+		test.Fail()
+	}
+}
@@ -0,0 +1,1203 @@
+package govaluate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+/*
+	Represents a function which is available to every expression, without needing to be passed in by the caller.
+	Functions passed in by the caller always take priority over a built-in of the same name.
+*/
+type builtinFunction struct {
+	function    ExpressionFunction
+	description string
+
+	// for built-ins which call other functions by name (like "reduce"), makes the function given a way to find those functions.
+	// If set, this is used instead of [function].
+	makeFunction func(resolve functionResolver) ExpressionFunction
+
+	// for built-ins which can build strings much longer than their arguments (like "replace"), the length of the string
+	// [function] would return for the given arguments (or zero if it would fail), so that `MaxStringBytes` is checked before it's built.
+	stringSize func(arguments []interface{}) int
+}
+
+var builtinFunctions = map[string]builtinFunction{
+	"ifnull": builtinFunction{
+		function:    ifnullFunction,
+		description: "ifnull(a, b) returns a, unless a is nil, in which case it returns b.",
+	},
+	"nvl": builtinFunction{
+		function:    ifnullFunction,
+		description: "nvl(a, b) is an alias for ifnull(a, b).",
+	},
+	"nullif": builtinFunction{
+		function:    nullifFunction,
+		description: "nullif(a, b) returns nil if a is equal to b, otherwise it returns a.",
+	},
+	"switch": builtinFunction{
+		function:    switchFunction,
+		description: "switch(x, case1, result1, case2, result2, ..., default) returns the result of the first case equal to x, otherwise the default (or nil, if there isn't one).",
+	},
+	"try": builtinFunction{
+		function:    tryFunction,
+		description: "try(a, b) returns a, unless evaluating a fails, in which case it returns b. b is only evaluated if a fails.",
+	},
+	"fail": builtinFunction{
+		function:    failFunction,
+		description: "fail(message) stops evaluation, returning an error with the given message.",
+	},
+	"split": builtinFunction{
+		function:    splitFunction,
+		description: "split(s, separator) returns a list of the substrings of s between each separator.",
+	},
+	"lenCompare": builtinFunction{
+		function:    lenCompareFunction,
+		description: "lenCompare(a, b) returns -1, 0, or 1 if the length of a is less than, equal to, or greater than the length of b.",
+	},
+	"cmp": builtinFunction{
+		function:    cmpFunction,
+		description: "cmp(a, b) returns -1, 0, or 1 if a is less than, equal to, or greater than b. Both must be numbers, strings, or times.",
+	},
+	"semverCompare": builtinFunction{
+		function:    semverCompareFunction,
+		description: "semverCompare(a, b) returns -1, 0, or 1 if the semantic version a has lower, the same, or higher precedence than b, so semverCompare('1.10.0', '1.9.0') is 1.",
+	},
+	"semverSatisfies": builtinFunction{
+		function:    semverSatisfiesFunction,
+		description: "semverSatisfies(v, constraint) returns true if the semantic version v satisfies the constraint, like '>=1.2.0 <2.0.0' or '^1.2.0'.",
+	},
+	"now": builtinFunction{
+		function:    nowFunction,
+		description: "now() returns the current time, which can have durations added to it, as in now() + 5m.",
+	},
+	"percent": builtinFunction{
+		function:    percentFunction,
+		description: "percent(x, p) returns p percent of x, that is, x * p / 100. Not to be confused with the modulus operator '%'.",
+	},
+	"round": builtinFunction{
+		function:    roundFunction,
+		description: "round(x, n) returns x rounded to n decimal places, with halves rounded away from zero, so round(2.5, 0) is 3 and round(-2.5, 0) is -3. For banker's rounding, use roundEven.",
+	},
+	"roundEven": builtinFunction{
+		function:    roundEvenFunction,
+		description: "roundEven(x, n) returns x rounded to n decimal places, with halves rounded to the nearest even digit (banker's rounding), so roundEven(2.5, 0) is 2 and roundEven(3.5, 0) is 4. Unlike round, this doesn't bias totals upwards.",
+	},
+	"mod": builtinFunction{
+		function:    modFunction,
+		description: "mod(a, b) returns the Euclidean modulus of a by b, which is never negative (unlike a % b), so mod(-1, 3) is 2.",
+	},
+	"replace": builtinFunction{
+		function:    replaceFunction,
+		description: "replace(s, old, new) returns s, with every instance of old replaced by new.",
+		stringSize:  replacedSize,
+	},
+	"replaceN": builtinFunction{
+		function:    replaceNFunction,
+		description: "replaceN(s, old, new, n) returns s, with the first n instances of old replaced by new.",
+		stringSize:  replacedSize,
+	},
+	"matchNamed": builtinFunction{
+		function:    matchNamedFunction,
+		description: "matchNamed(s, pattern) returns a map of the named capture groups of the regex pattern, as matched against s. Returns nil if it doesn't match.",
+	},
+	"groups": builtinFunction{
+		function:    groupsFunction,
+		description: "groups(s, pattern) returns a list of the text captured by each group of the regex pattern, as matched against s. Returns nil if it doesn't match.",
+	},
+	"upper": builtinFunction{
+		function:    upperFunction,
+		description: "upper(s) returns s with all letters in upper case.",
+	},
+	"lower": builtinFunction{
+		function:    lowerFunction,
+		description: "lower(s) returns s with all letters in lower case.",
+	},
+	"trim": builtinFunction{
+		function:    trimFunction,
+		description: "trim(s) returns s without any leading or trailing whitespace.",
+	},
+	"trimLeft": builtinFunction{
+		function:    trimLeftFunction,
+		description: "trimLeft(s, cutset) returns s without any leading characters contained in cutset.",
+	},
+	"trimRight": builtinFunction{
+		function:    trimRightFunction,
+		description: "trimRight(s, cutset) returns s without any trailing characters contained in cutset.",
+	},
+	"indexOf": builtinFunction{
+		function:    indexOfFunction,
+		description: "indexOf(items, value) returns the index of the first of the items which is equal to value, or -1 if none are.",
+	},
+	"contains": builtinFunction{
+		function:    containsFunction,
+		description: "contains(s, substr) returns true if the string s contains the string substr. s may also be a []byte, in which case substr may be a []byte or a string. For lists, use listContains.",
+	},
+	"listContains": builtinFunction{
+		function:    listContainsFunction,
+		description: "listContains(items, value) returns true if any of the items is equal to value. For strings, use contains.",
+	},
+	"unique": builtinFunction{
+		function:    uniqueFunction,
+		description: "unique(items) returns the items without any duplicates (using the same equality as ==), each in the position it first appeared.",
+	},
+	"toList": builtinFunction{
+		function:    toListFunction,
+		description: "toList(x) returns x if it's already a list, or a list with x as its only item if it isn't (and an empty list if x is null).",
+	},
+	"ord": builtinFunction{
+		function:    ordFunction,
+		description: "ord(c) returns the unicode code point of the single character c, as a number.",
+	},
+	"chr": builtinFunction{
+		function:    chrFunction,
+		description: "chr(n) returns a string of the single character whose unicode code point is n.",
+	},
+	"hash": builtinFunction{
+		function:    hashFunction,
+		description: "hash(s) returns a number in the range [0, 1) derived from the string s, which is always the same for the same s.",
+	},
+	"crc32": builtinFunction{
+		function:    crc32Function,
+		description: "crc32(s) returns the IEEE CRC-32 checksum of the string s, as a number.",
+	},
+	"fnv": builtinFunction{
+		function:    fnvFunction,
+		description: "fnv(s) returns the 32-bit FNV-1a hash of the string s, as a number.",
+	},
+	"reduce": builtinFunction{
+		makeFunction: makeReduceFunction,
+		description:  "reduce(items, f, initial) calls the function f(accumulator, item) for each of the items in turn, starting with an accumulator of initial, and returns the final accumulator.",
+	},
+	"map": builtinFunction{
+		makeFunction: makeMapFunction,
+		description:  "map(items, f) returns a list of the result of calling the function f(item) for each of the items.",
+	},
+	"filter": builtinFunction{
+		makeFunction: makeFilterFunction,
+		description:  "filter(items, predicate) returns a list of the items for which the function predicate(item) returns true. The predicate may also be a sub-expression of the item 'it', as in filter(items, \"it > 5\").",
+	},
+	"object": builtinFunction{
+		function:    objectFunction,
+		description: "object(key, value, ...) returns a map of each key to the value after it, keeping the keys in the order given.",
+	},
+	"hasKey": builtinFunction{
+		function:    hasKeyFunction,
+		description: "hasKey(m, key) returns true if the map m has the given key.",
+	},
+	"keys": builtinFunction{
+		function:    keysFunction,
+		description: "keys(m) returns a list of the keys of the map m, in the order they were set for maps built by object(), or sorted otherwise.",
+	},
+	"values": builtinFunction{
+		function:    valuesFunction,
+		description: "values(m) returns a list of the values of the map m, in the same order as keys(m).",
+	},
+	"typeof": builtinFunction{
+		function:    typeofFunction,
+		description: "typeof(x) returns the type of x, one of \"number\", \"string\", \"bool\", \"null\", \"array\", \"map\", or \"object\".",
+	},
+}
+
+func ifnullFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("ifnull", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	if isNil(arguments[0]) {
+		return arguments[1], nil
+	}
+	return arguments[0], nil
+}
+
+func nullifFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("nullif", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	if isEqual(arguments[0], arguments[1]) {
+		return nil, nil
+	}
+	return arguments[0], nil
+}
+
+/*
+	Compares the first argument against each case (every other argument after it), using the same equality as "==",
+	and returns the result which follows the first matching case. If no case matches, returns the last argument
+	when it's left over as a default, or nil otherwise.
+*/
+func switchFunction(arguments ...interface{}) (interface{}, error) {
+
+	if len(arguments) < 2 {
+		return nil, fmt.Errorf("Function 'switch' expects at least 2 arguments, got %d", len(arguments))
+	}
+
+	value := arguments[0]
+	cases := arguments[1:]
+
+	for i := 0; i+1 < len(cases); i += 2 {
+		if isEqual(value, cases[i]) {
+			return cases[i+1], nil
+		}
+	}
+
+	if len(cases)%2 == 1 {
+		return cases[len(cases)-1], nil
+	}
+	return nil, nil
+}
+
+/*
+	Calls to "try" with two arguments are evaluated specially (see `evaluateTry`), since by the time a function is called,
+	its arguments have already been evaluated, and any errors returned. So this is only called when "try" is misused.
+*/
+func tryFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("try", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+	return arguments[0], nil
+}
+
+func failFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("fail", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, errors.New(fmt.Sprintf("%v", arguments[0]))
+}
+
+func splitFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("split", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkStringArguments("split", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(arguments[0].(string), arguments[1].(string))
+
+	ret := make([]interface{}, len(parts))
+	for i, part := range parts {
+		ret[i] = part
+	}
+	return ret, nil
+}
+
+func replaceFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("replace", arguments, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkStringArguments("replace", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Replace(arguments[0].(string), arguments[1].(string), arguments[2].(string), -1), nil
+}
+
+func replaceNFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("replaceN", arguments, 4)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkStringArguments("replaceN", arguments[:3])
+	if err != nil {
+		return nil, err
+	}
+
+	if !isFloat64(arguments[3]) {
+		return nil, errors.New("Function 'replaceN' expects a numeric count")
+	}
+
+	return strings.Replace(arguments[0].(string), arguments[1].(string), arguments[2].(string), int(arguments[3].(float64))), nil
+}
+
+/*
+	Returns the length of the string which "replace" (or "replaceN", given a count) would return for the given [arguments],
+	without building it.
+*/
+func replacedSize(arguments []interface{}) int {
+
+	if len(arguments) < 3 || !isString(arguments[0]) || !isString(arguments[1]) || !isString(arguments[2]) {
+		return 0
+	}
+
+	str, old, replacement := arguments[0].(string), arguments[1].(string), arguments[2].(string)
+
+	// like strings.Replace, an empty [old] matches before every rune, and at the end.
+	matches := strings.Count(str, old)
+
+	if len(arguments) > 3 && isFloat64(arguments[3]) && arguments[3].(float64) >= 0 && arguments[3].(float64) < float64(matches) {
+		matches = int(arguments[3].(float64))
+	}
+	return len(str) + matches*(len(replacement)-len(old))
+}
+
+/*
+	Returns a function which gives the length of the string that the built-in of the given [name] would return,
+	given the right side of a call to it in the given [style], if [function] is that built-in and it can build large strings.
+	Returns nil otherwise.
+*/
+func findStringSize(name string, function ExpressionFunction, style argumentStyle) func(right interface{}) int {
+
+	builtin, found := builtinFunctions[name]
+	if !found || builtin.stringSize == nil || reflect.ValueOf(builtin.function).Pointer() != reflect.ValueOf(function).Pointer() {
+		return nil
+	}
+
+	return func(right interface{}) int {
+
+		if style == argumentList {
+			return builtin.stringSize(right.([]interface{}))
+		}
+		return builtin.stringSize([]interface{}{right})
+	}
+}
+
+func matchNamedFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("matchNamed", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isString(arguments[0]) || !isRegexOrString(arguments[1]) {
+		return nil, errors.New("Function 'matchNamed' expects a string and a regex pattern")
+	}
+
+	pattern, err := compilePattern(arguments[1])
+	if err != nil {
+		return nil, err
+	}
+
+	matches := pattern.FindStringSubmatch(arguments[0].(string))
+	if matches == nil {
+		return nil, nil
+	}
+
+	ret := make(map[string]interface{})
+	for i, name := range pattern.SubexpNames() {
+		if name != "" {
+			ret[name] = matches[i]
+		}
+	}
+	return ret, nil
+}
+
+func groupsFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("groups", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isString(arguments[0]) || !isRegexOrString(arguments[1]) {
+		return nil, errors.New("Function 'groups' expects a string and a regex pattern")
+	}
+
+	pattern, err := compilePattern(arguments[1])
+	if err != nil {
+		return nil, err
+	}
+
+	matches := pattern.FindStringSubmatch(arguments[0].(string))
+	if matches == nil {
+		return nil, nil
+	}
+
+	// the first match is the whole of the matched text, rather than a group.
+	ret := make([]interface{}, len(matches)-1)
+	for i, match := range matches[1:] {
+		ret[i] = match
+	}
+	return ret, nil
+}
+
+func lenCompareFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("lenCompare", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	left, ok := collectionLength(arguments[0])
+	if !ok {
+		return nil, fmt.Errorf("Function 'lenCompare' cannot take the length of '%v'", arguments[0])
+	}
+
+	right, ok := collectionLength(arguments[1])
+	if !ok {
+		return nil, fmt.Errorf("Function 'lenCompare' cannot take the length of '%v'", arguments[1])
+	}
+
+	if left < right {
+		return -1.0, nil
+	}
+	if left > right {
+		return 1.0, nil
+	}
+	return 0.0, nil
+}
+
+func cmpFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("cmp", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	left := arguments[0]
+	right := arguments[1]
+
+	switch {
+	case isString(left) && isString(right):
+		return float64(strings.Compare(left.(string), right.(string))), nil
+
+	case isNumber(left) && isNumber(right):
+
+		comparison, comparable := compareNumbers(left, right)
+		if comparable {
+			return float64(comparison), nil
+		}
+
+	case isTime(left) && isTime(right):
+
+		leftTime := left.(time.Time)
+		rightTime := right.(time.Time)
+		return float64(compareOrdered(leftTime.Before(rightTime), leftTime.After(rightTime))), nil
+	}
+
+	return nil, fmt.Errorf("Function 'cmp' cannot compare '%v' (a %s) with '%v' (a %s)", left, friendlyTypeName(left), right, friendlyTypeName(right))
+}
+
+func semverCompareFunction(arguments ...interface{}) (interface{}, error) {
+
+	versions, err := readSemanticVersions("semverCompare", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+	return float64(versions[0].compare(versions[1])), nil
+}
+
+func semverSatisfiesFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("semverSatisfies", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := readSemanticVersions("semverSatisfies", arguments[:1], 1)
+	if err != nil {
+		return nil, err
+	}
+
+	constraint, ok := arguments[1].(string)
+	if !ok {
+		return nil, errors.New("Function 'semverSatisfies' expects string arguments")
+	}
+
+	satisfied, err := satisfiesSemanticConstraint(versions[0], constraint)
+	if err != nil {
+		return nil, fmt.Errorf("Function 'semverSatisfies' failed: %w", err)
+	}
+	return satisfied, nil
+}
+
+/*
+	Checks that there are exactly [count] [arguments], all of which are valid semantic versions, then returns them.
+*/
+func readSemanticVersions(name string, arguments []interface{}, count int) ([]semanticVersion, error) {
+
+	err := checkArgumentCount(name, arguments, count)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkStringArguments(name, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]semanticVersion, count)
+	for i, argument := range arguments {
+
+		ret[i], err = parseSemanticVersion(argument.(string))
+		if err != nil {
+			return nil, fmt.Errorf("Function '%s' failed: %w", name, err)
+		}
+	}
+	return ret, nil
+}
+
+func nowFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("now", arguments, 0)
+	if err != nil {
+		return nil, err
+	}
+	return time.Now(), nil
+}
+
+func percentFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("percent", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isFloat64(arguments[0]) || !isFloat64(arguments[1]) {
+		return nil, errors.New("Function 'percent' expects numeric arguments")
+	}
+
+	return arguments[0].(float64) * arguments[1].(float64) / 100, nil
+}
+
+const maxRoundingPlaces = 400
+
+func roundFunction(arguments ...interface{}) (interface{}, error) {
+	return callRoundFunction("round", arguments, false)
+}
+
+func roundEvenFunction(arguments ...interface{}) (interface{}, error) {
+	return callRoundFunction("roundEven", arguments, true)
+}
+
+/*
+	Checks the [arguments] of a rounding function of the given [name], then rounds (see `roundDecimal`).
+*/
+func callRoundFunction(name string, arguments []interface{}, halfEven bool) (interface{}, error) {
+
+	err := checkArgumentCount(name, arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isFloat64(arguments[0]) || !isFloat64(arguments[1]) {
+		return nil, fmt.Errorf("Function '%s' expects numeric arguments", name)
+	}
+
+	places := arguments[1].(float64)
+	if places != math.Trunc(places) {
+		return nil, fmt.Errorf("Function '%s' expects a whole number of decimal places, got '%v'", name, places)
+	}
+
+	// rounding to more places than this (in either direction) gives the same result as this many, for any float64.
+	if math.Abs(places) > maxRoundingPlaces {
+		return nil, fmt.Errorf("Function '%s' can round to at most %d decimal places, got '%v'", name, maxRoundingPlaces, places)
+	}
+
+	return roundDecimal(arguments[0].(float64), int(places), halfEven), nil
+}
+
+/*
+	Unlike "%" (which uses math.Mod, so the result has the sign of the dividend), the Euclidean modulus is always between 0 and |b|.
+*/
+func modFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("mod", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isFloat64(arguments[0]) || !isFloat64(arguments[1]) {
+		return nil, errors.New("Function 'mod' expects numeric arguments")
+	}
+
+	divisor := arguments[1].(float64)
+
+	ret := math.Mod(arguments[0].(float64), divisor)
+	if ret < 0 {
+		ret += math.Abs(divisor)
+	}
+	return ret, nil
+}
+
+func upperFunction(arguments ...interface{}) (interface{}, error) {
+	return callStringFunction("upper", arguments, 1, func(arguments []string) string {
+		return strings.ToUpper(arguments[0])
+	})
+}
+
+func lowerFunction(arguments ...interface{}) (interface{}, error) {
+	return callStringFunction("lower", arguments, 1, func(arguments []string) string {
+		return strings.ToLower(arguments[0])
+	})
+}
+
+func trimFunction(arguments ...interface{}) (interface{}, error) {
+	return callStringFunction("trim", arguments, 1, func(arguments []string) string {
+		return strings.TrimSpace(arguments[0])
+	})
+}
+
+func trimLeftFunction(arguments ...interface{}) (interface{}, error) {
+	return callStringFunction("trimLeft", arguments, 2, func(arguments []string) string {
+		return strings.TrimLeft(arguments[0], arguments[1])
+	})
+}
+
+func trimRightFunction(arguments ...interface{}) (interface{}, error) {
+	return callStringFunction("trimRight", arguments, 2, func(arguments []string) string {
+		return strings.TrimRight(arguments[0], arguments[1])
+	})
+}
+
+func indexOfFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("indexOf", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := collectionElements(arguments[0])
+	if !ok {
+		return nil, fmt.Errorf("Function 'indexOf' expects a list of items, got '%v'", arguments[0])
+	}
+
+	for i, item := range items {
+		if isEqual(item, arguments[1]) {
+			return float64(i), nil
+		}
+	}
+	return -1.0, nil
+}
+
+func containsFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("contains", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	// a []byte may be searched for bytes, or for a string (since there are no []byte literals).
+	if isBytes(arguments[0]) {
+
+		switch arguments[1].(type) {
+		case []byte:
+			return bytes.Contains(arguments[0].([]byte), arguments[1].([]byte)), nil
+		case string:
+			return bytes.Contains(arguments[0].([]byte), []byte(arguments[1].(string))), nil
+		}
+		return nil, errors.New("Function 'contains' expects a []byte to be searched for a []byte or string")
+	}
+
+	// lists are never searched, even for a string, since a list of strings would be easy to mistake for a string.
+	err = checkStringArguments("contains", arguments)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Contains(arguments[0].(string), arguments[1].(string)), nil
+}
+
+func listContainsFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("listContains", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := collectionElements(arguments[0])
+	if !ok {
+		return nil, fmt.Errorf("Function 'listContains' expects a list of items, got '%v'", arguments[0])
+	}
+
+	for _, item := range items {
+		if isEqual(item, arguments[1]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func uniqueFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("unique", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := collectionElements(arguments[0])
+	if !ok {
+		return nil, fmt.Errorf("Function 'unique' expects a list of items, got '%v'", arguments[0])
+	}
+
+	ret := make([]interface{}, 0, len(items))
+
+	for _, item := range items {
+
+		duplicate := false
+		for _, kept := range ret {
+			if isEqual(item, kept) {
+				duplicate = true
+				break
+			}
+		}
+
+		if !duplicate {
+			ret = append(ret, item)
+		}
+	}
+	return ret, nil
+}
+
+func toListFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("toList", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if arguments[0] == nil {
+		return []interface{}{}, nil
+	}
+
+	// bytes are a single value (like a string) here, even though they're a slice.
+	if !isBytes(arguments[0]) {
+
+		items, ok := collectionElements(arguments[0])
+		if ok {
+			return items, nil
+		}
+	}
+	return []interface{}{arguments[0]}, nil
+}
+
+func ordFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("ord", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkStringArguments("ord", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	character := arguments[0].(string)
+	if utf8.RuneCountInString(character) != 1 {
+		return nil, fmt.Errorf("Function 'ord' expects a single character, got '%s'", character)
+	}
+
+	codePoint, _ := utf8.DecodeRuneInString(character)
+	return float64(codePoint), nil
+}
+
+func chrFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("chr", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isFloat64(arguments[0]) {
+		return nil, errors.New("Function 'chr' expects a numeric code point")
+	}
+
+	codePoint := arguments[0].(float64)
+	if codePoint != float64(int32(codePoint)) || !utf8.ValidRune(rune(codePoint)) {
+		return nil, fmt.Errorf("Function 'chr' cannot convert '%v' to a character", codePoint)
+	}
+
+	return string(rune(codePoint)), nil
+}
+
+func hashFunction(arguments ...interface{}) (interface{}, error) {
+
+	return callHashFunction("hash", arguments, func(s string) float64 {
+
+		hash := fnv.New64a()
+		hash.Write([]byte(s))
+
+		// the top 53 bits are as many as a float64 can hold exactly, so every result is distinct and below 1.
+		return float64(hash.Sum64()>>11) / (1 << 53)
+	})
+}
+
+func crc32Function(arguments ...interface{}) (interface{}, error) {
+
+	return callHashFunction("crc32", arguments, func(s string) float64 {
+		return float64(crc32.ChecksumIEEE([]byte(s)))
+	})
+}
+
+func fnvFunction(arguments ...interface{}) (interface{}, error) {
+
+	return callHashFunction("fnv", arguments, func(s string) float64 {
+
+		hash := fnv.New32a()
+		hash.Write([]byte(s))
+		return float64(hash.Sum32())
+	})
+}
+
+/*
+	Checks that there is exactly one argument, which is a string, then returns the result of [function] on it.
+	Hashes are always calculated from the bytes of the string, so they're the same on every platform.
+*/
+func callHashFunction(name string, arguments []interface{}, function func(string) float64) (interface{}, error) {
+
+	err := checkArgumentCount(name, arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkStringArguments(name, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	return function(arguments[0].(string)), nil
+}
+
+func objectFunction(arguments ...interface{}) (interface{}, error) {
+
+	if len(arguments)%2 != 0 {
+		return nil, fmt.Errorf("Function 'object' expects pairs of keys and values, got %d arguments", len(arguments))
+	}
+
+	ret := NewOrderedMap()
+	for i := 0; i < len(arguments); i += 2 {
+
+		key, ok := arguments[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("Function 'object' expects string keys, got '%v'", arguments[i])
+		}
+
+		_, found := ret.Get(key)
+		if found {
+			return nil, fmt.Errorf("Function 'object' was given the key '%s' more than once", key)
+		}
+
+		ret.Set(key, arguments[i+1])
+	}
+	return ret, nil
+}
+
+func hasKeyFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("hasKey", arguments, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered, ok := arguments[0].(*OrderedMap)
+	if ok {
+		key, ok := arguments[1].(string)
+		if !ok {
+			return false, nil
+		}
+
+		_, found := ordered.Get(key)
+		return found, nil
+	}
+
+	reflected := reflect.ValueOf(arguments[0])
+	if reflected.Kind() != reflect.Map {
+		return nil, fmt.Errorf("Function 'hasKey' expects a map, got '%v'", arguments[0])
+	}
+
+	key, ok := mapKey(reflected.Type().Key(), arguments[1])
+	if !ok {
+		return false, nil
+	}
+	return reflected.MapIndex(key).IsValid(), nil
+}
+
+func keysFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("keys", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, _, err := mapEntries("keys", arguments[0])
+	return keys, err
+}
+
+func valuesFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("values", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	_, values, err := mapEntries("values", arguments[0])
+	return values, err
+}
+
+/*
+	Returns the given [key] as a key of a map whose keys are of the type [keyType], if it can be one.
+	Since numbers in expressions are float64, a whole number can be a key of a map with integer keys.
+	Returns false if it can't be, in which case the map can't have it.
+*/
+func mapKey(keyType reflect.Type, key interface{}) (reflect.Value, bool) {
+
+	if key == nil {
+		return reflect.Value{}, false
+	}
+
+	reflected := reflect.ValueOf(key)
+	if reflected.Type().AssignableTo(keyType) {
+		return reflected, true
+	}
+
+	// named string types (like "type Tag string").
+	if isString(key) && keyType.Kind() == reflect.String {
+		return reflected.Convert(keyType), true
+	}
+
+	number, ok := key.(float64)
+	if !ok || !isNumber(reflect.Zero(keyType).Interface()) {
+		return reflect.Value{}, false
+	}
+
+	// only numbers which survive the conversion unchanged (like 2, but not 2.5 for an int key) can be keys.
+	converted := reflected.Convert(keyType)
+	if castToFloat64(converted.Interface()) != number {
+		return reflect.Value{}, false
+	}
+	return converted, true
+}
+
+/*
+	Returns the keys and values of the given map [value], for the function of the given [name].
+	Maps built by object() keep their order; the keys of any other map are sorted, so that the order is always the same.
+*/
+func mapEntries(name string, value interface{}) ([]interface{}, []interface{}, error) {
+
+	ordered, ok := value.(*OrderedMap)
+	if ok {
+
+		keys := make([]interface{}, 0, ordered.Len())
+		for _, key := range ordered.Keys() {
+			keys = append(keys, key)
+		}
+		return keys, ordered.Values(), nil
+	}
+
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() != reflect.Map {
+		return nil, nil, fmt.Errorf("Function '%s' expects a map, got '%v'", name, value)
+	}
+
+	mapKeys := reflected.MapKeys()
+	sort.Slice(mapKeys, func(i, j int) bool {
+		return isKeyBefore(castToFloat64(mapKeys[i].Interface()), castToFloat64(mapKeys[j].Interface()))
+	})
+
+	keys := make([]interface{}, len(mapKeys))
+	values := make([]interface{}, len(mapKeys))
+
+	for i, key := range mapKeys {
+		keys[i] = castToFloat64(key.Interface())
+		values[i] = castToFloat64(reflected.MapIndex(key).Interface())
+	}
+	return keys, values, nil
+}
+
+/*
+	Returns true if the map key [left] sorts before [right]: strings and numbers in their usual order, and anything else by how it's written.
+*/
+func isKeyBefore(left interface{}, right interface{}) bool {
+
+	if isString(left) && isString(right) {
+		return left.(string) < right.(string)
+	}
+
+	if isNumber(left) && isNumber(right) {
+		comparison, _ := compareNumbers(left, right)
+		return comparison < 0
+	}
+	return fmt.Sprintf("%v", left) < fmt.Sprintf("%v", right)
+}
+
+func typeofFunction(arguments ...interface{}) (interface{}, error) {
+
+	err := checkArgumentCount("typeof", arguments, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return friendlyTypeName(arguments[0]), nil
+}
+
+/*
+	Returns the name that expression authors would use for the type of the given [value].
+*/
+func friendlyTypeName(value interface{}) string {
+
+	if isNil(value) {
+		return "null"
+	}
+
+	if isBigInt(value) {
+		return "number"
+	}
+
+	_, ok := value.(*OrderedMap)
+	if ok {
+		return "map"
+	}
+
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Float32:
+		fallthrough
+	case reflect.Float64:
+		fallthrough
+	case reflect.Int:
+		fallthrough
+	case reflect.Int8:
+		fallthrough
+	case reflect.Int16:
+		fallthrough
+	case reflect.Int32:
+		fallthrough
+	case reflect.Int64:
+		fallthrough
+	case reflect.Uint:
+		fallthrough
+	case reflect.Uint8:
+		fallthrough
+	case reflect.Uint16:
+		fallthrough
+	case reflect.Uint32:
+		fallthrough
+	case reflect.Uint64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Slice:
+		fallthrough
+	case reflect.Array:
+		return "array"
+	case reflect.Map:
+		return "map"
+	}
+	return "object"
+}
+
+/*
+	Returns the length of the given slice, array, map, or string [value].
+	Returns false if [value] is not one of those, and doesn't have a length.
+*/
+func collectionLength(value interface{}) (int, bool) {
+
+	if value == nil {
+		return 0, false
+	}
+
+	reflected := reflect.ValueOf(value)
+
+	switch reflected.Kind() {
+	case reflect.Slice:
+		fallthrough
+	case reflect.Array:
+		fallthrough
+	case reflect.Map:
+		fallthrough
+	case reflect.String:
+		return reflected.Len(), true
+	}
+	return 0, false
+}
+
+/*
+	Returns the elements of the given slice or array [value], converting numbers to float64 the same way parameters are.
+	Returns false if [value] isn't a slice or array.
+*/
+func collectionElements(value interface{}) ([]interface{}, bool) {
+
+	if value == nil {
+		return nil, false
+	}
+
+	reflected := reflect.ValueOf(value)
+
+	switch reflected.Kind() {
+	case reflect.Slice:
+		fallthrough
+	case reflect.Array:
+
+		ret := make([]interface{}, reflected.Len())
+		for i := range ret {
+			ret[i] = castToFloat64(reflected.Index(i).Interface())
+		}
+		return ret, true
+	}
+	return nil, false
+}
+
+/*
+	Returns an error if the given [arguments] do not contain exactly [count] elements.
+*/
+func checkArgumentCount(name string, arguments []interface{}, count int) error {
+
+	if len(arguments) != count {
+		return fmt.Errorf("Function '%s' expects %d arguments, got %d", name, count, len(arguments))
+	}
+	return nil
+}
+
+/*
+	Checks that there are exactly [count] [arguments], all of which are strings, then returns the result of [function] on them.
+*/
+func callStringFunction(name string, arguments []interface{}, count int, function func([]string) string) (interface{}, error) {
+
+	err := checkArgumentCount(name, arguments, count)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkStringArguments(name, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	strs := make([]string, count)
+	for i, argument := range arguments {
+		strs[i] = argument.(string)
+	}
+	return function(strs), nil
+}
+
+/*
+	Returns an error if any of the given [arguments] are not strings.
+*/
+func checkStringArguments(name string, arguments []interface{}) error {
+
+	for _, argument := range arguments {
+		if !isString(argument) {
+			return fmt.Errorf("Function '%s' expects string arguments", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,1072 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+/*
+	Tests the functions which are available to every expression without being passed in.
+*/
+func TestBuiltinFunctions(test *testing.T) {
+
+	var nilPointer *dummyParameter
+
+	evaluationTests := []EvaluationTest{
+
+		EvaluationTest{
+
+			Name:     "ifnull with non-nil value",
+			Input:    "ifnull(1, 2)",
+			Expected: 1.0,
+		},
+		EvaluationTest{
+
+			Name:  "ifnull with nil parameter",
+			Input: "ifnull(foo, 'default')",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "foo",
+					Value: nil,
+				},
+			},
+			Expected: "default",
+		},
+		EvaluationTest{
+
+			Name:  "ifnull with typed nil parameter",
+			Input: "ifnull(foo, 'default')",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "foo",
+					Value: nilPointer,
+				},
+			},
+			Expected: "default",
+		},
+		EvaluationTest{
+
+			Name:  "nvl alias",
+			Input: "nvl(foo, 5) + 1",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "foo",
+					Value: nil,
+				},
+			},
+			Expected: 6.0,
+		},
+		EvaluationTest{
+
+			Name:  "ifnull with whitespace before parenthesis",
+			Input: "ifnull (foo, 2)",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "foo",
+					Value: nil,
+				},
+			},
+			Expected: 2.0,
+		},
+		EvaluationTest{
+
+			Name:     "nullif with equal values",
+			Input:    "nullif('a', 'a') ?? 'was null'",
+			Expected: "was null",
+		},
+		EvaluationTest{
+
+			Name:     "nullif with different values",
+			Input:    "nullif(1, 2)",
+			Expected: 1.0,
+		},
+		EvaluationTest{
+
+			Name:  "switch with a matching case",
+			Input: "switch(x, 1, 'one', 2, 'two', 'many')",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "x",
+					Value: 2,
+				},
+			},
+			Expected: "two",
+		},
+		EvaluationTest{
+
+			Name:     "switch without a matching case",
+			Input:    "switch('c', 'a', 1, 'b', 2, 0)",
+			Expected: 0.0,
+		},
+		EvaluationTest{
+
+			Name:     "switch without a default",
+			Input:    "switch(3, 1, 'one', 2, 'two') ?? 'none'",
+			Expected: "none",
+		},
+		EvaluationTest{
+
+			Name:     "switch with only a default",
+			Input:    "switch(1, 'default')",
+			Expected: "default",
+		},
+		EvaluationTest{
+
+			Name:  "nullif with nil and typed nil",
+			Input: "nullif(foo, bar) ?? 'was null'",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "foo",
+					Value: nil,
+				},
+				EvaluationParameter{
+					Name:  "bar",
+					Value: nilPointer,
+				},
+			},
+			Expected: "was null",
+		},
+		EvaluationTest{
+
+			Name:     "fail in an untaken ternary branch",
+			Input:    "true ? 'ok' : fail('invalid input')",
+			Expected: "ok",
+		},
+		EvaluationTest{
+
+			Name:  "Built-in name used as a parameter",
+			Input: "ifnull + 1",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "ifnull",
+					Value: 1,
+				},
+			},
+			Expected: 2.0,
+		},
+		EvaluationTest{
+
+			Name:  "User function overrides built-in",
+			Input: "ifnull(1, 2)",
+			Functions: map[string]ExpressionFunction{
+				"ifnull": func(arguments ...interface{}) (interface{}, error) {
+					return "overridden", nil
+				},
+			},
+			Expected: "overridden",
+		},
+		EvaluationTest{
+
+			Name:  "split used with in",
+			Input: "'b' in split(csv, ',')",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "csv",
+					Value: "a,b,c",
+				},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:  "split passed to a function",
+			Input: "count(split('a,b,c', ','))",
+			Functions: map[string]ExpressionFunction{
+				"count": func(arguments ...interface{}) (interface{}, error) {
+					return float64(len(arguments[0].([]interface{}))), nil
+				},
+			},
+			Expected: 3.0,
+		},
+		EvaluationTest{
+
+			Name:  "split passed alongside other arguments",
+			Input: "arguments(split('a,b,c', ','), 'd')",
+			Functions: map[string]ExpressionFunction{
+				"arguments": func(arguments ...interface{}) (interface{}, error) {
+					return float64(len(arguments)), nil
+				},
+			},
+			Expected: 2.0,
+		},
+		EvaluationTest{
+
+			Name:  "lenCompare with longer left side",
+			Input: "lenCompare(foo, bar)",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "foo",
+					Value: []string{"a", "b", "c"},
+				},
+				EvaluationParameter{
+					Name:  "bar",
+					Value: []int{1, 2},
+				},
+			},
+			Expected: 1.0,
+		},
+		EvaluationTest{
+
+			Name:  "lenCompare with shorter left side",
+			Input: "lenCompare(foo, split('a,b', ','))",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "foo",
+					Value: []interface{}{},
+				},
+			},
+			Expected: -1.0,
+		},
+		EvaluationTest{
+
+			Name:  "lenCompare with equal lengths",
+			Input: "lenCompare(foo, 'ab') == 0",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "foo",
+					Value: map[string]int{"a": 1, "b": 2},
+				},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:  "groups",
+			Input: "'05' in groups(date, '([0-9]+)-([0-9]+)-([0-9]+)')",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "date",
+					Value: "2024-05-06",
+				},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:  "groups passed to a function",
+			Input: "count(groups('key=value', '([a-z]+)=([a-z]+)'))",
+			Functions: map[string]ExpressionFunction{
+				"count": func(arguments ...interface{}) (interface{}, error) {
+					return float64(len(arguments[0].([]interface{}))), nil
+				},
+			},
+			Expected: 2.0,
+		},
+		EvaluationTest{
+
+			Name:     "groups without a match",
+			Input:    "ifnull(groups('abc', '([0-9]+)'), 'none')",
+			Expected: "none",
+		},
+		EvaluationTest{
+
+			Name:     "cmp with strings",
+			Input:    "cmp('apple', 'banana')",
+			Expected: -1.0,
+		},
+		EvaluationTest{
+
+			Name:  "cmp with mixed numeric types",
+			Input: "cmp(foo, 2.5)",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "foo",
+					Value: int64(3),
+				},
+			},
+			Expected: 1.0,
+		},
+		EvaluationTest{
+
+			Name:     "cmp with equal numbers",
+			Input:    "cmp(4, 2 * 2)",
+			Expected: 0.0,
+		},
+		EvaluationTest{
+
+			Name:  "cmp with times",
+			Input: "cmp(start, end)",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "start",
+					Value: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				},
+				EvaluationParameter{
+					Name:  "end",
+					Value: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+				},
+			},
+			Expected: -1.0,
+		},
+		EvaluationTest{
+
+			Name:     "hash",
+			Input:    "hash('user-42')",
+			Expected: 0.1983465937202803,
+		},
+		EvaluationTest{
+
+			Name:  "hash for sampling",
+			Input: "hash(id) < 0.5",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "id",
+					Value: "user-42",
+				},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:     "crc32",
+			Input:    "crc32('user-42')",
+			Expected: 2097592435.0,
+		},
+		EvaluationTest{
+
+			Name:     "fnv",
+			Input:    "fnv('user-42')",
+			Expected: 39875499.0,
+		},
+		EvaluationTest{
+
+			Name:  "percent",
+			Input: "price - percent(price, discount)",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "price",
+					Value: 80,
+				},
+				EvaluationParameter{
+					Name:  "discount",
+					Value: 25,
+				},
+			},
+			Expected: 60.0,
+		},
+		EvaluationTest{
+
+			Name:     "percent is not modulus",
+			Input:    "percent(50, 10) == 5 && 50 % 10 == 0",
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:     "mod with a negative dividend",
+			Input:    "mod(-1, 3) == 2 && -1 % 3 == -1",
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:     "mod with a negative divisor",
+			Input:    "mod(-7, -3) == 2 && mod(7, -3) == 1",
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:     "mod with positive numbers",
+			Input:    "mod(7.5, 2)",
+			Expected: 1.5,
+		},
+		EvaluationTest{
+
+			Name:     "contains",
+			Input:    "contains('hello world', 'o w') && !contains('hello', 'z')",
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:  "listContains",
+			Input: "listContains(tags, 'b') && listContains(ids, 2) && !listContains(tags, 'z')",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "tags",
+					Value: []string{"a", "b"},
+				},
+				EvaluationParameter{
+					Name:  "ids",
+					Value: []int{1, 2, 3},
+				},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:     "listContains doesn't search within strings",
+			Input:    "listContains(split('a,b', ','), 'a,b')",
+			Expected: false,
+		},
+		EvaluationTest{
+
+			Name:  "unique",
+			Input: "reduce(unique(tags), 'acc .. it', '') .. reduce(unique(ids), 'acc .. it', '')",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "tags",
+					Value: []string{"b", "a", "b", "c", "a"},
+				},
+				EvaluationParameter{
+					Name:  "ids",
+					Value: []int{3, 1, 3, 3},
+				},
+			},
+			Expected: "bac31",
+		},
+		EvaluationTest{
+
+			Name:     "round",
+			Input:    "round(2.5, 0) .. ' ' .. round(-2.5, 0) .. ' ' .. round(2.675, 2) .. ' ' .. round(1234, -2) .. ' ' .. round(1.005, 2)",
+			Expected: "3 -3 2.68 1200 1.01",
+		},
+		EvaluationTest{
+
+			Name:     "roundEven",
+			Input:    "roundEven(2.5, 0) .. ' ' .. roundEven(3.5, 0) .. ' ' .. roundEven(-2.5, 0) .. ' ' .. roundEven(2.675, 2) .. ' ' .. roundEven(2.665, 2) .. ' ' .. roundEven(2.6651, 2) .. ' ' .. roundEven(1250, -2)",
+			Expected: "2 4 -2 2.68 2.66 2.67 1200",
+		},
+		EvaluationTest{
+
+			Name:  "toList",
+			Input: "reduce(toList(one), 'acc .. it', '') .. reduce(toList(many), 'acc .. it', '') .. reduce(toList(none), 'acc .. it', '-')",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "one",
+					Value: "a",
+				},
+				EvaluationParameter{
+					Name:  "many",
+					Value: []int{1, 2},
+				},
+				EvaluationParameter{
+					Name:  "none",
+					Value: nil,
+				},
+			},
+			Expected: "a12-",
+		},
+		EvaluationTest{
+
+			Name:  "toList for membership",
+			Input: "3 in toList(ids) && 'admin' in toList(role)",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "ids",
+					Value: []int{1, 3},
+				},
+				EvaluationParameter{
+					Name:  "role",
+					Value: "admin",
+				},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:  "hasKey",
+			Input: "hasKey(flags, 'beta') && !hasKey(flags, 'gamma') && hasKey(limits, 2) && !hasKey(limits, 2.5) && hasKey(object('a', 1), 'a')",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "flags",
+					Value: map[string]interface{}{"alpha": true, "beta": false},
+				},
+				EvaluationParameter{
+					Name:  "limits",
+					Value: map[int]string{1: "low", 2: "high"},
+				},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:  "keys and values",
+			Input: "reduce(keys(m), 'acc .. it', '') .. reduce(values(m), 'acc .. it', '') .. reduce(keys(ids), 'acc .. it', '')",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "m",
+					Value: map[string]int{"c": 3, "a": 1, "b": 2},
+				},
+				EvaluationParameter{
+					Name:  "ids",
+					Value: map[int]bool{10: true, 9: true, 100: false},
+				},
+			},
+			Expected: "abc123910100",
+		},
+		EvaluationTest{
+
+			Name:     "keys of an object keep their order",
+			Input:    "reduce(keys(object('z', 1, 'a', 2)), 'acc .. it', '')",
+			Expected: "za",
+		},
+		EvaluationTest{
+
+			Name:  "unique of lists",
+			Input: "reduce(unique(pairs), 'acc + 1', 0)",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "pairs",
+					Value: []interface{}{[]int{1, 2}, []int{1, 2}, []int{2, 1}},
+				},
+			},
+			Expected: 2.0,
+		},
+		EvaluationTest{
+
+			Name:  "typeof",
+			Input: "typeof(1) .. typeof('a') .. typeof(true) .. typeof(foo) .. typeof(bar) .. typeof(baz) .. typeof(qux)",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "foo",
+					Value: nil,
+				},
+				EvaluationParameter{
+					Name:  "bar",
+					Value: []string{"a"},
+				},
+				EvaluationParameter{
+					Name:  "baz",
+					Value: map[string]int{},
+				},
+				EvaluationParameter{
+					Name:  "qux",
+					Value: dummyParameter{},
+				},
+			},
+			Expected: "numberstringboolnullarraymapobject",
+		},
+		EvaluationTest{
+
+			Name:  "replace",
+			Input: "replace(name, ' ', '_')",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "name",
+					Value: "a b c",
+				},
+			},
+			Expected: "a_b_c",
+		},
+		EvaluationTest{
+
+			Name:  "matchNamed",
+			Input: "field(matchNamed(version, '^v(?P<major>[0-9]+)\\.(?P<minor>[0-9]+)'), 'minor') == '12'",
+			Functions: map[string]ExpressionFunction{
+				"field": func(arguments ...interface{}) (interface{}, error) {
+					return arguments[0].(map[string]interface{})[arguments[1].(string)], nil
+				},
+			},
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "version",
+					Value: "v1.12",
+				},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:     "matchNamed without a match",
+			Input:    "matchNamed('release', '^v(?P<major>[0-9]+)') ?? 'none'",
+			Expected: "none",
+		},
+		EvaluationTest{
+
+			Name:     "replaceN",
+			Input:    "replaceN('a b c', ' ', '', 1)",
+			Expected: "ab c",
+		},
+		EvaluationTest{
+
+			Name:     "replaceN with a negative count",
+			Input:    "replaceN('a b c', ' ', '', -1)",
+			Expected: "abc",
+		},
+		EvaluationTest{
+
+			Name:  "typeof used defensively",
+			Input: "typeof(foo) == 'string' ? foo == '1' : foo == 1",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "foo",
+					Value: int32(1),
+				},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:  "upper and lower",
+			Input: "lower(role) == 'admin' && upper(role) == 'ADMIN'",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "role",
+					Value: "AdMiN",
+				},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:     "trim",
+			Input:    "trim('  \tfoo bar\n ')",
+			Expected: "foo bar",
+		},
+		EvaluationTest{
+
+			Name:     "trimLeft and trimRight",
+			Input:    "trimLeft('0042', '0') .. trimRight('foo!?!', '?!')",
+			Expected: "42foo",
+		},
+		EvaluationTest{
+
+			Name:  "indexOf",
+			Input: "indexOf(items, 'b') .. indexOf(items, 'd') .. indexOf(numbers, 2)",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "items",
+					Value: []string{"a", "b", "c", "b"},
+				},
+				EvaluationParameter{
+					Name:  "numbers",
+					Value: []int{1, 2},
+				},
+			},
+			Expected: "1-11",
+		},
+		EvaluationTest{
+
+			Name:  "indexOf in an empty list",
+			Input: "indexOf(items, 1)",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "items",
+					Value: []interface{}{},
+				},
+			},
+			Expected: -1.0,
+		},
+		EvaluationTest{
+
+			Name:     "indexOf in a split string",
+			Input:    "indexOf(split('x,y,z', ','), 'z')",
+			Expected: 2.0,
+		},
+		EvaluationTest{
+
+			Name:     "typeof object",
+			Input:    "typeof(object('a', 1))",
+			Expected: "map",
+		},
+		EvaluationTest{
+
+			Name:  "ord",
+			Input: "ord(initial) >= ord('A') && ord(initial) <= ord('Z')",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "initial",
+					Value: "G",
+				},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:     "ord of a multibyte character",
+			Input:    "ord('é')",
+			Expected: 233.0,
+		},
+		EvaluationTest{
+
+			Name:     "chr",
+			Input:    "chr(ord('a') + 1) .. chr(9731)",
+			Expected: "b☃",
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+}
+
+func TestBuiltinFunctionFailures(test *testing.T) {
+
+	evaluationTests := []EvaluationFailureTest{
+
+		EvaluationFailureTest{
+
+			Name:     "ifnull with too few arguments",
+			Input:    "ifnull(1)",
+			Expected: "expects 2 arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:     "nullif with too many arguments",
+			Input:    "nullif(1, 2, 3)",
+			Expected: "expects 2 arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:     "switch without cases",
+			Input:    "switch(1)",
+			Expected: "expects at least 2 arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:     "mod with a string",
+			Input:    "mod('7', 2)",
+			Expected: "Function 'mod' expects numeric arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:  "contains with a list",
+			Input: "contains(tags, 'a')",
+			Parameters: map[string]interface{}{
+				"tags": []interface{}{"a"},
+			},
+			Expected: "Function 'contains' expects string arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:     "listContains with a string",
+			Input:    "listContains('abc', 'a')",
+			Expected: "Function 'listContains' expects a list of items, got 'abc'",
+		},
+		EvaluationFailureTest{
+
+			Name:     "keys of a list",
+			Input:    "keys(split('a,b', ','))",
+			Expected: "Function 'keys' expects a map",
+		},
+		EvaluationFailureTest{
+
+			Name:     "hasKey of a string",
+			Input:    "hasKey('abc', 'a')",
+			Expected: "Function 'hasKey' expects a map, got 'abc'",
+		},
+		EvaluationFailureTest{
+
+			Name:     "round to part of a place",
+			Input:    "round(1.234, 1.5)",
+			Expected: "Function 'round' expects a whole number of decimal places, got '1.5'",
+		},
+		EvaluationFailureTest{
+
+			Name:     "roundEven of a string",
+			Input:    "roundEven('1.5', 0)",
+			Expected: "Function 'roundEven' expects numeric arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:     "round to too many places",
+			Input:    "round(1, 1000)",
+			Expected: "can round to at most 400 decimal places",
+		},
+		EvaluationFailureTest{
+
+			Name:     "unique of a string",
+			Input:    "unique('abc')",
+			Expected: "Function 'unique' expects a list of items, got 'abc'",
+		},
+		EvaluationFailureTest{
+
+			Name:     "fail without a message",
+			Input:    "fail()",
+			Expected: "expects 1 arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:     "split with a non-string",
+			Input:    "split(1, ',')",
+			Expected: "expects string arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:     "lenCompare with a number",
+			Input:    "lenCompare(1, 'a')",
+			Expected: "cannot take the length of '1'",
+		},
+		EvaluationFailureTest{
+
+			Name:     "groups with an invalid pattern",
+			Input:    "groups('a', '(')",
+			Expected: "Unable to compile regexp pattern",
+		},
+		EvaluationFailureTest{
+
+			Name:     "hash of a number",
+			Input:    "hash(5)",
+			Expected: "Function 'hash' expects string arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:     "cmp with mixed types",
+			Input:    "cmp(1, 'a')",
+			Expected: "cannot compare '1' (a number) with 'a' (a string)",
+		},
+		EvaluationFailureTest{
+
+			Name:     "cmp with bools",
+			Input:    "cmp(true, false)",
+			Expected: "cannot compare 'true' (a bool)",
+		},
+		EvaluationFailureTest{
+
+			Name:     "percent of a string",
+			Input:    "percent('10', 5)",
+			Expected: "expects numeric arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:     "matchNamed with an invalid pattern",
+			Input:    "matchNamed('a', '(')",
+			Expected: "Unable to compile regexp pattern",
+		},
+		EvaluationFailureTest{
+
+			Name:     "replace with a number",
+			Input:    "replace('a1', 1, '2')",
+			Expected: "Function 'replace' expects string arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:     "replaceN with a string count",
+			Input:    "replaceN('aa', 'a', 'b', '1')",
+			Expected: "expects a numeric count",
+		},
+		EvaluationFailureTest{
+
+			Name:     "upper of a number",
+			Input:    "upper(1)",
+			Expected: "Function 'upper' expects string arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:     "trimLeft without a cutset",
+			Input:    "trimLeft('foo')",
+			Expected: "expects 2 arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:     "trimRight with a numeric cutset",
+			Input:    "trimRight('foo', 1)",
+			Expected: "Function 'trimRight' expects string arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:     "indexOf in a string",
+			Input:    "indexOf('abc', 'b')",
+			Expected: "Function 'indexOf' expects a list of items, got 'abc'",
+		},
+		EvaluationFailureTest{
+
+			Name:     "object with a missing value",
+			Input:    "object('a', 1, 'b')",
+			Expected: "expects pairs of keys and values, got 3 arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:     "object with a numeric key",
+			Input:    "object(1, 'a')",
+			Expected: "expects string keys, got '1'",
+		},
+		EvaluationFailureTest{
+
+			Name:     "object with a duplicate key",
+			Input:    "object('a', 1, 'a', 2)",
+			Expected: "was given the key 'a' more than once",
+		},
+		EvaluationFailureTest{
+
+			Name:     "ord of more than one character",
+			Input:    "ord('ab')",
+			Expected: "expects a single character, got 'ab'",
+		},
+		EvaluationFailureTest{
+
+			Name:     "ord of an empty string",
+			Input:    "ord('')",
+			Expected: "expects a single character",
+		},
+		EvaluationFailureTest{
+
+			Name:     "chr of a fraction",
+			Input:    "chr(65.5)",
+			Expected: "cannot convert '65.5' to a character",
+		},
+		EvaluationFailureTest{
+
+			Name:     "chr of a string",
+			Input:    "chr('A')",
+			Expected: "expects a numeric code point",
+		},
+	}
+
+	runEvaluationFailureTests(evaluationTests, test)
+}
+
+/*
+	The message given to fail() must come out of Evaluate() exactly as it was written.
+*/
+func TestFailMessage(test *testing.T) {
+
+	expression, err := NewEvaluableExpression("valid ? result : fail('invalid input: ' + reason)")
+	if err != nil {
+		test.Logf("Unable to parse expression: %v", err)
+		test.Fail()
+		return
+	}
+
+	parameters := map[string]interface{}{
+		"valid":  false,
+		"result": 1,
+		"reason": "too short",
+	}
+
+	_, err = expression.Evaluate(parameters)
+	if err == nil || err.Error() != "invalid input: too short" {
+		test.Logf("Expected error 'invalid input: too short', got '%v'", err)
+		test.Fail()
+	}
+}
+
+func TestObjectFunction(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("object('score', a + b, 'grade', a > 90 ? 'A' : 'B', 'tags', split(tags, ','), 'empty', object())")
+
+	result, err := expression.Evaluate(map[string]interface{}{"a": 95, "b": 2, "tags": "x,y"})
+	if err != nil {
+		test.Logf("Unable to evaluate object(): %v", err)
+		test.Fail()
+		return
+	}
+
+	serialized, err := json.Marshal(result)
+	if err != nil {
+		test.Logf("Unable to serialize object(): %v", err)
+		test.Fail()
+		return
+	}
+
+	expected := `{"score":97,"grade":"A","tags":["x","y"],"empty":{}}`
+	if string(serialized) != expected {
+		test.Logf("Expected %s, got %s", expected, serialized)
+		test.Fail()
+	}
+}
+
+func TestTryFunction(test *testing.T) {
+
+	evaluationTests := []EvaluationTest{
+
+		EvaluationTest{
+
+			Name:     "try with a failing function",
+			Input:    "try(fail('oops'), 'fallback')",
+			Expected: "fallback",
+		},
+		EvaluationTest{
+
+			Name:     "try with a missing parameter",
+			Input:    "try(missing * 2, 0) + 1",
+			Expected: 1.0,
+		},
+		EvaluationTest{
+
+			Name:  "try with a type error",
+			Input: "try(name > 1, false)",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "name",
+					Value: "bob",
+				},
+			},
+			Expected: false,
+		},
+		EvaluationTest{
+
+			Name:     "try without an error doesn't evaluate the fallback",
+			Input:    "try(1 + 1, fail('evaluated'))",
+			Expected: 2.0,
+		},
+		EvaluationTest{
+
+			Name:     "nested try",
+			Input:    "try(fail('a'), try(fail('b'), 'c'))",
+			Expected: "c",
+		},
+		EvaluationTest{
+
+			Name:  "try overridden by a user-defined function",
+			Input: "try(1, 2)",
+			Functions: map[string]ExpressionFunction{
+				"try": func(arguments ...interface{}) (interface{}, error) {
+					return arguments[1], nil
+				},
+			},
+			Expected: 2.0,
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+
+	failureTests := []EvaluationFailureTest{
+
+		EvaluationFailureTest{
+
+			Name:     "try with a failing fallback",
+			Input:    "try(fail('a'), fail('b'))",
+			Expected: "b",
+		},
+		EvaluationFailureTest{
+
+			Name:     "try with too many arguments",
+			Input:    "try(1, 2, 3)",
+			Expected: "expects 2 arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:     "try with one argument",
+			Input:    "try(fail('a'))",
+			Expected: "a",
+		},
+	}
+
+	runEvaluationFailureTests(failureTests, test)
+}
+
+func TestNullifMatchesEquality(test *testing.T) {
+
+	nullif, _ := NewEvaluableExpression("nullif(a, b)")
+	equal, _ := NewEvaluableExpression("a == b")
+
+	pairs := [][2]interface{}{
+		{"a", "a"},
+		{"a", "b"},
+		{1, 1.0},
+		{int64(3), uint8(3)},
+		{uint64(1 << 63), int64(-1)},
+		{[]byte("ab"), []byte("ab")},
+		{[]byte("ab"), []byte("ac")},
+		{[]byte{}, []byte(nil)},
+		{nil, nil},
+		{1.0, nil},
+		{true, true},
+	}
+
+	for _, pair := range pairs {
+
+		parameters := map[string]interface{}{"a": pair[0], "b": pair[1]}
+
+		expected, err := equal.Evaluate(parameters)
+		if err != nil {
+			test.Logf("Unable to compare %#v with %#v: %v", pair[0], pair[1], err)
+			test.Fail()
+			continue
+		}
+
+		result, err := nullif.Evaluate(parameters)
+		if err != nil || (result == nil) != expected {
+			test.Logf("Expected nullif(%#v, %#v) to be nil only when they're equal (%v), got %v, %v", pair[0], pair[1], expected, result, err)
+			test.Fail()
+		}
+	}
+}
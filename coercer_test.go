@@ -0,0 +1,85 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+type coercerTest struct {
+	input    string
+	expected interface{}
+}
+
+/*
+	Converts json.Numbers and strings of digits to float64, and leaves everything else alone.
+*/
+func numericCoercer(name string, value interface{}) (interface{}, error) {
+
+	switch value.(type) {
+	case json.Number:
+		return value.(json.Number).Float64()
+	case string:
+
+		number, err := strconv.ParseFloat(value.(string), 64)
+		if err == nil {
+			return number, nil
+		}
+		if name == "count" {
+			return nil, fmt.Errorf("Parameter '%s' is not a number", name)
+		}
+	}
+	return value, nil
+}
+
+func TestCoercer(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"price":  json.Number("2.5"),
+		"amount": "4",
+		"name":   "bob",
+		"count":  "many",
+		"small":  int8(3),
+	}
+
+	coercerTests := []coercerTest{
+		coercerTest{"price * amount", 10.0},
+		coercerTest{"amount > 3", true},
+		coercerTest{"name == 'bob'", true},
+		coercerTest{"small + 1", 4.0},
+	}
+
+	for _, coercerTest := range coercerTests {
+
+		expression, _ := NewEvaluableExpression(coercerTest.input)
+		expression.Coercer = numericCoercer
+
+		result, err := expression.Evaluate(parameters)
+		if err != nil || result != coercerTest.expected {
+			test.Logf("Expected '%s' to evaluate to %v, got %v, %v", coercerTest.input, coercerTest.expected, result, err)
+			test.Fail()
+		}
+	}
+
+	expression, _ := NewEvaluableExpression("count > 1")
+	expression.Coercer = numericCoercer
+
+	_, err := expression.Evaluate(parameters)
+	if err == nil || err.Error() != "Parameter 'count' is not a number" {
+		test.Logf("Expected the coercer's error to be returned, got %v", err)
+		test.Fail()
+	}
+
+	// values are only converted to float64 after the coercer has seen them.
+	expression, _ = NewEvaluableExpression("small")
+	expression.Coercer = func(name string, value interface{}) (interface{}, error) {
+		return fmt.Sprintf("%T", value), nil
+	}
+
+	result, _ := expression.Evaluate(parameters)
+	if result != "int8" {
+		test.Logf("Expected the coercer to see the original value, got %v", result)
+		test.Fail()
+	}
+}
@@ -0,0 +1,66 @@
+package govaluate
+
+import (
+	"context"
+)
+
+/*
+	A function which can be called from within an expression, like an ExpressionFunction,
+	but which is also given the context that the expression is being evaluated with (see `EvaluateWithContext`),
+	so that it can respect the same deadline or cancellation. Give these to `ParsingOptions.ContextFunctions`.
+	When the expression is evaluated without a context, they're given context.Background().
+*/
+type ContextFunction func(ctx context.Context, arguments ...interface{}) (interface{}, error)
+
+/*
+	Returns an ExpressionFunction which calls this function with the given [ctx].
+*/
+func (this ContextFunction) withContext(ctx context.Context) ExpressionFunction {
+
+	return func(arguments ...interface{}) (interface{}, error) {
+		return this(ctx, arguments...)
+	}
+}
+
+/*
+	Parameters which carry the context an expression is being evaluated with, for its ContextFunctions.
+*/
+type contextParameters struct {
+	Parameters
+	ctx context.Context
+}
+
+/*
+	Returns the context that the given [parameters] were given with `EvaluateWithContext`, or context.Background() if there isn't one.
+*/
+func findContext(parameters Parameters) context.Context {
+
+	for {
+		switch typed := parameters.(type) {
+		case contextParameters:
+			return typed.ctx
+		case *sanitizedParameters:
+			parameters = typed.orig
+		case *outerEvaluation:
+			parameters = typed.Parameters
+		default:
+			return context.Background()
+		}
+	}
+}
+
+/*
+	Same as `makeFunctionStage`, except that [function] is also given the context the expression is being evaluated with.
+*/
+func makeContextFunctionStage(function ContextFunction, style argumentStyle) evaluationOperator {
+
+	return func(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+		// evaluations without a context still count towards `MaxRecursionDepth` for any the function starts.
+		ctx := findContext(parameters)
+		if contextDepth(ctx) == 0 {
+			ctx = withNestedEvaluation(ctx)
+		}
+		return callFunction(function.withContext(ctx), style, right)
+	}
+}
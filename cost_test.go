@@ -0,0 +1,47 @@
+package govaluate
+
+import (
+	"testing"
+)
+
+type costTest struct {
+	input    string
+	expected int
+}
+
+func TestEstimateCost(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"foo": func(arguments ...interface{}) (interface{}, error) {
+			return nil, nil
+		},
+	}
+
+	costTests := []costTest{
+		costTest{"1 + 2 * 3", 0},
+		costTest{"a", 1},
+		costTest{"a + 1", 2},
+		costTest{"(a > 1) && (b < 2)", 5},
+		costTest{"a ** 2", 6},
+		costTest{"a =~ '^foo'", 11},
+		costTest{"a =~ b", 52},
+		costTest{"a.Field", 4},
+		costTest{"foo(a, 1)", 6},
+	}
+
+	for _, costTest := range costTests {
+
+		expression, err := NewEvaluableExpressionWithFunctions(costTest.input, functions)
+		if err != nil {
+			test.Logf("Unable to parse '%s': %v", costTest.input, err)
+			test.Fail()
+			continue
+		}
+
+		cost := expression.EstimateCost()
+		if cost != costTest.expected {
+			test.Logf("Expected '%s' to cost %d, got %d", costTest.input, costTest.expected, cost)
+			test.Fail()
+		}
+	}
+}
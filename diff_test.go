@@ -0,0 +1,113 @@
+package govaluate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFunctions(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"lookup": func(arguments ...interface{}) (interface{}, error) {
+			return nil, nil
+		},
+	}
+
+	expression, err := NewEvaluableExpressionWithFunctions("lookup(a) > 1 && upper(lookup(b)) == 'X' || c", functions)
+	if err != nil {
+		test.Fatalf("Unexpected parsing error: %v", err)
+	}
+
+	expected := []string{"lookup", "upper", "lookup"}
+	actual := expression.Functions()
+
+	if !reflect.DeepEqual(actual, expected) {
+		test.Logf("Expected functions %v, got %v", expected, actual)
+		test.Fail()
+	}
+
+	expression, _ = NewEvaluableExpression("a + 1")
+	if len(expression.Functions()) != 0 {
+		test.Logf("Expected no functions, got %v", expression.Functions())
+		test.Fail()
+	}
+}
+
+func TestDiff(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"lookup": func(arguments ...interface{}) (interface{}, error) {
+			return nil, nil
+		},
+		"score": func(arguments ...interface{}) (interface{}, error) {
+			return nil, nil
+		},
+	}
+
+	cases := []struct {
+		before   string
+		after    string
+		expected ExpressionDiff
+	}{
+		{
+			before:   "a > 1 && lookup(b)",
+			after:    "lookup(b) && a > 1",
+			expected: ExpressionDiff{},
+		},
+		{
+			before: "a > 1 && lookup(b)",
+			after:  "a > 1 && score(b, c) > d && upper(e) == 'X'",
+			expected: ExpressionDiff{
+				AddedParameters:  []string{"c", "d", "e"},
+				AddedFunctions:   []string{"score", "upper"},
+				RemovedFunctions: []string{"lookup"},
+			},
+		},
+		{
+			before: "user.Age > 1 && user.Name != ''",
+			after:  "account.Age > 1 && user.Name != ''",
+			expected: ExpressionDiff{
+				AddedParameters: []string{"account"},
+			},
+		},
+		{
+			before:   "user.Age > 1",
+			after:    "user.Age > 1 || user == 'admin'",
+			expected: ExpressionDiff{},
+		},
+		{
+			before: "z + y + lookup(x) + lookup(x)",
+			after:  "w",
+			expected: ExpressionDiff{
+				AddedParameters:   []string{"w"},
+				RemovedParameters: []string{"x", "y", "z"},
+				RemovedFunctions:  []string{"lookup"},
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+
+		before, err := NewEvaluableExpressionWithFunctions(testCase.before, functions)
+		if err != nil {
+			test.Fatalf("Unexpected parsing error for '%s': %v", testCase.before, err)
+		}
+
+		after, err := NewEvaluableExpressionWithFunctions(testCase.after, functions)
+		if err != nil {
+			test.Fatalf("Unexpected parsing error for '%s': %v", testCase.after, err)
+		}
+
+		actual := before.Diff(after)
+
+		if !reflect.DeepEqual(actual, testCase.expected) {
+			test.Logf("Diff of '%s' and '%s': expected %+v, got %+v", testCase.before, testCase.after, testCase.expected, actual)
+			test.Fail()
+		}
+
+		if actual.IsEmpty() != testCase.expected.IsEmpty() {
+			test.Logf("Diff of '%s' and '%s': expected IsEmpty() to be %v", testCase.before, testCase.after, testCase.expected.IsEmpty())
+			test.Fail()
+		}
+	}
+}
@@ -83,3 +83,45 @@ var fooFailureParameters = map[string]interface{}{
 	"foo":    fooParameter.Value,
 	"fooptr": &fooPtrParameter.Value,
 }
+
+/*
+	Struct shaped like a generated protobuf message, whose fields are only reachable through getters.
+*/
+type dummyMessage struct {
+	userId  string
+	profile *dummyMessageProfile
+}
+
+func (this *dummyMessage) GetUserId() string {
+	if this == nil {
+		return ""
+	}
+	return this.userId
+}
+
+func (this *dummyMessage) GetProfile() *dummyMessageProfile {
+	if this == nil {
+		return nil
+	}
+	return this.profile
+}
+
+type dummyMessageProfile struct {
+	displayName string
+	Age         float64
+}
+
+func (this *dummyMessageProfile) GetDisplayName() string {
+	if this == nil {
+		return ""
+	}
+	return this.displayName
+}
+
+var dummyMessageInstance = &dummyMessage{
+	userId: "u-1",
+	profile: &dummyMessageProfile{
+		displayName: "Ada",
+		Age:         36,
+	},
+}
@@ -0,0 +1,111 @@
+package govaluate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+	Parses a duration literal like "5m", "2h30m", or "7d".
+	This is the same format as `time.ParseDuration` (without a sign), except that "d" can also be used, for days of 24 hours.
+*/
+func parseDuration(candidate string) (time.Duration, error) {
+
+	var ret time.Duration
+	remaining := candidate
+
+	for remaining != "" {
+
+		// each part is a number, then a unit.
+		unitStart := strings.IndexFunc(remaining, isNotNumeric)
+		if unitStart <= 0 {
+			return 0, fmt.Errorf("Unable to parse duration '%s'", candidate)
+		}
+
+		unitEnd := strings.IndexFunc(remaining[unitStart:], isNumeric)
+		if unitEnd < 0 {
+			unitEnd = len(remaining)
+		} else {
+			unitEnd += unitStart
+		}
+
+		number := remaining[:unitStart]
+		unit := remaining[unitStart:unitEnd]
+
+		if unit == "d" {
+
+			days, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0, fmt.Errorf("Unable to parse duration '%s'", candidate)
+			}
+
+			number = strconv.FormatFloat(days*24, 'f', -1, 64)
+			unit = "h"
+		}
+
+		part, err := time.ParseDuration(number + unit)
+		if err != nil {
+			return 0, fmt.Errorf("Unable to parse duration '%s'", candidate)
+		}
+
+		ret += part
+		remaining = remaining[unitEnd:]
+	}
+
+	return ret, nil
+}
+
+func isNotNumeric(character rune) bool {
+	return !isNumeric(character)
+}
+
+func isDuration(value interface{}) bool {
+	switch value.(type) {
+	case time.Duration:
+		return true
+	}
+	return false
+}
+
+/*
+	Returns the result of adding [left] and [right] (or, if [subtract] is true, subtracting [right] from [left]),
+	where at least one of them is a time.Time or time.Duration.
+	Durations can be added to or subtracted from each other, or a time; and subtracting two times gives the duration between them.
+	Returns false for any other combination, such as a time and a number.
+*/
+func addTimes(left interface{}, right interface{}, subtract bool) (interface{}, bool) {
+
+	switch {
+	case isDuration(left) && isDuration(right):
+
+		if subtract {
+			return left.(time.Duration) - right.(time.Duration), true
+		}
+		return left.(time.Duration) + right.(time.Duration), true
+
+	case isTime(left) && isDuration(right):
+
+		if subtract {
+			return left.(time.Time).Add(-right.(time.Duration)), true
+		}
+		return left.(time.Time).Add(right.(time.Duration)), true
+
+	case isDuration(left) && isTime(right) && !subtract:
+		return right.(time.Time).Add(left.(time.Duration)), true
+
+	case isTime(left) && isTime(right) && subtract:
+		return left.(time.Time).Sub(right.(time.Time)), true
+	}
+
+	return nil, false
+}
+
+/*
+	Subtraction is usually between numbers, but can also be between times and durations (see `addTimes`).
+	Which combinations of those are valid depends on both sides, and is checked by the operator itself.
+*/
+func isSubtractable(value interface{}) bool {
+	return isFloat64(value) || isDuration(value) || isTime(value)
+}
@@ -0,0 +1,156 @@
+package govaluate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDurations(test *testing.T) {
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	times := []EvaluationParameter{
+		EvaluationParameter{
+			Name:  "start",
+			Value: start,
+		},
+		EvaluationParameter{
+			Name:  "end",
+			Value: end,
+		},
+		EvaluationParameter{
+			Name:  "timeout",
+			Value: 30 * time.Second,
+		},
+	}
+
+	evaluationTests := []EvaluationTest{
+
+		EvaluationTest{
+
+			Name:     "Single unit duration",
+			Input:    "5m",
+			Expected: 5 * time.Minute,
+		},
+		EvaluationTest{
+
+			Name:     "Multiple unit duration",
+			Input:    "2h30m",
+			Expected: 150 * time.Minute,
+		},
+		EvaluationTest{
+
+			Name:     "Days",
+			Input:    "7d",
+			Expected: 7 * 24 * time.Hour,
+		},
+		EvaluationTest{
+
+			Name:     "Fractional days",
+			Input:    "1.5d",
+			Expected: 36 * time.Hour,
+		},
+		EvaluationTest{
+
+			Name:     "Small units",
+			Input:    "100ms",
+			Expected: 100 * time.Millisecond,
+		},
+		EvaluationTest{
+
+			Name:     "Duration addition",
+			Input:    "5m + 30s",
+			Expected: 330 * time.Second,
+		},
+		EvaluationTest{
+
+			Name:       "Time plus duration",
+			Input:      "start + 7d",
+			Parameters: times,
+			Expected:   start.Add(7 * 24 * time.Hour),
+		},
+		EvaluationTest{
+
+			Name:       "Duration plus time",
+			Input:      "1h + start",
+			Parameters: times,
+			Expected:   start.Add(time.Hour),
+		},
+		EvaluationTest{
+
+			Name:       "Time minus duration",
+			Input:      "start - 1h",
+			Parameters: times,
+			Expected:   start.Add(-time.Hour),
+		},
+		EvaluationTest{
+
+			Name:       "Time minus time",
+			Input:      "end - start",
+			Parameters: times,
+			Expected:   9 * 24 * time.Hour,
+		},
+		EvaluationTest{
+
+			Name:       "Duration comparison",
+			Input:      "end - start > 7d",
+			Parameters: times,
+			Expected:   true,
+		},
+		EvaluationTest{
+
+			Name:       "Duration parameter comparison",
+			Input:      "timeout < 1m",
+			Parameters: times,
+			Expected:   true,
+		},
+		EvaluationTest{
+
+			Name:       "Time comparison",
+			Input:      "start + 10d >= end",
+			Parameters: times,
+			Expected:   true,
+		},
+		EvaluationTest{
+
+			Name:     "now",
+			Input:    "now() + 5m > now()",
+			Expected: true,
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+
+	failureTests := []EvaluationFailureTest{
+		EvaluationFailureTest{
+
+			Name:     "Duration plus number",
+			Input:    "5m + 1",
+			Expected: "cannot be used with the modifier '+'",
+		},
+		EvaluationFailureTest{
+
+			Name:       "Time plus time",
+			Input:      "start + start",
+			Parameters: map[string]interface{}{"start": start},
+			Expected:   "cannot be used with the modifier '+'",
+		},
+		EvaluationFailureTest{
+
+			Name:       "Duration minus time",
+			Input:      "5m - start",
+			Parameters: map[string]interface{}{"start": start},
+			Expected:   "cannot be subtracted from '5m0s'",
+		},
+	}
+
+	runEvaluationFailureTests(failureTests, test)
+
+	// letters which aren't a unit are read as a separate token, as they always have been.
+	_, err := NewEvaluableExpression("5mm")
+	if err == nil {
+		test.Logf("Expected '5mm' to fail parsing")
+		test.Fail()
+	}
+}
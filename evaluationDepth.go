@@ -0,0 +1,45 @@
+package govaluate
+
+import (
+	"context"
+	"errors"
+)
+
+/*
+	Returned when an expression is evaluated while more than its `MaxRecursionDepth` evaluations are already running,
+	such as when a function evaluates the expression which called it.
+*/
+var ErrRecursionTooDeep = errors.New("Evaluation exceeded the maximum recursion depth")
+
+// the key of the context value which holds how many evaluations are running, for `MaxRecursionDepth`.
+type evaluationDepthKey struct{}
+
+/*
+	Returns a context which records that one more evaluation is running than is recorded by [ctx].
+	Evaluations give this to their ContextFunctions, so that evaluations those start with it (see `EvaluateWithContext`) are counted as nested.
+*/
+func withNestedEvaluation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, evaluationDepthKey{}, contextDepth(ctx)+1)
+}
+
+/*
+	Returns the number of evaluations recorded as running by [ctx], which is zero if it didn't come from `EvaluateWithContext`.
+*/
+func contextDepth(ctx context.Context) int {
+
+	depth, _ := ctx.Value(evaluationDepthKey{}).(int)
+	return depth
+}
+
+/*
+	Returns the number of evaluations (including the current one) which are running, given the [parameters] of the current one.
+	Only evaluations which were nested through `EvaluateWithContext` can be known about; any other evaluation counts as the first.
+*/
+func evaluationDepth(parameters Parameters) int {
+
+	depth := contextDepth(findContext(parameters))
+	if depth < 1 {
+		return 1
+	}
+	return depth
+}
@@ -0,0 +1,34 @@
+package govaluate
+
+/*
+	EvaluationError is returned when an expression which was parsed from a string fails to evaluate.
+	It gives the position of the part of the expression which failed, such as an operator and its operands,
+	or a function call and its arguments, so that (for instance) an editor can highlight it.
+*/
+type EvaluationError struct {
+
+	/*
+		The byte offsets in the expression string of the first character of the part which failed, and just past its last character.
+		So `expression[Start:End]` is the failing part itself.
+	*/
+	Start, End int
+
+	/*
+		The error which caused the evaluation to fail.
+	*/
+	Err error
+}
+
+/*
+	Returns the message of the underlying error, unchanged.
+*/
+func (this *EvaluationError) Error() string {
+	return this.Err.Error()
+}
+
+/*
+	Returns the underlying error, so that it can be checked with `errors.Is` or `errors.As`.
+*/
+func (this *EvaluationError) Unwrap() error {
+	return this.Err
+}
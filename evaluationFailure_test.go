@@ -4,8 +4,12 @@ package govaluate
   Tests to make sure evaluation fails in the expected ways.
 */
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -36,6 +40,8 @@ const (
 	TOO_FEW_ARGS                    = "Too few arguments to parameter call"
 	TOO_MANY_ARGS                   = "Too many arguments to parameter call"
 	MISMATCHED_PARAMETERS           = "Argument type conversion failed"
+	MIXED_BIG_INT                   = "both sides must be *big.Int"
+	UNEXPORTED_ACCESSOR             = "Unable to access unexported"
 )
 
 // preset parameter map of types that can be used in an evaluation failure test to check typing.
@@ -190,6 +196,68 @@ func TestModifierTyping(test *testing.T) {
 	runEvaluationFailureTests(evaluationTests, test)
 }
 
+func TestBigIntTyping(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"big":    big.NewInt(10),
+		"number": 1,
+	}
+
+	evaluationTests := []EvaluationFailureTest{
+		EvaluationFailureTest{
+
+			Name:       "BITWISE_AND big.Int with number",
+			Input:      "big & number",
+			Parameters: parameters,
+			Expected:   MIXED_BIG_INT,
+		},
+		EvaluationFailureTest{
+
+			Name:       "BITWISE_OR number with big.Int",
+			Input:      "number | big",
+			Parameters: parameters,
+			Expected:   MIXED_BIG_INT,
+		},
+		EvaluationFailureTest{
+
+			Name:       "BITWISE_LSHIFT number by big.Int",
+			Input:      "number << big",
+			Parameters: parameters,
+			Expected:   "the shifted value must also be a *big.Int",
+		},
+		EvaluationFailureTest{
+
+			Name:       "BITWISE_RSHIFT big.Int by negative count",
+			Input:      "big >> -1",
+			Parameters: parameters,
+			Expected:   "negative amount",
+		},
+		EvaluationFailureTest{
+
+			Name:       "BITWISE_LSHIFT big.Int by fractional count",
+			Input:      "big << 1.7",
+			Parameters: parameters,
+			Expected:   "it is not a whole number of bits",
+		},
+		EvaluationFailureTest{
+
+			Name:       "BITWISE_LSHIFT big.Int by huge count",
+			Input:      "big << 1000000000000",
+			Parameters: parameters,
+			Expected:   "would make a number of more than 1048576 bits",
+		},
+		EvaluationFailureTest{
+
+			Name:       "BITWISE_LSHIFT big.Int by huge big.Int count",
+			Input:      "big << (big << 100)",
+			Parameters: parameters,
+			Expected:   "would make a number of more than 1048576 bits",
+		},
+	}
+
+	runEvaluationFailureTests(evaluationTests, test)
+}
+
 func TestLogicalOperatorTyping(test *testing.T) {
 
 	evaluationTests := []EvaluationFailureTest{
@@ -419,6 +487,51 @@ func TestRegexParameterCompilation(test *testing.T) {
 	runEvaluationFailureTests(evaluationTests, test)
 }
 
+func TestRegexErrorHandler(test *testing.T) {
+
+	var warnings []string
+
+	expression, _ := NewEvaluableExpression("name =~ pattern || name !~ pattern")
+	expression.RegexErrorHandler = func(pattern string, err error) (bool, error) {
+		warnings = append(warnings, pattern)
+		return false, nil
+	}
+
+	result, err := expression.Evaluate(map[string]interface{}{"name": "foo", "pattern": "[foo"})
+	if err != nil || result != false {
+		test.Logf("Expected a malformed pattern to fail both comparisons, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	if len(warnings) != 2 || warnings[0] != "[foo" {
+		test.Logf("Expected the malformed pattern to be given to the handler twice, got %v", warnings)
+		test.Fail()
+	}
+
+	result, err = expression.Evaluate(map[string]interface{}{"name": "foo", "pattern": "^f"})
+	if err != nil || result != true {
+		test.Logf("Expected a valid pattern to match as usual, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	// errors other than compiling the pattern aren't given to the handler.
+	_, err = expression.Evaluate(map[string]interface{}{"name": 1, "pattern": "[foo"})
+	if err == nil || len(warnings) != 2 {
+		test.Logf("Expected a type error which the handler didn't see, got %v, %v", err, warnings)
+		test.Fail()
+	}
+
+	expression.RegexErrorHandler = func(pattern string, err error) (bool, error) {
+		return false, errors.New("Bad pattern: " + pattern)
+	}
+
+	_, err = expression.Evaluate(map[string]interface{}{"name": "foo", "pattern": "[foo"})
+	if err == nil || !strings.Contains(err.Error(), "Bad pattern: [foo") {
+		test.Logf("Expected the handler's error, got %v", err)
+		test.Fail()
+	}
+}
+
 func TestFunctionExecution(test *testing.T) {
 
 	evaluationTests := []EvaluationFailureTest{
@@ -462,6 +575,37 @@ func TestInvalidParameterCalls(test *testing.T) {
 			Parameters: fooFailureParameters,
 			Expected:   INVALID_PARAMETER_CALL,
 		},
+		EvaluationFailureTest{
+
+			Name:  "Unexported parameter access",
+			Input: "foo.x",
+			Parameters: map[string]interface{}{
+				"foo": DebugStruct{},
+			},
+			Expected: UNEXPORTED_ACCESSOR,
+		},
+		EvaluationFailureTest{
+
+			Name:  "Missing nested map key",
+			Input: "user.profile.age",
+			Parameters: map[string]interface{}{
+				"user": map[string]interface{}{
+					"name": "bob",
+				},
+			},
+			Expected: "No key 'profile' present in 'user'",
+		},
+		EvaluationFailureTest{
+
+			Name:  "Missing deeply nested map key",
+			Input: "user.profile.age",
+			Parameters: map[string]interface{}{
+				"user": map[string]interface{}{
+					"profile": map[string]int{},
+				},
+			},
+			Expected: "No key 'age' present in 'user.profile'",
+		},
 		EvaluationFailureTest{
 
 			Name:       "Parameter method call returns error",
@@ -541,3 +685,497 @@ func runEvaluationFailureTests(evaluationTests []EvaluationFailureTest, test *te
 		}
 	}
 }
+
+func TestExponentOverflow(test *testing.T) {
+
+	inputs := []string{
+		"10 ** 400",
+		"foo ** 400 > 1",
+		"-(foo ** 309)",
+	}
+
+	for _, input := range inputs {
+
+		expression, err := NewEvaluableExpression(input)
+		if err != nil {
+			test.Logf("Unable to parse '%s': %v", input, err)
+			test.Fail()
+			continue
+		}
+
+		// by default, infinity is an acceptable result.
+		_, err = expression.Evaluate(map[string]interface{}{"foo": 10})
+		if err != nil {
+			test.Logf("Expected '%s' to evaluate without overflow checks, got %v", input, err)
+			test.Fail()
+		}
+
+		expression.ChecksExponentOverflow = true
+
+		_, err = expression.Evaluate(map[string]interface{}{"foo": 10})
+		if err == nil || !strings.Contains(err.Error(), "overflows to infinity") {
+			test.Logf("Expected '%s' to fail with an overflow error, got %v", input, err)
+			test.Fail()
+		}
+	}
+
+	// infinite inputs aren't overflows.
+	expression, _ := NewEvaluableExpression("foo ** 2")
+	expression.ChecksExponentOverflow = true
+
+	result, err := expression.Evaluate(map[string]interface{}{"foo": math.Inf(1)})
+	if err != nil || !math.IsInf(result.(float64), 1) {
+		test.Logf("Expected an infinite input to give an infinite result, got %v, %v", result, err)
+		test.Fail()
+	}
+}
+
+func TestShiftOverflow(test *testing.T) {
+
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{"1 << 64", "Left shift '1 << 64' overflows"},
+		{"foo << 60", "Left shift '16 << 60' overflows"},
+		{"flags | (1 << bit)", "Left shift '1 << 70' overflows"},
+		{"(0 - foo) << 1", "Unable to shift '-16' left, it is negative"},
+		{"1 << (0 - foo)", "Unable to shift by a negative amount '-16'"},
+	}
+
+	parameters := map[string]interface{}{"foo": 16, "flags": 1, "bit": 70}
+
+	for _, testCase := range cases {
+
+		expression, _ := NewEvaluableExpression(testCase.input)
+
+		// by default, bits are silently lost.
+		_, err := expression.Evaluate(parameters)
+		if err != nil {
+			test.Logf("Expected '%s' to evaluate without shift checks, got %v", testCase.input, err)
+			test.Fail()
+		}
+
+		expression.ChecksShiftOverflow = true
+
+		_, err = expression.Evaluate(parameters)
+		if err == nil || !strings.Contains(err.Error(), testCase.expected) {
+			test.Logf("Expected '%s' to fail with '%s', got %v", testCase.input, testCase.expected, err)
+			test.Fail()
+		}
+	}
+
+	// shifts which keep every bit are fine, right up to the 64th.
+	expression, _ := NewEvaluableExpression("(1 << 63) + (foo << 59) + (0 << 100)")
+	expression.ChecksShiftOverflow = true
+
+	result, err := expression.Evaluate(map[string]interface{}{"foo": 15})
+	if err != nil || result != float64(uint64(1)<<63+uint64(15)<<59) {
+		test.Logf("Expected shifts within 64 bits to succeed, got %v, %v", result, err)
+		test.Fail()
+	}
+}
+
+type errorPositionTest struct {
+	input    string
+	expected string
+}
+
+func TestEvaluationErrorPositions(test *testing.T) {
+
+	positionTests := []errorPositionTest{
+		errorPositionTest{input: "(foo * 2) > 1", expected: "foo * 2"},
+		errorPositionTest{input: "bar && (!bar || foo)", expected: "!bar || foo"},
+		errorPositionTest{input: "1 - 2 - foo", expected: "1 - 2 - foo"},
+		errorPositionTest{input: "1 + missing", expected: "missing"},
+		errorPositionTest{input: "'é' == 'é' && missing", expected: "missing"},
+		errorPositionTest{input: "ifnull(foo, 1) .. ord ('ab')", expected: "ord ('ab')"},
+		errorPositionTest{input: "bar ? 2 * -foo : 0", expected: "-foo"},
+		errorPositionTest{input: "(1 + 2 > foo) == bar", expected: "1 + 2 > foo"},
+	}
+
+	parameters := map[string]interface{}{
+		"foo": "x",
+		"bar": true,
+	}
+
+	for _, positionTest := range positionTests {
+
+		expression, err := NewEvaluableExpression(positionTest.input)
+		if err != nil {
+			test.Logf("Unable to parse '%s': %v", positionTest.input, err)
+			test.Fail()
+			continue
+		}
+
+		_, err = expression.Evaluate(parameters)
+
+		evaluationError, ok := err.(*EvaluationError)
+		if !ok {
+			test.Logf("Expected '%s' to fail with an EvaluationError, got %v", positionTest.input, err)
+			test.Fail()
+			continue
+		}
+
+		actual := positionTest.input[evaluationError.Start:evaluationError.End]
+		if actual != positionTest.expected {
+			test.Logf("Expected '%s' to fail at '%s', got '%s' (%v)", positionTest.input, positionTest.expected, actual, err)
+			test.Fail()
+		}
+	}
+
+	// errors from user functions can still be found.
+	failure := errors.New("failure")
+	functions := map[string]ExpressionFunction{
+		"failing": func(arguments ...interface{}) (interface{}, error) {
+			return nil, failure
+		},
+	}
+
+	expression, _ := NewEvaluableExpressionWithFunctions("1 + failing()", functions)
+
+	_, err := expression.Evaluate(nil)
+	if !errors.Is(err, failure) {
+		test.Logf("Expected the function's error to be unwrapped, got %v", err)
+		test.Fail()
+	}
+}
+
+func TestMaxRecursionDepth(test *testing.T) {
+
+	var expression *EvaluableExpression
+
+	// counts down from n, evaluating the expression again (with the context it was given) for every step.
+	options := ParsingOptions{
+		ContextFunctions: map[string]ContextFunction{
+			"countdown": func(ctx context.Context, arguments ...interface{}) (interface{}, error) {
+
+				n := arguments[0].(float64)
+				if n <= 0 {
+					return 0.0, nil
+				}
+				return expression.EvaluateWithContext(ctx, map[string]interface{}{"n": n - 1})
+			},
+		},
+	}
+
+	expression, _ = NewEvaluableExpressionWithOptions("countdown(n)", nil, options)
+	expression.MaxRecursionDepth = 10
+
+	_, err := expression.EvaluateWithContext(context.Background(), map[string]interface{}{"n": 9.0})
+	if err != nil {
+		test.Logf("Expected 10 nested evaluations to be allowed, got %v", err)
+		test.Fail()
+	}
+
+	_, err = expression.EvaluateWithContext(context.Background(), map[string]interface{}{"n": 10.0})
+	if !errors.Is(err, ErrRecursionTooDeep) {
+		test.Logf("Expected 11 nested evaluations to fail with ErrRecursionTooDeep, got %v", err)
+		test.Fail()
+	}
+
+	// evaluations that aren't nested don't count towards the limit.
+	for i := 0; i < 20; i++ {
+
+		_, err = expression.EvaluateWithContext(context.Background(), map[string]interface{}{"n": 0.0})
+		if err != nil {
+			test.Logf("Expected sequential evaluations to be allowed, got %v", err)
+			test.Fail()
+			return
+		}
+	}
+
+	// an evaluation without a context still gives its depth to the functions it calls.
+	_, err = expression.Evaluate(map[string]interface{}{"n": 10.0})
+	if !errors.Is(err, ErrRecursionTooDeep) {
+		test.Logf("Expected 11 nested evaluations from Evaluate to fail with ErrRecursionTooDeep, got %v", err)
+		test.Fail()
+	}
+}
+
+func TestMaxStringBytes(test *testing.T) {
+
+	// every string built along the way counts: two of 2 bytes, two of 4, then 8, and finally 16.
+	expression, _ := NewEvaluableExpressionWithFunctions("double(double(s + s) .. double(s + s))", map[string]ExpressionFunction{
+		"double": func(arguments ...interface{}) (interface{}, error) {
+			return arguments[0].(string) + arguments[0].(string), nil
+		},
+	})
+	parameters := map[string]interface{}{"s": "x"}
+
+	expression.MaxStringBytes = 2 + 2 + 4 + 4 + 8 + 16
+	result, err := expression.Evaluate(parameters)
+	if err != nil || len(result.(string)) != 16 {
+		test.Logf("Expected evaluation within the string budget to succeed, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	expression.MaxStringBytes--
+	_, err = expression.Evaluate(parameters)
+	if !errors.Is(err, ErrStringBudgetExceeded) {
+		test.Logf("Expected evaluation over the string budget to fail with ErrStringBudgetExceeded, got %v", err)
+		test.Fail()
+	}
+
+	// strings which are only passed through, rather than built, don't count.
+	expression, _ = NewEvaluableExpression("s == 'xxxx' ? s : 'short'")
+	expression.MaxStringBytes = 1
+
+	_, err = expression.Evaluate(map[string]interface{}{"s": "xxxx"})
+	if err != nil {
+		test.Logf("Expected strings which weren't built to be allowed, got %v", err)
+		test.Fail()
+	}
+
+	// strings returned by method calls are built, so they count.
+	expression, _ = NewEvaluableExpression("foo.Func()")
+	expression.MaxStringBytes = 3
+
+	_, err = expression.Evaluate(map[string]interface{}{"foo": dummyParameter{}})
+	if !errors.Is(err, ErrStringBudgetExceeded) {
+		test.Logf("Expected a string from a method call to count towards the budget, got %v", err)
+		test.Fail()
+	}
+}
+
+func TestMaxStringBytesChecksBeforeBuilding(test *testing.T) {
+
+	// replacing the empty string inserts [s] before every character, which would be about 9MB.
+	expression, _ := NewEvaluableExpression("replace(s, '', s)")
+	expression.MaxStringBytes = 1 << 20
+
+	parameters := map[string]interface{}{"s": strings.Repeat("x", 3000)}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	_, err := expression.Evaluate(parameters)
+
+	runtime.ReadMemStats(&after)
+
+	if !errors.Is(err, ErrStringBudgetExceeded) {
+		test.Logf("Expected a large replace to fail with ErrStringBudgetExceeded, got %v", err)
+		test.Fail()
+	}
+	if after.TotalAlloc-before.TotalAlloc > 1<<20 {
+		test.Logf("Expected the large replace to be refused before it was built, but %d bytes were allocated", after.TotalAlloc-before.TotalAlloc)
+		test.Fail()
+	}
+
+	// the size is worked out exactly, so a replace which fits is allowed; and a count limits it.
+	expression, _ = NewEvaluableExpression("replaceN(s, 'x', 'yy', 1000)")
+	expression.MaxStringBytes = 4000
+
+	result, err := expression.Evaluate(parameters)
+	if err != nil || len(result.(string)) != 4000 {
+		test.Logf("Expected a replace within the budget to succeed, got %v", err)
+		test.Fail()
+	}
+
+	expression.MaxStringBytes = 3999
+
+	_, err = expression.Evaluate(parameters)
+	if !errors.Is(err, ErrStringBudgetExceeded) {
+		test.Logf("Expected a replace just over the budget to fail with ErrStringBudgetExceeded, got %v", err)
+		test.Fail()
+	}
+}
+
+func TestMaxCollectionSize(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("typeof(split(s, ''))")
+	parameters := map[string]interface{}{"s": "abcd"}
+
+	expression.MaxCollectionSize = 4
+	result, err := expression.Evaluate(parameters)
+	if err != nil || result != "array" {
+		test.Logf("Expected a list within the limit to be allowed, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	expression.MaxCollectionSize = 3
+	_, err = expression.Evaluate(parameters)
+	if !errors.Is(err, ErrCollectionTooLarge) {
+		test.Logf("Expected a list over the limit to fail with ErrCollectionTooLarge, got %v", err)
+		test.Fail()
+	}
+
+	// the limit can't be caught by "try".
+	expression, _ = NewEvaluableExpression("try(typeof(map(items, 'it * 2')), 'none')")
+	expression.MaxCollectionSize = 2
+
+	_, err = expression.Evaluate(map[string]interface{}{"items": []interface{}{1.0, 2.0, 3.0}})
+	if !errors.Is(err, ErrCollectionTooLarge) {
+		test.Logf("Expected try not to catch ErrCollectionTooLarge, got %v", err)
+		test.Fail()
+	}
+}
+
+func TestTypeHints(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"count":   "5",
+		"name":    "bob",
+		"active":  true,
+		"missing": nil,
+		"age":     30,
+	}
+
+	evaluationTests := []EvaluationFailureTest{
+		EvaluationFailureTest{
+			Name:       "Quoted number literal",
+			Input:      "'5' - 3",
+			Parameters: parameters,
+			Expected:   "'5' is a string, so remove the quotes to use it as the number 5",
+		},
+		EvaluationFailureTest{
+			Name:       "Numeric string parameter",
+			Input:      "count * 2",
+			Parameters: parameters,
+			Expected:   "the parameter 'count' is a string, so it needs to be given as a number instead",
+		},
+		EvaluationFailureTest{
+			Name:       "Numeric string compared with a number",
+			Input:      "age > count",
+			Parameters: parameters,
+			Expected:   "the parameter 'count' is a string, so it needs to be given as a number instead",
+		},
+		EvaluationFailureTest{
+			Name:       "Bool in arithmetic",
+			Input:      "age + active",
+			Parameters: parameters,
+			Expected:   "the parameter 'active' is a bool, so use a ternary to turn it into a number, like '(active ? 1 : 0)'",
+		},
+		EvaluationFailureTest{
+			Name:       "Null in arithmetic",
+			Input:      "missing - 1",
+			Parameters: parameters,
+			Expected:   "the parameter 'missing' is null, so use '??' to give it a default, like 'missing ?? 0'",
+		},
+		EvaluationFailureTest{
+			Name:       "Number as a condition",
+			Input:      "age && active",
+			Parameters: parameters,
+			Expected:   "the parameter 'age' is a number, so compare it to get a bool, like 'age != 0'",
+		},
+		EvaluationFailureTest{
+			Name:       "String as a condition",
+			Input:      "name ? 1 : 2",
+			Parameters: parameters,
+			Expected:   "the parameter 'name' is a string, so compare it to get a bool, like \"name == 'yes'\"",
+		},
+		EvaluationFailureTest{
+			Name:       "Regex of a number",
+			Input:      "age =~ '3.*'",
+			Parameters: parameters,
+			Expected:   "the parameter 'age' is a number, but '=~' needs a string on its left and a pattern on its right",
+		},
+		EvaluationFailureTest{
+			Name:       "Non-numeric string",
+			Input:      "name - 1",
+			Parameters: parameters,
+			Expected:   "cannot be used with the modifier '-', it is not a number",
+		},
+	}
+
+	runEvaluationFailureTests(evaluationTests, test)
+}
+
+func TestNilComparisonsAreFalse(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"missing": nil,
+		"nilPtr":  (*dummyParameter)(nil),
+		"age":     30,
+		"name":    "bob",
+	}
+
+	nilTests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"missing > 5", false},
+		{"5 <= missing", false},
+		{"missing < 5 || age > 18", true},
+		{"nilPtr >= 1", false},
+		{"missing =~ 'b.*'", false},
+		{"missing !~ 'b.*'", false},
+		{"name =~ 'b.*'", true},
+		{"missing == missing", true},
+		{"missing != 5", true},
+	}
+
+	for _, nilTest := range nilTests {
+
+		expression, _ := NewEvaluableExpression(nilTest.input)
+		expression.NilComparisonsAreFalse = true
+
+		result, err := expression.Evaluate(parameters)
+		if err != nil || result != nilTest.expected {
+			test.Logf("Expected '%s' to evaluate to %v, got %v, %v", nilTest.input, nilTest.expected, result, err)
+			test.Fail()
+		}
+	}
+
+	// without the option, comparing nil is still a type error.
+	expression, _ := NewEvaluableExpression("missing > 5")
+
+	_, err := expression.Evaluate(parameters)
+	if err == nil {
+		test.Logf("Expected comparing nil to be an error by default")
+		test.Fail()
+	}
+}
+
+func TestByteSlices(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"payload": []byte("hello world"),
+		"same":    []byte("hello world"),
+		"other":   []byte("goodbye"),
+		"empty":   []byte{},
+		"none":    []byte(nil),
+	}
+
+	byteTests := []struct {
+		input          string
+		bytesAsStrings bool
+		expected       interface{}
+	}{
+		{"payload == same", false, true},
+		{"payload != other", false, true},
+		{"empty == none", false, true},
+		{"payload > other", false, true},
+		{"other <= payload", false, true},
+		{"contains(payload, 'lo wo')", false, true},
+		{"contains(payload, same)", false, true},
+		{"contains(other, 'hello')", false, false},
+		{"payload == 'hello world'", true, true},
+		{"'goodbye' < payload", true, true},
+		{"payload =~ '^hello'", true, true},
+	}
+
+	for _, byteTest := range byteTests {
+
+		expression, _ := NewEvaluableExpression(byteTest.input)
+		expression.BytesAsStrings = byteTest.bytesAsStrings
+
+		result, err := expression.Evaluate(parameters)
+		if err != nil || result != byteTest.expected {
+			test.Logf("Expected '%s' to evaluate to %v, got %v, %v", byteTest.input, byteTest.expected, result, err)
+			test.Fail()
+		}
+	}
+
+	// without the option, comparing a []byte with a string is an error, rather than never being equal.
+	for _, input := range []string{"payload == 'hello world'", "payload > 'a'"} {
+
+		expression, _ := NewEvaluableExpression(input)
+
+		_, err := expression.Evaluate(parameters)
+		if err == nil || !strings.Contains(err.Error(), "[]byte") {
+			test.Logf("Expected comparing a []byte with a string in '%s' to be an error, got %v", input, err)
+			test.Fail()
+		}
+	}
+}
@@ -1,12 +1,17 @@
 package govaluate
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math"
+	"math/big"
+	"math/bits"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -24,6 +29,9 @@ type stageCombinedTypeCheck func(left interface{}, right interface{}) bool
 type evaluationStage struct {
 	symbol OperatorSymbol
 
+	// for parameters, accessors and functions, the name that was used to refer to them.
+	name string
+
 	leftStage, rightStage *evaluationStage
 
 	// the operation that will be used to evaluate this stage (such as adding [left] to [right] and return the result)
@@ -40,6 +48,31 @@ type evaluationStage struct {
 
 	// regardless of which type check is used, this string format will be used as the error message for type errors
 	typeErrorFormat string
+
+	// for literals which were calculated at parse time, the stages they were calculated from.
+	// Some evaluation options (like rounding each division) change what those stages would return, so they need to be evaluated instead.
+	elided *evaluationStage
+
+	// for calls to the built-in "try", whether an error from evaluating the first argument is replaced by the second argument.
+	// Both arguments are then evaluated only when needed, rather than before the call.
+	catchesErrors bool
+
+	// for calls to functions which are marked as pure, whether their results are cached. See `FunctionCache`.
+	pure bool
+
+	// for calls to built-ins which call other functions (like "map"), whether the operator is given the evaluation
+	// the call is part of, to evaluate any sub-expressions with. See `outerEvaluation`.
+	evaluatesSubExpressions bool
+
+	// for calls to built-ins which can build large strings, gives the length of the string they'll return for the evaluated arguments.
+	// See `MaxStringBytes`.
+	stringSize func(right interface{}) int
+
+	// the byte offsets in the expression string of the token this stage was planned from,
+	// and of the whole subexpression this stage evaluates (including its children).
+	// All of these are zero if the expression wasn't parsed from a string.
+	tokenStart, tokenEnd int
+	start, end           int
 }
 
 var (
@@ -57,11 +90,37 @@ func (this *evaluationStage) swapWith(other *evaluationStage) {
 func (this *evaluationStage) setToNonStage(other evaluationStage) {
 
 	this.symbol = other.symbol
+	this.name = other.name
 	this.operator = other.operator
 	this.leftTypeCheck = other.leftTypeCheck
 	this.rightTypeCheck = other.rightTypeCheck
 	this.typeCheck = other.typeCheck
 	this.typeErrorFormat = other.typeErrorFormat
+	this.elided = other.elided
+	this.tokenStart = other.tokenStart
+	this.tokenEnd = other.tokenEnd
+}
+
+/*
+	Returns [err] as an EvaluationError holding the position of this stage, if that position is known.
+*/
+func (this *evaluationStage) locateError(err error) error {
+
+	if err == nil || this.end == 0 {
+		return err
+	}
+
+	// errors which were already located came from a more specific stage.
+	_, located := err.(*EvaluationError)
+	if located {
+		return err
+	}
+
+	return &EvaluationError{
+		Start: this.start,
+		End:   this.end,
+		Err:   err,
+	}
 }
 
 func (this *evaluationStage) isShortCircuitable() bool {
@@ -90,13 +149,62 @@ func addStage(left interface{}, right interface{}, parameters Parameters) (inter
 
 	// string concat if either are strings
 	if isString(left) || isString(right) {
-		return fmt.Sprintf("%v%v", left, right), nil
+		return concatenate(left, right), nil
+	}
+
+	result, ok := addTimes(left, right, false)
+	if ok {
+		return result, nil
+	}
+
+	return left.(float64) + right.(float64), nil
+}
+
+func numericAddStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	result, ok := addTimes(left, right, false)
+	if ok {
+		return result, nil
 	}
 
 	return left.(float64) + right.(float64), nil
 }
+
+func concatStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return concatenate(left, right), nil
+}
+
+/*
+	Joins the string forms of [left] and [right].
+	Numbers are always written out in full, never in scientific notation - so 1000000 is "1000000", not "1e+06".
+*/
+func concatenate(left interface{}, right interface{}) string {
+	return stringify(left) + stringify(right)
+}
+
+func stringify(value interface{}) string {
+
+	switch value.(type) {
+	case string:
+		return value.(string)
+	case float64:
+		return strconv.FormatFloat(value.(float64), 'f', -1, 64)
+	case *big.Rat:
+		return value.(*big.Rat).RatString()
+	}
+	return fmt.Sprintf("%v", value)
+}
 func subtractStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
-	return left.(float64) - right.(float64), nil
+
+	if isFloat64(left) && isFloat64(right) {
+		return left.(float64) - right.(float64), nil
+	}
+
+	result, ok := addTimes(left, right, true)
+	if !ok {
+		return nil, fmt.Errorf("Value '%v' cannot be subtracted from '%v'", right, left)
+	}
+	return result, nil
 }
 func multiplyStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
 	return left.(float64) * right.(float64), nil
@@ -107,6 +215,20 @@ func divideStage(left interface{}, right interface{}, parameters Parameters) (in
 func exponentStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
 	return math.Pow(left.(float64), right.(float64)), nil
 }
+
+/*
+	Returns an error if an exponentiation of the finite [left] and [right] values gave an infinite [result].
+*/
+func checkExponentOverflow(left interface{}, right interface{}, result interface{}) error {
+
+	if !math.IsInf(result.(float64), 0) ||
+		math.IsInf(left.(float64), 0) ||
+		math.IsInf(right.(float64), 0) {
+		return nil
+	}
+
+	return fmt.Errorf("Exponentiation '%v ** %v' overflows to infinity", left, right)
+}
 func modulusStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
 	return math.Mod(left.(float64), right.(float64)), nil
 }
@@ -114,31 +236,71 @@ func gteStage(left interface{}, right interface{}, parameters Parameters) (inter
 	if isString(left) && isString(right) {
 		return boolIface(left.(string) >= right.(string)), nil
 	}
-	return boolIface(left.(float64) >= right.(float64)), nil
+	if isBytes(left) && isBytes(right) {
+		return boolIface(bytes.Compare(left.([]byte), right.([]byte)) >= 0), nil
+	}
+	if isFloat64(left) && isFloat64(right) {
+		return boolIface(left.(float64) >= right.(float64)), nil
+	}
+	if isTime(left) && isTime(right) {
+		return boolIface(!left.(time.Time).Before(right.(time.Time))), nil
+	}
+	comparison, comparable := compareNumbers(left, right)
+	return boolIface(comparable && comparison >= 0), nil
 }
 func gtStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
 	if isString(left) && isString(right) {
 		return boolIface(left.(string) > right.(string)), nil
 	}
-	return boolIface(left.(float64) > right.(float64)), nil
+	if isBytes(left) && isBytes(right) {
+		return boolIface(bytes.Compare(left.([]byte), right.([]byte)) > 0), nil
+	}
+	if isFloat64(left) && isFloat64(right) {
+		return boolIface(left.(float64) > right.(float64)), nil
+	}
+	if isTime(left) && isTime(right) {
+		return boolIface(left.(time.Time).After(right.(time.Time))), nil
+	}
+	comparison, comparable := compareNumbers(left, right)
+	return boolIface(comparable && comparison > 0), nil
 }
 func lteStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
 	if isString(left) && isString(right) {
 		return boolIface(left.(string) <= right.(string)), nil
 	}
-	return boolIface(left.(float64) <= right.(float64)), nil
+	if isBytes(left) && isBytes(right) {
+		return boolIface(bytes.Compare(left.([]byte), right.([]byte)) <= 0), nil
+	}
+	if isFloat64(left) && isFloat64(right) {
+		return boolIface(left.(float64) <= right.(float64)), nil
+	}
+	if isTime(left) && isTime(right) {
+		return boolIface(!left.(time.Time).After(right.(time.Time))), nil
+	}
+	comparison, comparable := compareNumbers(left, right)
+	return boolIface(comparable && comparison <= 0), nil
 }
 func ltStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
 	if isString(left) && isString(right) {
 		return boolIface(left.(string) < right.(string)), nil
 	}
-	return boolIface(left.(float64) < right.(float64)), nil
+	if isBytes(left) && isBytes(right) {
+		return boolIface(bytes.Compare(left.([]byte), right.([]byte)) < 0), nil
+	}
+	if isFloat64(left) && isFloat64(right) {
+		return boolIface(left.(float64) < right.(float64)), nil
+	}
+	if isTime(left) && isTime(right) {
+		return boolIface(left.(time.Time).Before(right.(time.Time))), nil
+	}
+	comparison, comparable := compareNumbers(left, right)
+	return boolIface(comparable && comparison < 0), nil
 }
 func equalStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
-	return boolIface(reflect.DeepEqual(left, right)), nil
+	return boolIface(isEqual(left, right)), nil
 }
 func notEqualStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
-	return boolIface(!reflect.DeepEqual(left, right)), nil
+	return boolIface(!isEqual(left, right)), nil
 }
 func andStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
 	return boolIface(left.(bool) && right.(bool)), nil
@@ -153,6 +315,9 @@ func invertStage(left interface{}, right interface{}, parameters Parameters) (in
 	return boolIface(!right.(bool)), nil
 }
 func bitwiseNotStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	if isBigInt(right) {
+		return new(big.Int).Not(right.(*big.Int)), nil
+	}
 	return float64(^int64(right.(float64))), nil
 }
 func ternaryIfStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
@@ -168,22 +333,69 @@ func ternaryElseStage(left interface{}, right interface{}, parameters Parameters
 	return right, nil
 }
 
+/*
+	Gives the result of the ordered comparison [symbol] between two strings, as ordered by the given [collation] (see `EvaluableExpression.Collation`).
+	Returns false if the symbol isn't one which collation applies to.
+*/
+func collateStrings(symbol OperatorSymbol, collation func(a, b string) int, left string, right string) (interface{}, bool) {
+
+	switch symbol {
+	case GT:
+		return collation(left, right) > 0, true
+	case GTE:
+		return collation(left, right) >= 0, true
+	case LT:
+		return collation(left, right) < 0, true
+	case LTE:
+		return collation(left, right) <= 0, true
+	}
+	return nil, false
+}
+
 func regexStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
 
-	var pattern *regexp.Regexp
-	var err error
+	pattern, err := compilePattern(right)
+	if err != nil {
+		return nil, err
+	}
+
+	return pattern.Match([]byte(left.(string))), nil
+}
+
+/*
+	Returns the given [pattern] if it's already compiled, or compiles it if it's a string.
+*/
+func compilePattern(pattern interface{}) (*regexp.Regexp, error) {
 
-	switch right.(type) {
+	switch pattern.(type) {
 	case string:
-		pattern, err = regexp.Compile(right.(string))
+		compiled, err := regexp.Compile(pattern.(string))
 		if err != nil {
-			return nil, errors.New(fmt.Sprintf("Unable to compile regexp pattern '%v': %v", right, err))
+			return nil, &patternError{pattern: pattern.(string), err: err}
 		}
+		return compiled, nil
 	case *regexp.Regexp:
-		pattern = right.(*regexp.Regexp)
+		return pattern.(*regexp.Regexp), nil
 	}
 
-	return pattern.Match([]byte(left.(string))), nil
+	return nil, errors.New(fmt.Sprintf("Value '%v' is not a regexp pattern", pattern))
+}
+
+/*
+	Returned when a regexp pattern given at evaluation-time can't be compiled.
+	Kept separate from other errors, so that `EvaluableExpression.RegexErrorHandler` can tell them apart.
+*/
+type patternError struct {
+	pattern string
+	err     error
+}
+
+func (this *patternError) Error() string {
+	return fmt.Sprintf("Unable to compile regexp pattern '%v': %v", this.pattern, this.err)
+}
+
+func (this *patternError) Unwrap() error {
+	return this.err
 }
 
 func notRegexStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
@@ -197,21 +409,146 @@ func notRegexStage(left interface{}, right interface{}, parameters Parameters) (
 }
 
 func bitwiseOrStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	if isBigInt(left) || isBigInt(right) {
+		return bigIntBitwiseStage(BITWISE_OR, left, right)
+	}
 	return float64(int64(left.(float64)) | int64(right.(float64))), nil
 }
 func bitwiseAndStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	if isBigInt(left) || isBigInt(right) {
+		return bigIntBitwiseStage(BITWISE_AND, left, right)
+	}
 	return float64(int64(left.(float64)) & int64(right.(float64))), nil
 }
 func bitwiseXORStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	if isBigInt(left) || isBigInt(right) {
+		return bigIntBitwiseStage(BITWISE_XOR, left, right)
+	}
 	return float64(int64(left.(float64)) ^ int64(right.(float64))), nil
 }
 func leftShiftStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	if isBigInt(left) || isBigInt(right) {
+		return bigIntShiftStage(BITWISE_LSHIFT, left, right)
+	}
 	return float64(uint64(left.(float64)) << uint64(right.(float64))), nil
 }
+
+/*
+	Returns an error if shifting the number [left] left by [right] bits would shift any set bits past the 64th.
+	Shifts of *big.Int values never overflow.
+*/
+func checkShiftOverflow(left interface{}, right interface{}) error {
+
+	if !isFloat64(left) || !isFloat64(right) {
+		return nil
+	}
+
+	value := left.(float64)
+	count := right.(float64)
+
+	if value < 0 {
+		return fmt.Errorf("Unable to shift '%v' left, it is negative", value)
+	}
+	if count < 0 {
+		return fmt.Errorf("Unable to shift by a negative amount '%v'", count)
+	}
+	if value < 1 {
+		return nil
+	}
+
+	// checking the size first means the value can only be converted when it fits, and the count can't overflow an int.
+	if value >= math.MaxUint64 || count >= 64 || bits.Len64(uint64(value))+int(count) > 64 {
+		return fmt.Errorf("Left shift '%v << %v' overflows", value, count)
+	}
+	return nil
+}
 func rightShiftStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	if isBigInt(left) || isBigInt(right) {
+		return bigIntShiftStage(BITWISE_RSHIFT, left, right)
+	}
 	return float64(uint64(left.(float64)) >> uint64(right.(float64))), nil
 }
 
+/*
+	Performs an exact bitwise operation between two *big.Int values.
+	There's no lossless way to combine a *big.Int with a float64, so mixing the two is an error.
+*/
+func bigIntBitwiseStage(symbol OperatorSymbol, left interface{}, right interface{}) (interface{}, error) {
+
+	leftInt, leftValid := left.(*big.Int)
+	rightInt, rightValid := right.(*big.Int)
+
+	if !leftValid || !rightValid {
+		return nil, fmt.Errorf("Unable to use the modifier '%v' between '%v' (%T) and '%v' (%T), both sides must be *big.Int", symbol.String(), left, left, right, right)
+	}
+
+	switch symbol {
+	case BITWISE_OR:
+		return new(big.Int).Or(leftInt, rightInt), nil
+	case BITWISE_AND:
+		return new(big.Int).And(leftInt, rightInt), nil
+	case BITWISE_XOR:
+		return new(big.Int).Xor(leftInt, rightInt), nil
+	}
+
+	return nil, fmt.Errorf("Modifier '%v' is not a bitwise operator", symbol.String())
+}
+
+/*
+	The most bits which a left shift of a *big.Int may make the result have,
+	so that an expression like "big << 1000000000000" is an error rather than using up all memory.
+*/
+const maxBigIntShiftBits = 1 << 20
+
+/*
+	Shifts a *big.Int by a (numeric or *big.Int) count of bits.
+	Unlike the other bitwise operators the count may be a float64, since it's only a small number of bits rather than a value.
+	The count must be a whole, non-negative number, and left shifts may not make a number of more than maxBigIntShiftBits bits.
+*/
+func bigIntShiftStage(symbol OperatorSymbol, left interface{}, right interface{}) (interface{}, error) {
+
+	var count *big.Int
+
+	value, valid := left.(*big.Int)
+	if !valid {
+		return nil, fmt.Errorf("Unable to shift '%v' (%T) by a *big.Int, the shifted value must also be a *big.Int", left, left)
+	}
+
+	switch right.(type) {
+	case float64:
+		if right.(float64) < 0 {
+			return nil, fmt.Errorf("Unable to shift by a negative amount '%v'", right)
+		}
+		if right.(float64) != math.Trunc(right.(float64)) || math.IsInf(right.(float64), 0) {
+			return nil, fmt.Errorf("Unable to shift by '%v', it is not a whole number of bits", right)
+		}
+		count, _ = new(big.Float).SetFloat64(right.(float64)).Int(nil)
+	case *big.Int:
+		if right.(*big.Int).Sign() < 0 {
+			return nil, fmt.Errorf("Unable to shift by '%v', it is not a valid number of bits", right)
+		}
+		count = right.(*big.Int)
+	}
+
+	// shifting right by at least as many bits as the value has always gives the same result, so there's no need to shift further.
+	if symbol == BITWISE_RSHIFT {
+
+		if count.Cmp(big.NewInt(int64(value.BitLen()))) > 0 {
+			count = big.NewInt(int64(value.BitLen()))
+		}
+		return new(big.Int).Rsh(value, uint(count.Uint64())), nil
+	}
+
+	if value.Sign() == 0 {
+		return new(big.Int), nil
+	}
+
+	if count.Sign() > 0 && count.Cmp(big.NewInt(int64(maxBigIntShiftBits-value.BitLen()))) > 0 {
+		return nil, fmt.Errorf("Left shift '%v << %v' would make a number of more than %d bits", value, count, maxBigIntShiftBits)
+	}
+	return new(big.Int).Lsh(value, uint(count.Uint64())), nil
+}
+
 func makeParameterStage(parameterName string) evaluationOperator {
 
 	return func(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
@@ -230,21 +567,64 @@ func makeLiteralStage(literal interface{}) evaluationOperator {
 	}
 }
 
-func makeFunctionStage(function ExpressionFunction) evaluationOperator {
+/*
+	Describes how the arguments of a function call were written.
+*/
+type argumentStyle int
+
+const (
+	// "foo()"
+	noArguments argumentStyle = iota
+
+	// "foo(a)". The argument is passed as-is, even if it is itself a list (such as the result of another function).
+	singleArgument
+
+	// "foo(a, b)". The list made by the separators is spread into individual arguments.
+	argumentList
+)
+
+func makeFunctionStage(function ExpressionFunction, style argumentStyle) evaluationOperator {
 
 	return func(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+		return callFunction(function, style, right)
+	}
+}
 
-		if right == nil {
-			return function()
-		}
+/*
+	Calls [function] with the evaluated arguments [right], which were written in the given [style].
+*/
+func callFunction(function ExpressionFunction, style argumentStyle, right interface{}) (interface{}, error) {
+
+	switch style {
+	case noArguments:
+		return function()
+	case argumentList:
 
-		switch right.(type) {
-		case []interface{}:
-			return function(right.([]interface{})...)
-		default:
-			return function(right)
+		// the list of arguments itself is new for each call, but any lists within it may not be.
+		arguments := right.([]interface{})
+		for i, argument := range arguments {
+			arguments[i] = copyList(argument)
 		}
+		return function(arguments...)
+	}
+	return function(copyList(right))
+}
+
+/*
+	Returns a copy of [value] if it's a list, so that a function which changes the lists it's given can't change
+	a list which is shared between evaluations (such as a literal, or a parameter bound with BindParameter).
+	Only the list itself is copied, not any lists within it. Anything other than a list is returned as-is.
+*/
+func copyList(value interface{}) interface{} {
+
+	list, ok := value.([]interface{})
+	if !ok {
+		return value
 	}
+
+	ret := make([]interface{}, len(list))
+	copy(ret, list)
+	return ret
 }
 
 func typeConvertParam(p reflect.Value, t reflect.Type) (ret reflect.Value, err error) {
@@ -289,6 +669,35 @@ func typeConvertParams(method reflect.Value, params []reflect.Value) ([]reflect.
 	return params, nil
 }
 
+/*
+	Returns the name of the getter which protobuf generates for the given field name,
+	such as "GetUserId" for "user_id".
+*/
+func getterName(field string) string {
+
+	ret := "Get"
+	for _, part := range strings.Split(field, "_") {
+		if part == "" {
+			continue
+		}
+		ret += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return ret
+}
+
+/*
+	Returns the method with the given name on the given struct, or on a pointer to it.
+	Returns an invalid Value if neither has it.
+*/
+func findMethod(coreValue reflect.Value, corePtrVal reflect.Value, name string) reflect.Value {
+
+	method := coreValue.MethodByName(name)
+	if method == (reflect.Value{}) && corePtrVal.IsValid() {
+		method = corePtrVal.MethodByName(name)
+	}
+	return method
+}
+
 func makeAccessorStage(pair []string) evaluationOperator {
 
 	reconstructed := strings.Join(pair, ".")
@@ -325,23 +734,49 @@ func makeAccessorStage(pair []string) evaluationOperator {
 				coreValue = coreValue.Elem()
 			}
 
-			if coreValue.Kind() != reflect.Struct {
-				return nil, errors.New("Unable to access '" + pair[i] + "', '" + pair[i-1] + "' is not a struct")
-			}
+			// maps are traversed by key, so that nested maps can be reached with a dotted path.
+			if coreValue.Kind() == reflect.Map && coreValue.Type().Key().Kind() == reflect.String {
+
+				entry := coreValue.MapIndex(reflect.ValueOf(pair[i]).Convert(coreValue.Type().Key()))
+				if !entry.IsValid() {
+					return nil, errors.New("No key '" + pair[i] + "' present in '" + strings.Join(pair[:i], ".") + "'")
+				}
 
-			field := coreValue.FieldByName(pair[i])
-			if field != (reflect.Value{}) {
-				value = field.Interface()
+				value = entry.Interface()
 				continue
 			}
 
-			method := coreValue.MethodByName(pair[i])
-			if method == (reflect.Value{}) {
-				if corePtrVal.IsValid() {
-					method = corePtrVal.MethodByName(pair[i])
+			if coreValue.Kind() != reflect.Struct {
+				return nil, errors.New("Unable to access '" + pair[i] + "', '" + pair[i-1] + "' is not a struct or map")
+			}
+
+			// generated protobuf messages (and similar types) expose their fields through getters,
+			// so that `msg.user_id` can be read with `msg.GetUserId()`.
+			getter := getterName(pair[i])
+
+			var method reflect.Value
+
+			structField, found := coreValue.Type().FieldByName(pair[i])
+			if found && structField.PkgPath != "" {
+
+				method = findMethod(coreValue, corePtrVal, getter)
+				if method == (reflect.Value{}) {
+					return nil, errors.New("Unable to access unexported field '" + pair[i] + "' in token '" + reconstructed + "'")
+				}
+			} else {
+
+				field := coreValue.FieldByName(pair[i])
+				if field != (reflect.Value{}) {
+					value = field.Interface()
+					continue
 				}
+
+				method = findMethod(coreValue, corePtrVal, pair[i])
 				if method == (reflect.Value{}) {
-					return nil, errors.New("No method or field '" + pair[i] + "' present on parameter '" + pair[i-1] + "'")
+					method = findMethod(coreValue, corePtrVal, getter)
+				}
+				if method == (reflect.Value{}) {
+					return nil, errors.New("No method or field '" + pair[i] + "' (or getter '" + getter + "') present on parameter '" + pair[i-1] + "'")
 				}
 			}
 
@@ -405,23 +840,48 @@ func makeAccessorStage(pair []string) evaluationOperator {
 }
 
 func separatorStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return []interface{}{left, right}, nil
+}
 
-	var ret []interface{}
+/*
+	Used for separators whose left side is another separator, so that "a, b, c" makes one list of three values.
+	Lists which come from anywhere else (such as a function's return value) are never appended to.
+*/
+func appendSeparatorStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+	return append(left.([]interface{}), right), nil
+}
 
-	switch left.(type) {
-	case []interface{}:
-		ret = append(left.([]interface{}), right)
-	default:
-		ret = []interface{}{left, right}
+/*
+	Returns the element of the list [left] at the position [right], such as for "split(s, ',')[0]".
+*/
+func indexStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	index, ok := right.(float64)
+	if !ok {
+		return nil, fmt.Errorf("Unable to index with '%v', which is not a number", right)
+	}
+
+	if index != math.Trunc(index) {
+		return nil, fmt.Errorf("Unable to index with %v, which is not a whole number", index)
 	}
 
-	return ret, nil
+	list := reflect.ValueOf(left)
+	_, isBytes := left.([]byte)
+
+	if left == nil || isBytes || (list.Kind() != reflect.Slice && list.Kind() != reflect.Array) {
+		return nil, fmt.Errorf("Unable to index '%v', which is not a list", left)
+	}
+
+	if index < 0 || index >= float64(list.Len()) {
+		return nil, fmt.Errorf("Index %v is out of range for a list of length %d", index, list.Len())
+	}
+	return castToFloat64(list.Index(int(index)).Interface()), nil
 }
 
 func inStage(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
 
 	for _, value := range right.([]interface{}) {
-		if left == value {
+		if left == value || isMixedNumbers(left, value) && isEqual(left, value) {
 			return true, nil
 		}
 	}
@@ -439,6 +899,34 @@ func isString(value interface{}) bool {
 	return false
 }
 
+func isBytes(value interface{}) bool {
+
+	switch value.(type) {
+	case []byte:
+		return true
+	}
+	return false
+}
+
+/*
+	Returns true if one of [left] or [right] is a []byte and the other is a string (or, on the right, a compiled regex).
+*/
+func isMixedBytes(left interface{}, right interface{}) bool {
+	return (isBytes(left) && isRegexOrString(right)) || (isString(left) && isBytes(right))
+}
+
+/*
+	Returns the given [value] as a string if it's a []byte, or unchanged otherwise.
+*/
+func bytesToString(value interface{}) interface{} {
+
+	switch value.(type) {
+	case []byte:
+		return string(value.([]byte))
+	}
+	return value
+}
+
 func isRegexOrString(value interface{}) bool {
 
 	switch value.(type) {
@@ -466,8 +954,60 @@ func isFloat64(value interface{}) bool {
 	return false
 }
 
+func isBigInt(value interface{}) bool {
+	switch value.(type) {
+	case *big.Int:
+		return true
+	}
+	return false
+}
+
+func isTime(value interface{}) bool {
+	switch value.(type) {
+	case time.Time:
+		return true
+	}
+	return false
+}
+
+/*
+	Bitwise operators work on numbers, but can also work exactly on *big.Int values.
+	Mixing the two passes this check, but is rejected by the operator itself with a more specific error.
+*/
+func isBitwiseOperand(value interface{}) bool {
+	return isFloat64(value) || isBigInt(value)
+}
+
 /*
-	Addition usually means between numbers, but can also mean string concat.
+	Returns true for an untyped nil, as well as for nil pointers, maps, slices, etc. which have been boxed into an interface.
+	Those "typed nils" don't compare equal to nil, but almost always mean the same thing to an expression author.
+*/
+func isNil(value interface{}) bool {
+
+	if value == nil {
+		return true
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Ptr:
+		fallthrough
+	case reflect.Map:
+		fallthrough
+	case reflect.Slice:
+		fallthrough
+	case reflect.Interface:
+		fallthrough
+	case reflect.Func:
+		fallthrough
+	case reflect.Chan:
+		return reflected.IsNil()
+	}
+	return false
+}
+
+/*
+	Addition usually means between numbers, but can also mean string concat, or adding durations (see `addTimes`).
 	String concat needs one (or both) of the sides to be a string.
 */
 func additionTypeCheck(left interface{}, right interface{}) bool {
@@ -475,6 +1015,10 @@ func additionTypeCheck(left interface{}, right interface{}) bool {
 	if isFloat64(left) && isFloat64(right) {
 		return true
 	}
+	_, ok := addTimes(left, right, false)
+	if ok {
+		return true
+	}
 	if !isString(left) && !isString(right) {
 		return false
 	}
@@ -482,20 +1026,168 @@ func additionTypeCheck(left interface{}, right interface{}) bool {
 }
 
 /*
-	Comparison can either be between numbers, or lexicographic between two strings,
-	but never between the two.
+	Addition with `ParsingOptions.DisableStringConcat`, which is the same as `additionTypeCheck` but never allows strings.
 */
-func comparatorTypeCheck(left interface{}, right interface{}) bool {
+func numericAdditionTypeCheck(left interface{}, right interface{}) bool {
 
 	if isFloat64(left) && isFloat64(right) {
 		return true
 	}
+	_, ok := addTimes(left, right, false)
+	return ok
+}
+
+/*
+	Comparison can either be between numbers, between two times, or lexicographic between two strings,
+	but never between different kinds of value.
+*/
+func comparatorTypeCheck(left interface{}, right interface{}) bool {
+
+	if isNumber(left) && isNumber(right) {
+		return true
+	}
 	if isString(left) && isString(right) {
 		return true
 	}
+	if isTime(left) && isTime(right) {
+		return true
+	}
+	if isBytes(left) && isBytes(right) {
+		return true
+	}
+	return false
+}
+
+/*
+	Returns true if the given [value] is of any of Go's integer or floating point types.
+	Parameters are always converted to float64, but other values (such as those returned by functions) may not be.
+*/
+func isNumber(value interface{}) bool {
+
+	if value == nil {
+		return false
+	}
+
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
 	return false
 }
 
+/*
+	Returns true if [left] and [right] are both numbers, but of different types (such as an int64 and a float64).
+*/
+func isMixedNumbers(left interface{}, right interface{}) bool {
+	return isNumber(left) && isNumber(right) && reflect.TypeOf(left) != reflect.TypeOf(right)
+}
+
+/*
+	Determines whether [left] and [right] are equal, as with the "==" operator.
+	Numbers of different types are equal if they have the same value, so that an int64 of 3 is equal to a float64 of 3.
+*/
+func isEqual(left interface{}, right interface{}) bool {
+
+	if isMixedNumbers(left, right) {
+		comparison, comparable := compareNumbers(left, right)
+		return comparable && comparison == 0
+	}
+	if isBytes(left) && isBytes(right) {
+		return bytes.Equal(left.([]byte), right.([]byte))
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+/*
+	Compares two numbers of any types, returning -1, 0, or 1 if [left] is less than, equal to, or greater than [right].
+	If both are integers, they're compared exactly. Otherwise they're both compared as float64.
+	Returns false if the two can't be compared, such as if either is NaN.
+*/
+func compareNumbers(left interface{}, right interface{}) (int, bool) {
+
+	leftValue := reflect.ValueOf(left)
+	rightValue := reflect.ValueOf(right)
+
+	leftSigned, leftIsInteger := integerSign(leftValue)
+	rightSigned, rightIsInteger := integerSign(rightValue)
+
+	if leftIsInteger && rightIsInteger {
+
+		// a negative signed integer is less than any unsigned one, otherwise both can be compared as the same kind.
+		switch {
+		case leftSigned && rightSigned:
+			return compareOrdered(leftValue.Int() < rightValue.Int(), leftValue.Int() > rightValue.Int()), true
+		case leftSigned && leftValue.Int() < 0:
+			return -1, true
+		case rightSigned && rightValue.Int() < 0:
+			return 1, true
+		}
+		return compareOrdered(integerMagnitude(leftValue) < integerMagnitude(rightValue), integerMagnitude(leftValue) > integerMagnitude(rightValue)), true
+	}
+
+	leftFloat := numberToFloat64(leftValue)
+	rightFloat := numberToFloat64(rightValue)
+
+	if math.IsNaN(leftFloat) || math.IsNaN(rightFloat) {
+		return 0, false
+	}
+	return compareOrdered(leftFloat < rightFloat, leftFloat > rightFloat), true
+}
+
+/*
+	Returns whether the given [value] is a signed integer, and whether it's an integer at all.
+*/
+func integerSign(value reflect.Value) (bool, bool) {
+
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return false, true
+	}
+	return false, false
+}
+
+/*
+	Returns the given non-negative integer [value] (of any integer kind) as a uint64.
+*/
+func integerMagnitude(value reflect.Value) uint64 {
+
+	signed, _ := integerSign(value)
+	if signed {
+		return uint64(value.Int())
+	}
+	return value.Uint()
+}
+
+/*
+	Returns the given [value] (of any integer or floating point kind) as a float64.
+*/
+func numberToFloat64(value reflect.Value) float64 {
+
+	signed, integer := integerSign(value)
+	if !integer {
+		return value.Float()
+	}
+	if signed {
+		return float64(value.Int())
+	}
+	return float64(value.Uint())
+}
+
+func compareOrdered(less bool, greater bool) int {
+
+	if less {
+		return -1
+	}
+	if greater {
+		return 1
+	}
+	return 0
+}
+
 func isArray(value interface{}) bool {
 	switch value.(type) {
 	case []interface{}:
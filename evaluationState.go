@@ -0,0 +1,110 @@
+package govaluate
+
+import (
+	"errors"
+	"reflect"
+	"sync/atomic"
+)
+
+/*
+	Holds anything which needs to be tracked over the course of a single evaluation, as opposed to being part of the expression.
+	Only evaluations which need it (such as those with a timeout, or which are being explained or limit strings) have one; it's nil otherwise.
+*/
+type evaluationState struct {
+
+	// set to non-zero (from another goroutine) when the evaluation should stop as soon as possible.
+	cancelled int32
+
+	// whether each decision made by the evaluation should be added to [decisions]. See `EvaluateExplained`.
+	explaining bool
+	decisions  []Decision
+
+	// whether the outcome of each comparison should be added to [conditions]. See `EvaluateConditions`.
+	// [conditionStage] is the comparison currently being recorded, if any.
+	recordsConditions bool
+	conditions        []Condition
+	conditionStage    *evaluationStage
+
+	// the total length of the strings built so far, for `MaxStringBytes`.
+	stringBytes int
+
+	// the results of pure functions called so far, when the expression doesn't have its own FunctionCache.
+	functionResults *FunctionCache
+}
+
+var errEvaluationCancelled = errors.New("Evaluation was cancelled")
+
+func (this *evaluationState) cancel() {
+	atomic.StoreInt32(&this.cancelled, 1)
+}
+
+func (this *evaluationState) isCancelled() bool {
+	return atomic.LoadInt32(&this.cancelled) != 0
+}
+
+/*
+	Returned when an evaluation builds more than its expression's `MaxStringBytes` of strings in total.
+*/
+var ErrStringBudgetExceeded = errors.New("Evaluation exceeded the maximum total size of strings")
+
+/*
+	Counts the given [value] (if it's a string) towards the total length of strings built by this evaluation,
+	and returns ErrStringBudgetExceeded if that total is now more than [limit].
+*/
+func (this *evaluationState) allocateString(value interface{}, limit int) error {
+
+	str, ok := value.(string)
+	if !ok {
+		return nil
+	}
+
+	this.stringBytes += len(str)
+	if this.stringBytes > limit {
+		return ErrStringBudgetExceeded
+	}
+	return nil
+}
+
+/*
+	Returns ErrStringBudgetExceeded if building a string of [size] bytes would take the total length of strings built by this evaluation
+	past [limit]. The string isn't counted; that happens once it's built, with `allocateString`.
+*/
+func (this *evaluationState) checkStringSize(size int, limit int) error {
+
+	if this.stringBytes+size > limit {
+		return ErrStringBudgetExceeded
+	}
+	return nil
+}
+
+/*
+	Returned when a function returns a list (or map) with more elements than its expression's `MaxCollectionSize`.
+*/
+var ErrCollectionTooLarge = errors.New("Evaluation exceeded the maximum size of a collection")
+
+/*
+	Returns ErrCollectionTooLarge if the given [value] is a slice, array, or map with more than [limit] elements.
+*/
+func checkCollectionSize(value interface{}, limit int) error {
+
+	reflected := reflect.ValueOf(value)
+
+	switch reflected.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if reflected.Len() > limit {
+			return ErrCollectionTooLarge
+		}
+	}
+	return nil
+}
+
+/*
+	Returns the cache of the results of pure functions called during this evaluation.
+*/
+func (this *evaluationState) functionCache() *FunctionCache {
+
+	if this.functionResults == nil {
+		this.functionResults = NewFunctionCache(maxEvaluationFunctionResults)
+	}
+	return this.functionResults
+}
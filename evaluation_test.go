@@ -3,7 +3,10 @@ package govaluate
 import (
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 )
@@ -469,6 +472,18 @@ func TestNoParameterEvaluation(test *testing.T) {
 			Input:    "123 + 'bar' == '123bar'",
 			Expected: true,
 		},
+		EvaluationTest{
+
+			Name:     "Large and small float64 to string concat",
+			Input:    "1000000 + ' and ' + 0.0000001 + ' and ' + 1.5",
+			Expected: "1000000 and 0.0000001 and 1.5",
+		},
+		EvaluationTest{
+
+			Name:     "Large float64 explicit concat",
+			Input:    "1000000 .. 123456789012",
+			Expected: "1000000123456789012",
+		},
 		EvaluationTest{
 
 			Name:     "String to date concat",
@@ -487,6 +502,18 @@ func TestNoParameterEvaluation(test *testing.T) {
 			Input:    "true + 'bar' == 'truebar'",
 			Expected: true,
 		},
+		EvaluationTest{
+
+			Name:     "Explicit concat of numbers",
+			Input:    "1..2.5",
+			Expected: "12.5",
+		},
+		EvaluationTest{
+
+			Name:     "Explicit concat with additive precedence",
+			Input:    "1 + 2 .. 3 * 4 .. true",
+			Expected: "312true",
+		},
 		EvaluationTest{
 
 			Name:     "Null coalesce left",
@@ -601,6 +628,24 @@ func TestNoParameterEvaluation(test *testing.T) {
 
 			Expected: 14.0,
 		},
+		EvaluationTest{
+
+			Name:  "Function with whitespace before parenthesis",
+			Input: "max (foo, 2) + max(foo,2)",
+			Functions: map[string]ExpressionFunction{
+				"max": func(arguments ...interface{}) (interface{}, error) {
+					return math.Max(arguments[0].(float64), arguments[1].(float64)), nil
+				},
+			},
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "foo",
+					Value: 5,
+				},
+			},
+
+			Expected: 10.0,
+		},
 		EvaluationTest{
 
 			Name:  "Empty function and modifier, compared",
@@ -1088,6 +1133,22 @@ func TestParameterizedEvaluation(test *testing.T) {
 			},
 			Expected: "baz123bartrue",
 		},
+		EvaluationTest{
+
+			Name:  "Explicit concat of parameters without whitespace",
+			Input: "foo..bar",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "foo",
+					Value: "baz",
+				},
+				EvaluationParameter{
+					Name:  "bar",
+					Value: 1,
+				},
+			},
+			Expected: "baz1",
+		},
 		EvaluationTest{
 
 			Name:  "Integer width spectrum",
@@ -1391,6 +1452,37 @@ func TestParameterizedEvaluation(test *testing.T) {
 			Parameters: []EvaluationParameter{fooParameter},
 			Expected:   "funkalicious",
 		},
+		EvaluationTest{
+
+			Name:  "Nested map parameter",
+			Input: "user.profile.age >= 18 && user.name == 'bob'",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name: "user",
+					Value: map[string]interface{}{
+						"name": "bob",
+						"profile": map[string]interface{}{
+							"age": 21,
+						},
+					},
+				},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:  "Struct field within map parameter",
+			Input: "users.bob.Nested.Funk",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name: "users",
+					Value: map[string]dummyParameter{
+						"bob": fooParameter.Value.(dummyParameter),
+					},
+				},
+			},
+			Expected: "funkalicious",
+		},
 		EvaluationTest{
 
 			Name:       "Parameter call with + modifier",
@@ -1465,6 +1557,57 @@ func TestStructFunctions(test *testing.T) {
 	}
 }
 
+/*
+	Tests that bitwise operators are exact when given *big.Int values wider than 64 bits.
+	These can't go through `runEvaluationTests`, since *big.Int results need to be compared with Cmp().
+*/
+func TestBigIntBitwise(test *testing.T) {
+
+	wide, _ := new(big.Int).SetString("ff0000000000000000000000000000ff", 16)
+	mask, _ := new(big.Int).SetString("0f00000000000000000000000000000f", 16)
+
+	cases := map[string]string{
+		"wide & mask":           "0f00000000000000000000000000000f",
+		"wide | mask":           "ff0000000000000000000000000000ff",
+		"wide ^ mask":           "f00000000000000000000000000000f0",
+		"mask << 4":             "f00000000000000000000000000000f0",
+		"wide >> 120":           "ff",
+		"~mask & wide":          "f00000000000000000000000000000f0",
+		"wide >> 1000000000000": "0",
+		"(mask << 1000) >> 996": "f00000000000000000000000000000f0",
+	}
+
+	parameters := map[string]interface{}{
+		"wide": wide,
+		"mask": mask,
+	}
+
+	for input, expectedString := range cases {
+
+		expected, _ := new(big.Int).SetString(expectedString, 16)
+
+		expression, err := NewEvaluableExpression(input)
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %v", input, err)
+			test.Fail()
+			continue
+		}
+
+		result, err := expression.Evaluate(parameters)
+		if err != nil {
+			test.Logf("Test '%s' failed: %v", input, err)
+			test.Fail()
+			continue
+		}
+
+		resultInt, valid := result.(*big.Int)
+		if !valid || resultInt.Cmp(expected) != 0 {
+			test.Logf("Test '%s' failed: expected %x, got %v", input, expected, result)
+			test.Fail()
+		}
+	}
+}
+
 func runEvaluationTests(evaluationTests []EvaluationTest, test *testing.T) {
 
 	var expression *EvaluableExpression
@@ -1514,3 +1657,287 @@ func runEvaluationTests(evaluationTests []EvaluationTest, test *testing.T) {
 		}
 	}
 }
+
+type namedInteger int
+
+/*
+	Parameters are always converted to float64, but values returned by functions aren't,
+	so comparisons need to handle numbers of different types.
+*/
+func TestMixedNumericComparisons(test *testing.T) {
+
+	values := map[string]interface{}{
+		"int3":            int64(3),
+		"uint3":           uint(3),
+		"float32Half":     float32(0.5),
+		"named3":          namedInteger(3),
+		"exact":           int64(1 << 53),
+		"exactPlusOne":    uint64(1<<53 + 1),
+		"negative":        int8(-1),
+		"maxUint":         uint64(math.MaxUint64),
+		"notANumber":      math.NaN(),
+		"floatNotANumber": float32(math.NaN()),
+	}
+
+	functions := map[string]ExpressionFunction{
+		"get": func(arguments ...interface{}) (interface{}, error) {
+			return values[arguments[0].(string)], nil
+		},
+	}
+
+	evaluationTests := []EvaluationTest{
+
+		EvaluationTest{
+			Name:      "Int equal to float",
+			Input:     "get('int3') == 3",
+			Functions: functions,
+			Expected:  true,
+		},
+		EvaluationTest{
+			Name:      "Int not equal to float",
+			Input:     "get('int3') != 3",
+			Functions: functions,
+			Expected:  false,
+		},
+		EvaluationTest{
+			Name:      "Int not equal to fractional float",
+			Input:     "get('int3') == 3.5",
+			Functions: functions,
+			Expected:  false,
+		},
+		EvaluationTest{
+			Name:      "Int less than float",
+			Input:     "get('int3') < 3.5 && get('int3') <= 3 && get('int3') >= 3 && !(get('int3') > 3)",
+			Functions: functions,
+			Expected:  true,
+		},
+		EvaluationTest{
+			Name:      "Signed and unsigned ints",
+			Input:     "get('uint3') == get('int3') && get('named3') == 3",
+			Functions: functions,
+			Expected:  true,
+		},
+		EvaluationTest{
+			Name:      "Float32 and float64",
+			Input:     "get('float32Half') == 0.5 && get('float32Half') < 1",
+			Functions: functions,
+			Expected:  true,
+		},
+		EvaluationTest{
+			Name:      "Ints compared exactly beyond float precision",
+			Input:     "get('exact') == get('exactPlusOne')",
+			Functions: functions,
+			Expected:  false,
+		},
+		EvaluationTest{
+			Name:      "Ints ordered exactly beyond float precision",
+			Input:     "get('exact') < get('exactPlusOne')",
+			Functions: functions,
+			Expected:  true,
+		},
+		EvaluationTest{
+			Name:      "Negative int less than large unsigned int",
+			Input:     "get('negative') < get('maxUint') && get('maxUint') > get('negative')",
+			Functions: functions,
+			Expected:  true,
+		},
+		EvaluationTest{
+			Name:      "Int compared to NaN",
+			Input:     "get('int3') < get('notANumber') || get('int3') >= get('floatNotANumber') || get('int3') == get('notANumber')",
+			Functions: functions,
+			Expected:  false,
+		},
+		EvaluationTest{
+			Name:      "Int in list of floats",
+			Input:     "get('int3') in (1, 2, 3)",
+			Functions: functions,
+			Expected:  true,
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+}
+
+func TestCollation(test *testing.T) {
+
+	// a stand-in for a real collator, which sorts accented letters with their unaccented ones.
+	folder := strings.NewReplacer("é", "e", "É", "E")
+	collation := func(a, b string) int {
+		return strings.Compare(folder.Replace(a), folder.Replace(b))
+	}
+
+	cases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"'é' < 'f'", true},
+		{"name < 'f' && name >= 'e' && !(name > 'e')", true},
+		{"name <= 'e'", true},
+		{"name == 'e'", false},
+		{"'b' > 'a'", true},
+		{"1 < 2", true},
+		{"name =~ '^é'", true},
+	}
+
+	for _, testCase := range cases {
+
+		expression, _ := NewEvaluableExpression(testCase.input)
+		expression.Collation = collation
+
+		result, err := expression.Evaluate(map[string]interface{}{"name": "é"})
+		if err != nil || result != testCase.expected {
+			test.Logf("Expected '%s' to be %v with a collation, got %v, %v", testCase.input, testCase.expected, result, err)
+			test.Fail()
+		}
+	}
+
+	// by default, strings are compared byte-wise.
+	expression, _ := NewEvaluableExpression("'é' < 'f'")
+
+	result, err := expression.Evaluate(nil)
+	if err != nil || result != false {
+		test.Logf("Expected byte-wise comparison by default, got %v, %v", result, err)
+		test.Fail()
+	}
+}
+
+func TestIndexing(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"pair": func(arguments ...interface{}) (interface{}, error) {
+			return []interface{}{1.0, 2.0}, nil
+		},
+		"grid": func(arguments ...interface{}) (interface{}, error) {
+			return []interface{}{[]interface{}{"a", "b"}, []interface{}{"c", "d"}}, nil
+		},
+		"ints": func(arguments ...interface{}) (interface{}, error) {
+			return []int{10, 20, 30}, nil
+		},
+		"scalar": func(arguments ...interface{}) (interface{}, error) {
+			return "abc", nil
+		},
+	}
+
+	evaluationTests := []EvaluationTest{
+
+		EvaluationTest{
+
+			Name:     "Index into a built-in's result",
+			Input:    "split('a,b', ',')[0]",
+			Expected: "a",
+		},
+		EvaluationTest{
+
+			Name:      "Indexed result used by an operator",
+			Input:     "pair()[1] + 1",
+			Functions: functions,
+			Expected:  3.0,
+		},
+		EvaluationTest{
+
+			Name:      "Indexed result under a prefix",
+			Input:     "-pair()[0] * 2",
+			Functions: functions,
+			Expected:  -2.0,
+		},
+		EvaluationTest{
+
+			Name:      "Index by an expression",
+			Input:     "pair()[i - 1]",
+			Functions: functions,
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "i",
+					Value: 2,
+				},
+			},
+			Expected: 2.0,
+		},
+		EvaluationTest{
+
+			Name:      "Chained indexes",
+			Input:     "grid()[1][0] .. grid() [0] [1]",
+			Functions: functions,
+			Expected:  "cb",
+		},
+		EvaluationTest{
+
+			Name:      "Index into a typed slice",
+			Input:     "ints()[2]",
+			Functions: functions,
+			Expected:  30.0,
+		},
+		EvaluationTest{
+
+			Name:      "Index which calls a function",
+			Input:     "split('x,y,z', ',')[pair()[1]]",
+			Functions: functions,
+			Expected:  "z",
+		},
+		EvaluationTest{
+
+			Name:      "Bracketed parameter within an index",
+			Input:     "pair()[[an index]]",
+			Functions: functions,
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "an index",
+					Value: 0,
+				},
+			},
+			Expected: 1.0,
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+
+	failureTests := []EvaluationFailureTest{
+
+		EvaluationFailureTest{
+
+			Name:      "Index past the end",
+			Input:     "pair()[2]",
+			Functions: functions,
+			Expected:  "Index 2 is out of range for a list of length 2",
+		},
+		EvaluationFailureTest{
+
+			Name:      "Negative index",
+			Input:     "pair()[-1]",
+			Functions: functions,
+			Expected:  "Index -1 is out of range",
+		},
+		EvaluationFailureTest{
+
+			Name:      "Fractional index",
+			Input:     "pair()[0.5]",
+			Functions: functions,
+			Expected:  "not a whole number",
+		},
+		EvaluationFailureTest{
+
+			Name:      "Non-numeric index",
+			Input:     "pair()['a']",
+			Functions: functions,
+			Expected:  "which is not a number",
+		},
+		EvaluationFailureTest{
+
+			Name:      "Index into something which isn't a list",
+			Input:     "scalar()[0]",
+			Functions: functions,
+			Expected:  "Unable to index 'abc', which is not a list",
+		},
+	}
+
+	runEvaluationFailureTests(failureTests, test)
+
+	for _, input := range []string{"pair()[]", "pair()[0)", "(pair()[0]"} {
+
+		_, err := NewEvaluableExpressionWithFunctions(input, functions)
+		if err == nil {
+			test.Logf("Expected a parsing error for '%s'", input)
+			test.Fail()
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package govaluate
+
+import (
+	"reflect"
+	"testing"
+)
+
+/*
+	A Decision, with its position given as the text of its condition.
+*/
+type explainedDecision struct {
+	condition string
+	symbol    OperatorSymbol
+	value     interface{}
+	taken     bool
+}
+
+func TestEvaluateExplained(test *testing.T) {
+
+	input := "age >= 18 ? (member || vip ? 'discount' : 'full') : 'child'"
+	expression, _ := NewEvaluableExpression(input)
+
+	result, decisions, err := expression.EvaluateExplained(map[string]interface{}{"age": 30, "member": false, "vip": true})
+	if err != nil || result != "discount" {
+		test.Logf("Expected explained evaluation to give 'discount', got %v, %v", result, err)
+		test.Fail()
+		return
+	}
+
+	expected := []explainedDecision{
+		explainedDecision{"age >= 18", TERNARY_TRUE, true, true},
+		explainedDecision{"member", OR, false, true},
+		explainedDecision{"member || vip", TERNARY_TRUE, true, true},
+	}
+	checkDecisions(test, input, decisions, expected)
+
+	// short-circuited parts of the expression make no decisions.
+	_, decisions, _ = expression.EvaluateExplained(map[string]interface{}{"age": 10, "member": true, "vip": true})
+
+	expected = []explainedDecision{
+		explainedDecision{"age >= 18", TERNARY_TRUE, false, false},
+	}
+	checkDecisions(test, input, decisions, expected)
+
+	input = "a && b"
+	expression, _ = NewEvaluableExpression(input)
+
+	_, decisions, _ = expression.EvaluateExplained(map[string]interface{}{"a": false, "b": true})
+
+	expected = []explainedDecision{
+		explainedDecision{"a", AND, false, false},
+	}
+	checkDecisions(test, input, decisions, expected)
+
+	// ordinary evaluation is unaffected.
+	result, err = expression.Evaluate(map[string]interface{}{"a": true, "b": true})
+	if err != nil || result != true {
+		test.Logf("Expected ordinary evaluation to give true, got %v, %v", result, err)
+		test.Fail()
+	}
+}
+
+func checkDecisions(test *testing.T, input string, decisions []Decision, expected []explainedDecision) {
+
+	if len(decisions) != len(expected) {
+		test.Logf("Expected %d decisions for '%s', got %v", len(expected), input, decisions)
+		test.Fail()
+		return
+	}
+
+	for i, decision := range decisions {
+
+		actual := explainedDecision{input[decision.Start:decision.End], decision.Symbol, decision.Condition, decision.Taken}
+		if actual != expected[i] {
+			test.Logf("Expected decision %d of '%s' to be %v, got %v", i, input, expected[i], actual)
+			test.Fail()
+		}
+	}
+}
+
+func TestEvaluateConditions(test *testing.T) {
+
+	input := "temperature > 30 || (humidity >= 80 && wind < 10) || 'storm' in alerts"
+	expression, _ := NewEvaluableExpression(input)
+
+	parameters := map[string]interface{}{
+		"temperature": 25,
+		"humidity":    90,
+		"wind":        5,
+		"alerts":      []interface{}{"flood"},
+	}
+
+	result, conditions, err := expression.EvaluateConditions(parameters)
+	if err != nil || result != true {
+		test.Logf("Expected evaluation to give true, got %v, %v", result, err)
+		test.Fail()
+		return
+	}
+
+	// the last comparison is short-circuited, so it isn't evaluated.
+	expected := []Condition{
+		Condition{Start: 0, End: 16, Text: "temperature > 30", Symbol: GT, Result: false},
+		Condition{Start: 21, End: 35, Text: "humidity >= 80", Symbol: GTE, Result: true},
+		Condition{Start: 39, End: 48, Text: "wind < 10", Symbol: LT, Result: true},
+	}
+
+	if !reflect.DeepEqual(conditions, expected) {
+		test.Logf("Expected conditions %v, got %v", expected, conditions)
+		test.Fail()
+	}
+
+	// comparisons within comparisons are recorded as well, before the comparison they're in.
+	input = "(a > 1) == (b > 1)"
+	expression, _ = NewEvaluableExpression(input)
+
+	_, conditions, _ = expression.EvaluateConditions(map[string]interface{}{"a": 2, "b": 0})
+
+	texts := make([]string, len(conditions))
+	for i, condition := range conditions {
+		texts[i] = condition.Text
+	}
+
+	if !reflect.DeepEqual(texts, []string{"a > 1", "b > 1", "(a > 1) == (b > 1)"}) || conditions[2].Result != false {
+		test.Logf("Expected nested conditions to be recorded in order, got %v", conditions)
+		test.Fail()
+	}
+
+	// conditions before an error are still returned.
+	expression, _ = NewEvaluableExpression("a > 1 && missing > 1")
+
+	_, conditions, err = expression.EvaluateConditions(map[string]interface{}{"a": 2})
+	if err == nil || len(conditions) != 1 || conditions[0].Text != "a > 1" {
+		test.Logf("Expected one condition and an error, got %v, %v", conditions, err)
+		test.Fail()
+	}
+}
@@ -1,8 +1,183 @@
 package govaluate
 
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
 /*
 	Represents a function that can be called from within an expression.
 	This method must return an error if, for any reason, it is unable to produce exactly one unambiguous result.
 	An error returned will halt execution of the expression.
 */
 type ExpressionFunction func(arguments ...interface{}) (interface{}, error)
+
+/*
+	One implementation of an overloaded function, used with `NewOverloadedFunction`.
+*/
+type FunctionOverload struct {
+
+	/*
+		The type of each argument this implementation accepts, in order.
+		An argument matches if its type is assignable to the given type (so interface types match any implementation of them).
+		A nil type matches any argument, including nil.
+
+		Remember that all numbers in expressions are float64.
+	*/
+	ArgumentTypes []reflect.Type
+
+	/*
+		Called when the arguments match ArgumentTypes.
+	*/
+	Function ExpressionFunction
+}
+
+/*
+	Creates a single ExpressionFunction which dispatches to one of the given [overloads], based on the types of the
+	arguments it is called with. The first overload whose ArgumentTypes match is used.
+	If none match, the function returns an error listing the available overloads.
+	The [name] is only used for that error message, and should be the name the function is given in the expression.
+*/
+func NewOverloadedFunction(name string, overloads ...FunctionOverload) ExpressionFunction {
+
+	return func(arguments ...interface{}) (interface{}, error) {
+
+		for _, overload := range overloads {
+			if overload.accepts(arguments) {
+				return overload.Function(arguments...)
+			}
+		}
+
+		signatures := make([]string, len(overloads))
+		for i, overload := range overloads {
+			signatures[i] = name + overload.signature()
+		}
+
+		argumentTypes := make([]string, len(arguments))
+		for i, argument := range arguments {
+			argumentTypes[i] = describeArgumentType(argument)
+		}
+
+		return nil, fmt.Errorf("No overload of function '%s' accepts arguments (%s); available overloads are: %s",
+			name, strings.Join(argumentTypes, ", "), strings.Join(signatures, ", "))
+	}
+}
+
+/*
+	Creates an ExpressionFunction which takes [required] arguments, followed by up to len([defaults]) optional arguments.
+	Any optional arguments which aren't given when it's called are filled in from [defaults], in order,
+	so that [function] is always called with exactly [required] + len([defaults]) arguments.
+
+	Defaults must be constants which could be written in an expression (numbers, strings, bools, or nil), and numbers are
+	converted to float64 as they would be in an expression. Returns an error if any default isn't one of those.
+	The [name] is only used for error messages, and should be the name the function is given in the expression.
+*/
+func NewFunctionWithDefaults(name string, function ExpressionFunction, required int, defaults ...interface{}) (ExpressionFunction, error) {
+
+	if required < 0 {
+		return nil, fmt.Errorf("Function '%s' cannot require %d arguments", name, required)
+	}
+
+	constants := make([]interface{}, len(defaults))
+	for i, value := range defaults {
+
+		value = castToFloat64(value)
+		if !isConstant(value) {
+			return nil, fmt.Errorf("Default value '%v' for argument %d of function '%s' is not a number, string, bool, or nil", value, required+i+1, name)
+		}
+		constants[i] = value
+	}
+
+	maximum := required + len(constants)
+
+	return func(arguments ...interface{}) (interface{}, error) {
+
+		if len(arguments) < required || len(arguments) > maximum {
+			return nil, fmt.Errorf("Function '%s' expects between %d and %d arguments, got %d", name, required, maximum, len(arguments))
+		}
+
+		if len(arguments) == maximum {
+			return function(arguments...)
+		}
+
+		// copied, so that the caller's arguments are never appended to.
+		filled := make([]interface{}, maximum)
+		copy(filled, arguments)
+		copy(filled[len(arguments):], constants[len(arguments)-required:])
+
+		return function(filled...)
+	}, nil
+}
+
+/*
+	Creates an ExpressionFunction which calls [function], but returns [fallback] instead of any error it returns,
+	so that a function which can fail for reasons outside the expression (like one backed by a network call) doesn't stop the whole evaluation.
+	Numeric fallbacks are converted to float64, as they would be in an expression.
+	Errors in evaluating the function's arguments aren't affected; for those, use the "try" built-in.
+*/
+func NewFunctionWithFallback(function ExpressionFunction, fallback interface{}) ExpressionFunction {
+
+	fallback = castToFloat64(fallback)
+
+	return func(arguments ...interface{}) (interface{}, error) {
+
+		result, err := function(arguments...)
+		if err != nil {
+			return fallback, nil
+		}
+		return result, nil
+	}
+}
+
+/*
+	Returns true if the given [value] is of a type which can be written as a literal in an expression.
+*/
+func isConstant(value interface{}) bool {
+	return value == nil || isFloat64(value) || isString(value) || isBool(value)
+}
+
+func (this FunctionOverload) accepts(arguments []interface{}) bool {
+
+	if len(arguments) != len(this.ArgumentTypes) {
+		return false
+	}
+
+	for i, argumentType := range this.ArgumentTypes {
+
+		if argumentType == nil {
+			continue
+		}
+
+		if arguments[i] == nil || !reflect.TypeOf(arguments[i]).AssignableTo(argumentType) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+	Returns the argument types of this overload, formatted like "(float64, string)".
+*/
+func (this FunctionOverload) signature() string {
+
+	types := make([]string, len(this.ArgumentTypes))
+	for i, argumentType := range this.ArgumentTypes {
+
+		if argumentType == nil {
+			types[i] = "any"
+			continue
+		}
+		types[i] = argumentType.String()
+	}
+
+	return "(" + strings.Join(types, ", ") + ")"
+}
+
+func describeArgumentType(argument interface{}) string {
+
+	if argument == nil {
+		return "nil"
+	}
+	return reflect.TypeOf(argument).String()
+}
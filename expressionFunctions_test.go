@@ -0,0 +1,275 @@
+package govaluate
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type money struct {
+	cents int64
+}
+
+func TestOverloadedFunctions(test *testing.T) {
+
+	abs := NewOverloadedFunction("abs",
+		FunctionOverload{
+			ArgumentTypes: []reflect.Type{reflect.TypeOf(0.0)},
+			Function: func(arguments ...interface{}) (interface{}, error) {
+				return math.Abs(arguments[0].(float64)), nil
+			},
+		},
+		FunctionOverload{
+			ArgumentTypes: []reflect.Type{reflect.TypeOf(money{})},
+			Function: func(arguments ...interface{}) (interface{}, error) {
+
+				cents := arguments[0].(money).cents
+				if cents < 0 {
+					cents = -cents
+				}
+				return money{cents}, nil
+			},
+		},
+		FunctionOverload{
+			ArgumentTypes: []reflect.Type{nil, reflect.TypeOf("")},
+			Function: func(arguments ...interface{}) (interface{}, error) {
+				return "two arguments", nil
+			},
+		},
+	)
+
+	functions := map[string]ExpressionFunction{"abs": abs}
+
+	evaluationTests := []EvaluationTest{
+
+		EvaluationTest{
+
+			Name:      "Overload for numbers",
+			Input:     "abs(-2) + abs(foo)",
+			Functions: functions,
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "foo",
+					Value: -3,
+				},
+			},
+			Expected: 5.0,
+		},
+		EvaluationTest{
+
+			Name:      "Overload for a custom type",
+			Input:     "abs(foo)",
+			Functions: functions,
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "foo",
+					Value: money{-150},
+				},
+			},
+			Expected: money{150},
+		},
+		EvaluationTest{
+
+			Name:      "Overload matching any type",
+			Input:     "abs(foo, 'bar')",
+			Functions: functions,
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "foo",
+					Value: nil,
+				},
+			},
+			Expected: "two arguments",
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+
+	expression, _ := NewEvaluableExpressionWithFunctions("abs('foo')", functions)
+
+	_, err := expression.Evaluate(nil)
+	if err == nil {
+		test.Logf("Expected an error when no overload matches")
+		test.Fail()
+		return
+	}
+
+	expected := "No overload of function 'abs' accepts arguments (string); available overloads are: abs(float64), abs(govaluate.money), abs(any, string)"
+	if !strings.Contains(err.Error(), expected) {
+		test.Logf("Expected error '%s', got '%v'", expected, err)
+		test.Fail()
+	}
+}
+
+func TestFunctionsWithDefaults(test *testing.T) {
+
+	// pad(s, width, padding) pads s on the left to the given width.
+	pad, err := NewFunctionWithDefaults("pad", func(arguments ...interface{}) (interface{}, error) {
+
+		ret := arguments[0].(string)
+		for len(ret) < int(arguments[1].(float64)) {
+			ret = arguments[2].(string) + ret
+		}
+		return ret, nil
+	}, 1, 4, "0")
+
+	if err != nil {
+		test.Logf("Expected a function with defaults to be created, got %v", err)
+		test.Fail()
+		return
+	}
+
+	functions := map[string]ExpressionFunction{"pad": pad}
+
+	evaluationTests := []EvaluationTest{
+
+		EvaluationTest{
+
+			Name:      "All defaults",
+			Input:     "pad('7')",
+			Functions: functions,
+			Expected:  "0007",
+		},
+		EvaluationTest{
+
+			Name:      "Some defaults",
+			Input:     "pad('7', 2)",
+			Functions: functions,
+			Expected:  "07",
+		},
+		EvaluationTest{
+
+			Name:      "No defaults",
+			Input:     "pad('7', 3, ' ')",
+			Functions: functions,
+			Expected:  "  7",
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+
+	failureTests := []EvaluationFailureTest{
+		EvaluationFailureTest{
+
+			Name:      "Too few arguments",
+			Input:     "pad()",
+			Functions: functions,
+			Expected:  "Function 'pad' expects between 1 and 3 arguments, got 0",
+		},
+		EvaluationFailureTest{
+
+			Name:      "Too many arguments",
+			Input:     "pad('7', 2, ' ', 1)",
+			Functions: functions,
+			Expected:  "Function 'pad' expects between 1 and 3 arguments, got 4",
+		},
+	}
+
+	runEvaluationFailureTests(failureTests, test)
+
+	_, err = NewFunctionWithDefaults("pad", pad, 1, []string{"0"})
+	if err == nil || !strings.Contains(err.Error(), "is not a number, string, bool, or nil") {
+		test.Logf("Expected a non-constant default to be rejected, got %v", err)
+		test.Fail()
+	}
+}
+
+func TestFunctionsWithFallbacks(test *testing.T) {
+
+	// fetches a price, failing for anything it doesn't know.
+	fetchPrice := func(arguments ...interface{}) (interface{}, error) {
+
+		if arguments[0] == "apple" {
+			return 2.5, nil
+		}
+		return nil, errors.New("Service unavailable")
+	}
+
+	functions := map[string]ExpressionFunction{
+		"price":       NewFunctionWithFallback(fetchPrice, 0),
+		"strictPrice": fetchPrice,
+	}
+
+	evaluationTests := []EvaluationTest{
+
+		EvaluationTest{
+
+			Name:      "Successful call",
+			Input:     "price('apple')",
+			Functions: functions,
+			Expected:  2.5,
+		},
+		EvaluationTest{
+
+			Name:      "Failed call",
+			Input:     "price('pear') + 1",
+			Functions: functions,
+			Expected:  1.0,
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+
+	failureTests := []EvaluationFailureTest{
+		EvaluationFailureTest{
+
+			Name:      "Without a fallback",
+			Input:     "strictPrice('pear')",
+			Functions: functions,
+			Expected:  "Service unavailable",
+		},
+		EvaluationFailureTest{
+
+			Name:      "Failed arguments",
+			Input:     "price(missing)",
+			Functions: functions,
+			Expected:  "No parameter 'missing' found",
+		},
+	}
+
+	runEvaluationFailureTests(failureTests, test)
+}
+
+func TestFunctionArgumentsAreCopied(test *testing.T) {
+
+	// returns the first item of a list, then changes it.
+	functions := map[string]ExpressionFunction{
+		"first": func(arguments ...interface{}) (interface{}, error) {
+
+			items := arguments[0].([]interface{})
+			ret := items[0]
+			items[0] = "changed"
+			return ret, nil
+		},
+	}
+
+	inputs := []string{
+		"first(items)",
+		"first(items, 'unused')",
+	}
+
+	for _, input := range inputs {
+
+		expression, _ := NewEvaluableExpressionWithFunctions(input, functions)
+		bound := expression.BindParameter("items", []interface{}{"original", "other"})
+
+		for i := 0; i < 2; i++ {
+
+			result, err := bound.Evaluate(nil)
+			if err != nil || result != "original" {
+				test.Logf("Expected evaluation %d of '%s' to be unaffected by the function changing its argument, got %v, %v", i+1, input, result, err)
+				test.Fail()
+			}
+		}
+
+		items := []interface{}{"original", "other"}
+		expression.Evaluate(map[string]interface{}{"items": items})
+
+		if items[0] != "original" {
+			test.Logf("Expected '%s' not to change the list passed as a parameter, got %v", input, items)
+			test.Fail()
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package govaluate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEvaluateFloatFast(test *testing.T) {
+
+	parameters := map[string]float64{
+		"a": 3,
+		"b": 4.5,
+		"c": -2,
+	}
+
+	fastInputs := []string{
+		"a + b * c",
+		"(a + b) * c / 2 - a % 2",
+		"a ** 2 + -b",
+		"a > b || b >= 4.5 && c != 0",
+		"!(a < b) == false",
+		"a > 1 ? b * 2 : c",
+		"a > 100 ? missing : c",
+		"false && missing > 1",
+		"1 + 2",
+		"true",
+	}
+
+	for _, input := range fastInputs {
+
+		expression, err := NewEvaluableExpression(input)
+		if err != nil {
+			test.Logf("Unable to parse '%s': %v", input, err)
+			test.Fail()
+			continue
+		}
+
+		if !expression.CanEvaluateFloatFast() {
+			test.Logf("Expected '%s' to use the fast path", input)
+			test.Fail()
+		}
+
+		assertSameAsEvaluate(expression, parameters, test)
+	}
+
+	// these all fall back to normal evaluation.
+	slowInputs := []string{
+		"a + 'x'",
+		"max(a, b)",
+		"a > 1 ? 'x' : c",
+		"a ?? b",
+		"a in (1, 2, 3)",
+		"a & 1",
+		"a > 1 ? 2",
+	}
+
+	functions := map[string]ExpressionFunction{
+		"max": func(arguments ...interface{}) (interface{}, error) {
+			return arguments[0], nil
+		},
+	}
+
+	for _, input := range slowInputs {
+
+		expression, err := NewEvaluableExpressionWithFunctions(input, functions)
+		if err != nil {
+			test.Logf("Unable to parse '%s': %v", input, err)
+			test.Fail()
+			continue
+		}
+
+		if expression.CanEvaluateFloatFast() {
+			test.Logf("Expected '%s' not to use the fast path", input)
+			test.Fail()
+		}
+
+		assertSameAsEvaluate(expression, parameters, test)
+	}
+
+	// options which change evaluation can't use the fast path.
+	expression, _ := NewEvaluableExpression("a / c")
+	expression.PrecisionMode = RoundResult
+
+	if expression.CanEvaluateFloatFast() {
+		test.Logf("Expected rounding to disable the fast path")
+		test.Fail()
+	}
+
+	result, err := expression.EvaluateFloatFast(parameters)
+	if err != nil || result != -2.0 {
+		test.Logf("Expected the rounded result -2, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	expression, _ = NewEvaluableExpression("a + missing")
+
+	_, err = expression.EvaluateFloatFast(parameters)
+	if err == nil || err.Error() != "No parameter 'missing' found." {
+		test.Logf("Expected an error for a missing parameter, got %v", err)
+		test.Fail()
+	}
+
+	// errors are located within the expression, just as they are by Evaluate.
+	_, expectedErr := expression.Evaluate(map[string]interface{}{"a": 3.0})
+
+	var located, expected *EvaluationError
+	if !errors.As(err, &located) || !errors.As(expectedErr, &expected) || located.Start != expected.Start || located.End != expected.End {
+		test.Logf("Expected the same *EvaluationError as Evaluate (%#v), got %#v", expectedErr, err)
+		test.Fail()
+	}
+}
+
+func assertSameAsEvaluate(expression *EvaluableExpression, parameters map[string]float64, test *testing.T) {
+
+	converted := make(map[string]interface{})
+	for name, value := range parameters {
+		converted[name] = value
+	}
+
+	expected, expectedErr := expression.Evaluate(converted)
+	actual, err := expression.EvaluateFloatFast(parameters)
+
+	if !reflect.DeepEqual(actual, expected) || (err == nil) != (expectedErr == nil) {
+		test.Logf("Expected '%s' to evaluate to %v (%v), got %v (%v)", expression.String(), expected, expectedErr, actual, err)
+		test.Fail()
+	}
+}
+
+func TestEvaluateFloatFastOptions(test *testing.T) {
+
+	parameters := map[string]float64{
+		"a": 1,
+		"b": 0,
+	}
+
+	// each option, on an expression whose result it could change.
+	options := []struct {
+		name      string
+		input     string
+		configure func(expression *EvaluableExpression)
+	}{
+		{"ChecksTypes", "a + 1", func(expression *EvaluableExpression) { expression.ChecksTypes = false }},
+		{"Observer", "a + 1", func(expression *EvaluableExpression) { expression.Observer = new(recordingObserver) }},
+		{"RoundDivision", "a / 3", func(expression *EvaluableExpression) {
+			expression.PrecisionMode = RoundDivision
+			expression.ResultPrecision = 2
+		}},
+		{"RoundResult", "a / 3", func(expression *EvaluableExpression) {
+			expression.PrecisionMode = RoundResult
+			expression.ResultPrecision = 2
+		}},
+		{"ChecksExponentOverflow", "10 ** 400 + a", func(expression *EvaluableExpression) { expression.ChecksExponentOverflow = true }},
+		{"ChecksShiftOverflow", "(1 << 64) + a", func(expression *EvaluableExpression) { expression.ChecksShiftOverflow = true }},
+		{"Truthiness", "a > 0 && b < 1", func(expression *EvaluableExpression) { expression.Truthiness = DefaultTruthiness }},
+		{"Coercer", "a + 1", func(expression *EvaluableExpression) {
+			expression.Coercer = func(name string, value interface{}) (interface{}, error) {
+				return value.(float64) * 2, nil
+			}
+		}},
+		{"NilComparisonsAreFalse", "a > b", func(expression *EvaluableExpression) { expression.NilComparisonsAreFalse = true }},
+		{"BytesAsStrings", "a == b", func(expression *EvaluableExpression) { expression.BytesAsStrings = true }},
+		{"RegexErrorHandler", "a + 1", func(expression *EvaluableExpression) {
+			expression.RegexErrorHandler = func(pattern string, err error) (bool, error) { return false, nil }
+		}},
+		{"IntWhenWhole", "a + 1", func(expression *EvaluableExpression) { expression.NumericResultKind = IntWhenWhole }},
+		{"AlwaysInt64", "a / 3", func(expression *EvaluableExpression) { expression.NumericResultKind = AlwaysInt64 }},
+		{"NormalizesNegativeZero", "-b", func(expression *EvaluableExpression) { expression.NormalizesNegativeZero = true }},
+		{"ExactRationals", "a / 3", func(expression *EvaluableExpression) { expression.ExactRationals = true }},
+		{"EqualityTolerance", "0.1 + 0.2 == 0.3 && a + 0.2 == 1.2", func(expression *EvaluableExpression) {
+			expression.EqualityTolerance = DefaultEqualityTolerance
+		}},
+		{"Collation", "('b' < 'a') && a > 0", func(expression *EvaluableExpression) {
+			expression.Collation = func(a, b string) int { return strings.Compare(b, a) }
+		}},
+		{"MaxRecursionDepth", "a + 1", func(expression *EvaluableExpression) { expression.MaxRecursionDepth = 1 }},
+		{"MaxStringBytes", "a + 1", func(expression *EvaluableExpression) { expression.MaxStringBytes = 1 }},
+		{"MaxCollectionSize", "a + 1", func(expression *EvaluableExpression) { expression.MaxCollectionSize = 1 }},
+		{"FunctionCache", "a + 1", func(expression *EvaluableExpression) { expression.FunctionCache = NewFunctionCache(10) }},
+		{"StreamWorkers", "a + 1", func(expression *EvaluableExpression) { expression.StreamWorkers = 4 }},
+	}
+
+	for _, option := range options {
+
+		expression, err := NewEvaluableExpression(option.input)
+		if err != nil {
+			test.Logf("Unable to parse '%s' for %s: %v", option.input, option.name, err)
+			test.Fail()
+			continue
+		}
+
+		option.configure(expression)
+		assertSameAsEvaluate(expression, parameters, test)
+	}
+}
@@ -0,0 +1,222 @@
+package govaluate
+
+import (
+	"container/list"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+/*
+	Holds the results of calls to pure functions (see `ParsingOptions.PureFunctions`), so that calling one again
+	with the same arguments returns the same result without calling the function.
+	Setting the same FunctionCache as the `FunctionCache` of many expressions lets them share results,
+	such as when several rules all use "expensive(x)". It's safe to use from many goroutines at once.
+
+	Results are found by the function's name and arguments, so every expression using the cache must mean the same function by the same name.
+	They're kept until `Clear` is called, or until the cache is full, when the least recently used are forgotten to make room.
+	Lists and maps are copied as they're cached and as they're returned, so changing a result doesn't change the cached one.
+*/
+type FunctionCache struct {
+	lock sync.Mutex
+
+	// each element holds a cachedResult, with the most recently used first.
+	recent     *list.List
+	elements   map[string]*list.Element
+	maxEntries int
+}
+
+type cachedResult struct {
+	key    string
+	result interface{}
+}
+
+/*
+	The most results which the cache used by a single evaluation (when its expression has no FunctionCache) holds.
+*/
+const maxEvaluationFunctionResults = 1024
+
+/*
+	Creates a cache which holds at most [maxEntries] results (and at least one).
+*/
+func NewFunctionCache(maxEntries int) *FunctionCache {
+
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+
+	return &FunctionCache{
+		recent:     list.New(),
+		elements:   make(map[string]*list.Element),
+		maxEntries: maxEntries,
+	}
+}
+
+/*
+	Forgets every result held by this cache, such as when the data the functions read has changed.
+*/
+func (this *FunctionCache) Clear() {
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.recent.Init()
+	this.elements = make(map[string]*list.Element)
+}
+
+/*
+	Returns the number of results held by this cache.
+*/
+func (this *FunctionCache) Len() int {
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.recent.Len()
+}
+
+func (this *FunctionCache) get(key string) (interface{}, bool) {
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	element, found := this.elements[key]
+	if !found {
+		return nil, false
+	}
+
+	this.recent.MoveToFront(element)
+	return copyCollection(element.Value.(cachedResult).result), true
+}
+
+func (this *FunctionCache) set(key string, result interface{}) {
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	result = copyCollection(result)
+
+	element, found := this.elements[key]
+	if found {
+		element.Value = cachedResult{key: key, result: result}
+		this.recent.MoveToFront(element)
+		return
+	}
+
+	if this.recent.Len() >= this.maxEntries {
+
+		oldest := this.recent.Back()
+		this.recent.Remove(oldest)
+		delete(this.elements, oldest.Value.(cachedResult).key)
+	}
+
+	this.elements[key] = this.recent.PushFront(cachedResult{key: key, result: result})
+}
+
+/*
+	Returns a copy of [value] if it's a slice or map, or [value] itself otherwise.
+	Only the slice or map itself is copied, not any slices or maps within it.
+*/
+func copyCollection(value interface{}) interface{} {
+
+	reflected := reflect.ValueOf(value)
+
+	switch reflected.Kind() {
+	case reflect.Slice:
+
+		if reflected.IsNil() {
+			return value
+		}
+
+		copied := reflect.MakeSlice(reflected.Type(), reflected.Len(), reflected.Len())
+		reflect.Copy(copied, reflected)
+		return copied.Interface()
+
+	case reflect.Map:
+
+		if reflected.IsNil() {
+			return value
+		}
+
+		copied := reflect.MakeMapWithSize(reflected.Type(), reflected.Len())
+		for _, key := range reflected.MapKeys() {
+			copied.SetMapIndex(key, reflected.MapIndex(key))
+		}
+		return copied.Interface()
+	}
+	return value
+}
+
+/*
+	Returns the key which a call to the function [name] with the given [arguments] (as given to its stage, written in the given [style]) is cached by,
+	or false if the call can't be cached because an argument isn't a number, string, bool, or nil.
+	Strings are quoted, so that (for instance) 1 and "1" are different.
+*/
+func functionCacheKey(name string, style argumentStyle, arguments interface{}) (string, bool) {
+
+	var ok bool
+
+	key := make([]byte, 0, len(name)+16)
+	key = append(key, name...)
+	key = append(key, '(')
+
+	switch style {
+	case argumentList:
+		for i, argument := range arguments.([]interface{}) {
+
+			if i > 0 {
+				key = append(key, ',')
+			}
+
+			key, ok = appendCacheKey(key, argument)
+			if !ok {
+				return "", false
+			}
+		}
+	case singleArgument:
+		key, ok = appendCacheKey(key, arguments)
+		if !ok {
+			return "", false
+		}
+	}
+	return string(append(key, ')')), true
+}
+
+func appendCacheKey(key []byte, argument interface{}) ([]byte, bool) {
+
+	switch argument := argument.(type) {
+	case nil:
+		return append(key, "nil"...), true
+	case float64:
+		return strconv.AppendFloat(key, argument, 'g', -1, 64), true
+	case string:
+		return strconv.AppendQuote(key, argument), true
+	case bool:
+		return strconv.AppendBool(key, argument), true
+	}
+	return key, false
+}
+
+/*
+	Marks every call within the given [stage] (and its children) to one of the given [pure] functions,
+	so that its results are cached. Returns true if any were marked.
+*/
+func markPureFunctions(stage *evaluationStage, pure []string) bool {
+
+	if stage == nil || len(pure) == 0 {
+		return false
+	}
+
+	marked := markPureFunctions(stage.leftStage, pure)
+	marked = markPureFunctions(stage.rightStage, pure) || marked
+
+	if stage.symbol == FUNCTIONAL && !stage.catchesErrors {
+		for _, name := range pure {
+			if stage.name == name {
+				stage.pure = true
+				marked = true
+			}
+		}
+	}
+	return marked
+}
@@ -0,0 +1,248 @@
+package govaluate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPureFunctions(test *testing.T) {
+
+	calls := 0
+	functions := map[string]ExpressionFunction{
+		"expensive": func(arguments ...interface{}) (interface{}, error) {
+			calls++
+			return arguments[0].(float64) * 2, nil
+		},
+		"failing": func(arguments ...interface{}) (interface{}, error) {
+			calls++
+			return nil, errors.New("failed")
+		},
+	}
+	options := ParsingOptions{PureFunctions: []string{"expensive", "failing"}}
+
+	expression, _ := NewEvaluableExpressionWithOptions("expensive(x) + expensive(x) + expensive(x + 1)", functions, options)
+
+	result, err := expression.Evaluate(map[string]interface{}{"x": 1})
+	if err != nil || result != 8.0 || calls != 2 {
+		test.Logf("Expected a pure function to be called once for each set of arguments, got %v, %v, after %d calls", result, err, calls)
+		test.Fail()
+	}
+
+	// without a FunctionCache, results are only kept for one evaluation.
+	calls = 0
+	expression.Evaluate(map[string]interface{}{"x": 1})
+	if calls != 2 {
+		test.Logf("Expected results not to be kept between evaluations, got %d calls", calls)
+		test.Fail()
+	}
+
+	// functions which aren't marked as pure are always called.
+	calls = 0
+	impure, _ := NewEvaluableExpressionWithFunctions("expensive(1) + expensive(1)", functions)
+	impure.Evaluate(nil)
+	if calls != 2 {
+		test.Logf("Expected an impure function to be called every time, got %d calls", calls)
+		test.Fail()
+	}
+
+	// errors aren't cached.
+	calls = 0
+	failing, _ := NewEvaluableExpressionWithOptions("try(failing(1), 0) + try(failing(1), 0)", functions, options)
+	failing.Evaluate(nil)
+	if calls != 2 {
+		test.Logf("Expected a failing function to be called every time, got %d calls", calls)
+		test.Fail()
+	}
+}
+
+func TestSharedFunctionCache(test *testing.T) {
+
+	calls := 0
+	functions := map[string]ExpressionFunction{
+		"expensive": func(arguments ...interface{}) (interface{}, error) {
+			calls++
+			return arguments[0].(string) + "!", nil
+		},
+	}
+	options := ParsingOptions{PureFunctions: []string{"expensive"}}
+	cache := NewFunctionCache(10)
+
+	first, _ := NewEvaluableExpressionWithOptions("expensive(name) == 'a!'", functions, options)
+	second, _ := NewEvaluableExpressionWithOptions("expensive(name) .. expensive('b')", functions, options)
+	first.FunctionCache = cache
+	second.FunctionCache = cache
+
+	parameters := map[string]interface{}{"name": "a"}
+
+	first.Evaluate(parameters)
+	result, err := second.Evaluate(parameters)
+	if err != nil || result != "a!b!" || calls != 2 {
+		test.Logf("Expected expressions to share results through their FunctionCache, got %v, %v, after %d calls", result, err, calls)
+		test.Fail()
+	}
+
+	second.Evaluate(parameters)
+	if calls != 2 || cache.Len() != 2 {
+		test.Logf("Expected results to be kept between evaluations, got %d calls and %d results", calls, cache.Len())
+		test.Fail()
+	}
+
+	cache.Clear()
+	first.Evaluate(parameters)
+	if calls != 3 {
+		test.Logf("Expected a cleared cache to call functions again, got %d calls", calls)
+		test.Fail()
+	}
+}
+
+func TestFunctionCacheLimits(test *testing.T) {
+
+	calls := 0
+	functions := map[string]ExpressionFunction{
+		"expensive": func(arguments ...interface{}) (interface{}, error) {
+			calls++
+			return strings.Repeat("a", int(arguments[0].(float64))), nil
+		},
+		"count": func(arguments ...interface{}) (interface{}, error) {
+			calls++
+			return float64(len(arguments[0].([]interface{}))), nil
+		},
+	}
+	options := ParsingOptions{PureFunctions: []string{"expensive", "count"}}
+	cache := NewFunctionCache(10)
+
+	unlimited, _ := NewEvaluableExpressionWithOptions("expensive(100)", functions, options)
+	limited, _ := NewEvaluableExpressionWithOptions("expensive(100)", functions, options)
+	unlimited.FunctionCache = cache
+	limited.FunctionCache = cache
+	limited.MaxStringBytes = 10
+
+	unlimited.Evaluate(nil)
+	_, err := limited.Evaluate(nil)
+	if !errors.Is(err, ErrStringBudgetExceeded) || calls != 1 {
+		test.Logf("Expected a cached result to count towards MaxStringBytes, got %v after %d calls", err, calls)
+		test.Fail()
+	}
+
+	// calls with arguments which aren't numbers, strings, bools, or nil aren't cached.
+	calls = 0
+	lists, _ := NewEvaluableExpressionWithOptions("count(items) + count(items)", functions, options)
+	lists.FunctionCache = cache
+
+	result, err := lists.Evaluate(map[string]interface{}{"items": []interface{}{1, 2}})
+	if err != nil || result != 4.0 || calls != 2 {
+		test.Logf("Expected a call with a list argument not to be cached, got %v, %v, after %d calls", result, err, calls)
+		test.Fail()
+	}
+}
+
+func TestFunctionCacheKey(test *testing.T) {
+
+	keys := map[string]bool{}
+	arguments := []interface{}{1.0, "1", true, "true", nil, "nil", "a,b"}
+
+	for _, argument := range arguments {
+
+		key, ok := functionCacheKey("f", singleArgument, argument)
+		if !ok {
+			test.Logf("Expected %#v to have a cache key", argument)
+			test.Fail()
+		}
+		keys[key] = true
+	}
+
+	key, _ := functionCacheKey("f", argumentList, []interface{}{"a", "b"})
+	keys[key] = true
+	key, _ = functionCacheKey("f", noArguments, nil)
+	keys[key] = true
+
+	if len(keys) != len(arguments)+2 {
+		test.Logf("Expected different arguments to have different keys, got %v", keys)
+		test.Fail()
+	}
+
+	_, ok := functionCacheKey("f", argumentList, []interface{}{1.0, map[string]interface{}{}})
+	if ok {
+		test.Logf("Expected a map argument not to be cached")
+		test.Fail()
+	}
+
+	_, ok = functionCacheKey("f", singleArgument, []interface{}{"a", "b"})
+	if ok {
+		test.Logf("Expected a list argument not to be cached")
+		test.Fail()
+	}
+}
+
+func TestFunctionCacheIsBounded(test *testing.T) {
+
+	calls := 0
+	functions := map[string]ExpressionFunction{
+		"expensive": func(arguments ...interface{}) (interface{}, error) {
+			calls++
+			return arguments[0], nil
+		},
+	}
+	options := ParsingOptions{PureFunctions: []string{"expensive"}}
+	cache := NewFunctionCache(2)
+
+	expression, _ := NewEvaluableExpressionWithOptions("expensive(x)", functions, options)
+	expression.FunctionCache = cache
+
+	for _, x := range []float64{1, 2, 3} {
+		expression.Evaluate(map[string]interface{}{"x": x})
+	}
+
+	if cache.Len() != 2 {
+		test.Logf("Expected the cache to hold at most 2 results, got %d", cache.Len())
+		test.Fail()
+	}
+
+	// the most recent results are kept, but the oldest was forgotten.
+	calls = 0
+	expression.Evaluate(map[string]interface{}{"x": 3})
+	expression.Evaluate(map[string]interface{}{"x": 1})
+
+	if calls != 1 {
+		test.Logf("Expected only the least recently used result to be forgotten, got %d calls", calls)
+		test.Fail()
+	}
+}
+
+func TestFunctionCacheCopiesResults(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"letters": func(arguments ...interface{}) (interface{}, error) {
+			return []interface{}{"a", "b"}, nil
+		},
+		"counts": func(arguments ...interface{}) (interface{}, error) {
+			return map[string]interface{}{"a": 1.0}, nil
+		},
+	}
+	options := ParsingOptions{PureFunctions: []string{"letters", "counts"}}
+	cache := NewFunctionCache(10)
+
+	letters, _ := NewEvaluableExpressionWithOptions("letters()", functions, options)
+	counts, _ := NewEvaluableExpressionWithOptions("counts()", functions, options)
+	letters.FunctionCache = cache
+	counts.FunctionCache = cache
+
+	for i := 0; i < 2; i++ {
+
+		list, _ := letters.Evaluate(nil)
+		if !reflect.DeepEqual(list, []interface{}{"a", "b"}) {
+			test.Logf("Expected a cached list not to be changed by its callers, got %v", list)
+			test.Fail()
+		}
+		list.([]interface{})[0] = "changed"
+
+		values, _ := counts.Evaluate(nil)
+		if !reflect.DeepEqual(values, map[string]interface{}{"a": 1.0}) {
+			test.Logf("Expected a cached map not to be changed by its callers, got %v", values)
+			test.Fail()
+		}
+		values.(map[string]interface{})["b"] = 2.0
+	}
+}
@@ -0,0 +1,364 @@
+package govaluate
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+/*
+	Finds the functions available to an expression, for built-ins which call other functions (like "reduce").
+	These are funcs, rather than methods, since built-ins can't refer to anything which refers back to them.
+*/
+type functionResolver struct {
+
+	// finds a function by its [name], returning false if there's no such function.
+	find func(name string) (ExpressionFunction, bool)
+
+	// compiles a sub-expression given in place of a function name (like "it > 5"). See `subExpressionFunction`.
+	compile func(expression string) (*EvaluableExpression, error)
+
+	// the evaluation which the built-in is being called as part of, if it's known.
+	outer *outerEvaluation
+}
+
+/*
+	Returns the function for this built-in, as used by an expression which was given the user-defined [functions].
+*/
+func (this builtinFunction) bind(functions map[string]ExpressionFunction) ExpressionFunction {
+
+	if this.makeFunction == nil {
+		return this.function
+	}
+	return this.makeFunction(newFunctionResolver(functions))
+}
+
+/*
+	Same as `bind`, except that the function is made for each call, given the evaluation which that call is part of,
+	so that any sub-expressions it's given are evaluated as part of that evaluation too.
+	Returns nil if this built-in doesn't call other functions.
+*/
+func (this builtinFunction) bindWithin(functions map[string]ExpressionFunction) func(outer *outerEvaluation) ExpressionFunction {
+
+	if this.makeFunction == nil {
+		return nil
+	}
+
+	resolve := newFunctionResolver(functions)
+
+	return func(outer *outerEvaluation) ExpressionFunction {
+
+		within := resolve
+		within.outer = outer
+		return this.makeFunction(within)
+	}
+}
+
+/*
+	The evaluation which a call to a built-in that calls other functions (like "map") is part of.
+	It's given to that call's operator as its Parameters, so that any sub-expressions the built-in is given
+	are evaluated with the same options, limits, and evaluationState as the rest of the expression.
+*/
+type outerEvaluation struct {
+	Parameters
+	expression EvaluableExpression
+}
+
+/*
+	Same as `makeFunctionStage`, except that [function] is made for each call, given the evaluation which the call is part of.
+	The stage has to be marked as `evaluatesSubExpressions` for its Parameters to be an outerEvaluation.
+*/
+func makeSubExpressionFunctionStage(function func(outer *outerEvaluation) ExpressionFunction, style argumentStyle) evaluationOperator {
+
+	return func(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+		outer, _ := parameters.(*outerEvaluation)
+		return callFunction(function(outer), style, right)
+	}
+}
+
+/*
+	The most sub-expressions which each expression keeps compiled. Sub-expressions can be built from parameters,
+	so there may be any number of them; once there are more than this, the least recently used are compiled again when needed.
+*/
+const maxCompiledSubExpressions = 64
+
+/*
+	Keeps the compiled forms of the most recently used sub-expressions (up to maxCompiledSubExpressions), for `newFunctionResolver`.
+	It's safe to use from many goroutines at once.
+*/
+type subExpressionCache struct {
+	lock sync.Mutex
+
+	// each element holds a compiledSubExpression, with the most recently used first.
+	recent   *list.List
+	elements map[string]*list.Element
+}
+
+type compiledSubExpression struct {
+	expression string
+	compiled   *EvaluableExpression
+}
+
+func newSubExpressionCache() *subExpressionCache {
+
+	return &subExpressionCache{
+		recent:   list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+/*
+	Returns the compiled form of the given sub-[expression], compiling it with [compile] if it isn't already kept.
+*/
+func (this *subExpressionCache) get(expression string, compile func(string) (*EvaluableExpression, error)) (*EvaluableExpression, error) {
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	element, found := this.elements[expression]
+	if found {
+		this.recent.MoveToFront(element)
+		return element.Value.(compiledSubExpression).compiled, nil
+	}
+
+	compiled, err := compile(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	if this.recent.Len() >= maxCompiledSubExpressions {
+
+		oldest := this.recent.Back()
+		this.recent.Remove(oldest)
+		delete(this.elements, oldest.Value.(compiledSubExpression).expression)
+	}
+
+	this.elements[expression] = this.recent.PushFront(compiledSubExpression{expression: expression, compiled: compiled})
+	return compiled, nil
+}
+
+/*
+	Creates a resolver which finds functions the same way an expression does; the user-defined [functions] first, then built-ins.
+	Sub-expressions are compiled the first time they're used, and the most recently used are kept (see `subExpressionCache`).
+*/
+func newFunctionResolver(functions map[string]ExpressionFunction) functionResolver {
+
+	expressions := newSubExpressionCache()
+
+	find := func(name string) (ExpressionFunction, bool) {
+
+		function, found := functions[name]
+		if found {
+			return function, true
+		}
+
+		builtin, found := builtinFunctions[name]
+		if found {
+			return builtin.bind(functions), true
+		}
+		return nil, false
+	}
+
+	// sub-expressions can use the same functions as the expression they're in, but none of its parameters.
+	compile := func(expression string) (*EvaluableExpression, error) {
+
+		return expressions.get(expression, func(expression string) (*EvaluableExpression, error) {
+			return NewEvaluableExpressionWithFunctions(expression, functions)
+		})
+	}
+
+	return functionResolver{find: find, compile: compile}
+}
+
+/*
+	Returns a function which evaluates the [compiled] sub-expression with its argument as the parameter "it".
+	When called with two arguments (as by "reduce"), the first is the parameter "acc", and the second is "it".
+
+	If the [outer] evaluation is known, the sub-expression is evaluated as part of it, with the same options and evaluationState,
+	so that (for instance) the strings it builds count towards the same `MaxStringBytes`, and a timeout stops it too.
+	Its comparisons and decisions aren't recorded, though, since they're within a different expression string.
+*/
+func subExpressionFunction(compiled *EvaluableExpression, outer *outerEvaluation) ExpressionFunction {
+
+	if outer == nil {
+		return func(arguments ...interface{}) (interface{}, error) {
+			return compiled.Evaluate(subExpressionParameters(arguments))
+		}
+	}
+
+	inner := outer.expression
+	inner.tokens = compiled.tokens
+	inner.evaluationStages = compiled.evaluationStages
+	inner.inputExpression = compiled.inputExpression
+	inner.parameterSlots = compiled.parameterSlots
+	inner.fastFloat = compiled.fastFloat
+	inner.callsPureFunctions = compiled.callsPureFunctions
+	inner.isSubExpression = true
+
+	return func(arguments ...interface{}) (interface{}, error) {
+
+		parameters := &sanitizedParameters{orig: MapParameters(subExpressionParameters(arguments))}
+		return inner.evaluateStage(inner.evaluationStages, parameters)
+	}
+}
+
+func subExpressionParameters(arguments []interface{}) map[string]interface{} {
+
+	parameters := make(map[string]interface{}, 2)
+	if len(arguments) > 0 {
+		parameters["it"] = arguments[len(arguments)-1]
+	}
+	if len(arguments) > 1 {
+		parameters["acc"] = arguments[0]
+	}
+	return parameters
+}
+
+/*
+	Finds the function which a higher-order built-in (called [name]) should call, given its [argument].
+	The argument may be the name of a function, a sub-expression (see `subExpressionFunction`), or a function itself (such as from a parameter).
+*/
+func resolveFunctionArgument(name string, argument interface{}, resolve functionResolver) (ExpressionFunction, error) {
+
+	switch argument.(type) {
+	case ExpressionFunction:
+		return argument.(ExpressionFunction), nil
+	case func(...interface{}) (interface{}, error):
+		return argument.(func(...interface{}) (interface{}, error)), nil
+	case string:
+
+		function, found := resolve.find(argument.(string))
+		if found {
+			return function, nil
+		}
+
+		if isFunctionName(argument.(string)) {
+			return nil, fmt.Errorf("Function '%s' was given an unknown function '%s'", name, argument)
+		}
+
+		compiled, err := resolve.compile(argument.(string))
+		if err != nil {
+			return nil, fmt.Errorf("Function '%s' was given an invalid expression '%s': %w", name, argument, err)
+		}
+		return subExpressionFunction(compiled, resolve.outer), nil
+	}
+
+	return nil, fmt.Errorf("Function '%s' expects the name of a function, got '%v'", name, argument)
+}
+
+/*
+	Returns true if the given [value] could only be the name of a function, rather than a sub-expression.
+*/
+func isFunctionName(value string) bool {
+
+	for _, character := range value {
+		if !isVariableName(character) || character == '.' {
+			return false
+		}
+	}
+	return value != ""
+}
+
+func makeReduceFunction(resolve functionResolver) ExpressionFunction {
+
+	return func(arguments ...interface{}) (interface{}, error) {
+
+		err := checkArgumentCount("reduce", arguments, 3)
+		if err != nil {
+			return nil, err
+		}
+
+		items, ok := collectionElements(arguments[0])
+		if !ok {
+			return nil, fmt.Errorf("Function 'reduce' expects a list of items, got '%v'", arguments[0])
+		}
+
+		function, err := resolveFunctionArgument("reduce", arguments[1], resolve)
+		if err != nil {
+			return nil, err
+		}
+
+		accumulator := arguments[2]
+		for i, item := range items {
+
+			accumulator, err = function(accumulator, item)
+			if err != nil {
+				return nil, fmt.Errorf("Function 'reduce' failed at index %d: %w", i, err)
+			}
+		}
+		return accumulator, nil
+	}
+}
+
+func makeMapFunction(resolve functionResolver) ExpressionFunction {
+
+	return func(arguments ...interface{}) (interface{}, error) {
+
+		items, function, err := readHigherOrderArguments("map", arguments, resolve)
+		if err != nil {
+			return nil, err
+		}
+
+		ret := make([]interface{}, len(items))
+		for i, item := range items {
+
+			ret[i], err = function(item)
+			if err != nil {
+				return nil, fmt.Errorf("Function 'map' failed at index %d: %w", i, err)
+			}
+		}
+		return ret, nil
+	}
+}
+
+func makeFilterFunction(resolve functionResolver) ExpressionFunction {
+
+	return func(arguments ...interface{}) (interface{}, error) {
+
+		items, predicate, err := readHigherOrderArguments("filter", arguments, resolve)
+		if err != nil {
+			return nil, err
+		}
+
+		ret := make([]interface{}, 0)
+		for i, item := range items {
+
+			matches, err := predicate(item)
+			if err != nil {
+				return nil, fmt.Errorf("Function 'filter' failed at index %d: %w", i, err)
+			}
+
+			if !isBool(matches) {
+				return nil, fmt.Errorf("Function 'filter' failed at index %d: predicate returned '%v', which is not a bool", i, matches)
+			}
+
+			if matches.(bool) {
+				ret = append(ret, item)
+			}
+		}
+		return ret, nil
+	}
+}
+
+/*
+	Reads the arguments to a higher-order built-in (called [name]) which takes a list of items and a function to call on each.
+*/
+func readHigherOrderArguments(name string, arguments []interface{}, resolve functionResolver) ([]interface{}, ExpressionFunction, error) {
+
+	err := checkArgumentCount(name, arguments, 2)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items, ok := collectionElements(arguments[0])
+	if !ok {
+		return nil, nil, fmt.Errorf("Function '%s' expects a list of items, got '%v'", name, arguments[0])
+	}
+
+	function, err := resolveFunctionArgument(name, arguments[1], resolve)
+	if err != nil {
+		return nil, nil, err
+	}
+	return items, function, nil
+}
@@ -0,0 +1,464 @@
+package govaluate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+var errNegativeNumber = errors.New("negative number")
+
+var higherOrderTestFunctions = map[string]ExpressionFunction{
+	"add": func(arguments ...interface{}) (interface{}, error) {
+		return arguments[0].(float64) + arguments[1].(float64), nil
+	},
+	"join": func(arguments ...interface{}) (interface{}, error) {
+		return arguments[0].(string) + arguments[1].(string), nil
+	},
+	"positive": func(arguments ...interface{}) (interface{}, error) {
+
+		if arguments[len(arguments)-1].(float64) < 0 {
+			return nil, errNegativeNumber
+		}
+		return arguments[len(arguments)-1], nil
+	},
+	"double": func(arguments ...interface{}) (interface{}, error) {
+		return arguments[0].(float64) * 2, nil
+	},
+	"isEven": func(arguments ...interface{}) (interface{}, error) {
+		return int(arguments[0].(float64))%2 == 0, nil
+	},
+}
+
+func TestReduce(test *testing.T) {
+
+	evaluationTests := []EvaluationTest{
+
+		EvaluationTest{
+
+			Name:      "reduce with a user-defined function",
+			Input:     "reduce(items, 'add', 0)",
+			Functions: higherOrderTestFunctions,
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "items",
+					Value: []int{1, 2, 3},
+				},
+			},
+			Expected: 6.0,
+		},
+		EvaluationTest{
+
+			Name:      "reduce over a split string",
+			Input:     "reduce(split('a,b,c', ','), 'join', '>')",
+			Functions: higherOrderTestFunctions,
+			Expected:  ">abc",
+		},
+		EvaluationTest{
+
+			Name:  "reduce with a built-in function",
+			Input: "reduce(items, 'ifnull', none)",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "none",
+					Value: nil,
+				},
+				EvaluationParameter{
+					Name:  "items",
+					Value: []interface{}{nil, "first", "second"},
+				},
+			},
+			Expected: "first",
+		},
+		EvaluationTest{
+
+			Name:  "reduce with a function parameter",
+			Input: "reduce(items, add, 10)",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "items",
+					Value: [2]float64{1, 2},
+				},
+				EvaluationParameter{
+					Name:  "add",
+					Value: higherOrderTestFunctions["add"],
+				},
+			},
+			Expected: 13.0,
+		},
+		EvaluationTest{
+
+			Name:      "reduce over an empty list",
+			Input:     "reduce(items, 'add', 'initial')",
+			Functions: higherOrderTestFunctions,
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "items",
+					Value: []interface{}{},
+				},
+			},
+			Expected: "initial",
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+
+	failureTests := []EvaluationFailureTest{
+
+		EvaluationFailureTest{
+
+			Name:      "reduce with an unknown function",
+			Input:     "reduce(items, 'missing', 0)",
+			Functions: higherOrderTestFunctions,
+			Parameters: map[string]interface{}{
+				"items": []interface{}{1.0},
+			},
+			Expected: "Function 'reduce' was given an unknown function 'missing'",
+		},
+		EvaluationFailureTest{
+
+			Name:      "reduce over a number",
+			Input:     "reduce(1, 'add', 0)",
+			Functions: higherOrderTestFunctions,
+			Expected:  "expects a list of items, got '1'",
+		},
+		EvaluationFailureTest{
+
+			Name:      "reduce with a failing function",
+			Input:     "reduce(items, 'positive', 0)",
+			Functions: higherOrderTestFunctions,
+			Parameters: map[string]interface{}{
+				"items": []interface{}{1.0, 2.0, -3.0},
+			},
+			Expected: "Function 'reduce' failed at index 2: negative number",
+		},
+	}
+
+	runEvaluationFailureTests(failureTests, test)
+
+	// the function's own error is kept, so callers can still check for it.
+	expression, _ := NewEvaluableExpressionWithFunctions("reduce(items, 'positive', 0)", higherOrderTestFunctions)
+
+	_, err := expression.Evaluate(map[string]interface{}{"items": []interface{}{-1.0}})
+	if !errors.Is(err, errNegativeNumber) {
+		test.Logf("Expected reduce to wrap the error of its function, got %v", err)
+		test.Fail()
+	}
+}
+
+func TestMapAndFilter(test *testing.T) {
+
+	evaluationTests := []EvaluationTest{
+
+		EvaluationTest{
+
+			Name:      "map then reduce",
+			Input:     "reduce(map(items, 'double'), 'add', 0)",
+			Functions: higherOrderTestFunctions,
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "items",
+					Value: []int{1, 2, 3},
+				},
+			},
+			Expected: 12.0,
+		},
+		EvaluationTest{
+
+			Name:      "filter then reduce",
+			Input:     "reduce(filter(items, 'isEven'), 'add', 0)",
+			Functions: higherOrderTestFunctions,
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "items",
+					Value: []int{1, 2, 3, 4},
+				},
+			},
+			Expected: 6.0,
+		},
+		EvaluationTest{
+
+			Name:  "map with a built-in function",
+			Input: "'FOO' in map(split(names, ','), 'upper')",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "names",
+					Value: "bar,foo",
+				},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:      "filter with no matches",
+			Input:     "lenCompare(filter(items, 'isEven'), '')",
+			Functions: higherOrderTestFunctions,
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "items",
+					Value: []float64{1, 3},
+				},
+			},
+			Expected: 0.0,
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+
+	failureTests := []EvaluationFailureTest{
+
+		EvaluationFailureTest{
+
+			Name:      "map with a failing function",
+			Input:     "map(items, 'positive')",
+			Functions: higherOrderTestFunctions,
+			Parameters: map[string]interface{}{
+				"items": []interface{}{1.0, -2.0},
+			},
+			Expected: "Function 'map' failed at index 1: negative number",
+		},
+		EvaluationFailureTest{
+
+			Name:      "filter with a predicate which doesn't return a bool",
+			Input:     "filter(items, 'double')",
+			Functions: higherOrderTestFunctions,
+			Parameters: map[string]interface{}{
+				"items": []interface{}{1.0},
+			},
+			Expected: "Function 'filter' failed at index 0: predicate returned '2', which is not a bool",
+		},
+		EvaluationFailureTest{
+
+			Name:     "filter with a number as the predicate",
+			Input:    "filter(items, 1)",
+			Expected: "Function 'filter' expects the name of a function, got '1'",
+			Parameters: map[string]interface{}{
+				"items": []interface{}{1.0},
+			},
+		},
+		EvaluationFailureTest{
+
+			Name:     "map without a function",
+			Input:    "map(items)",
+			Expected: "Function 'map' expects 2 arguments, got 1",
+			Parameters: map[string]interface{}{
+				"items": []interface{}{1.0},
+			},
+		},
+	}
+
+	runEvaluationFailureTests(failureTests, test)
+
+	// the function's own error is kept, so callers can still check for it.
+	parameters := map[string]interface{}{"items": []interface{}{-1.0}}
+
+	for _, input := range []string{"map(items, 'positive')", "filter(items, 'positive')"} {
+
+		expression, _ := NewEvaluableExpressionWithFunctions(input, higherOrderTestFunctions)
+
+		_, err := expression.Evaluate(parameters)
+		if !errors.Is(err, errNegativeNumber) {
+			test.Logf("Expected '%s' to wrap the error of its function, got %v", input, err)
+			test.Fail()
+		}
+	}
+}
+
+func TestSubExpressionArguments(test *testing.T) {
+
+	evaluationTests := []EvaluationTest{
+
+		EvaluationTest{
+
+			Name:      "filter with a sub-expression",
+			Input:     "reduce(filter(items, \"it > 2\"), 'add', 0)",
+			Functions: higherOrderTestFunctions,
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "items",
+					Value: []int{1, 2, 3, 4},
+				},
+			},
+			Expected: 7.0,
+		},
+		EvaluationTest{
+
+			Name:      "map with a sub-expression using a function",
+			Input:     "reduce(map(items, 'double(it) + 1'), 'add', 0)",
+			Functions: higherOrderTestFunctions,
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "items",
+					Value: []float64{1, 2},
+				},
+			},
+			Expected: 8.0,
+		},
+		EvaluationTest{
+
+			Name:  "reduce with a sub-expression",
+			Input: "reduce(items, 'acc .. it', '')",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "items",
+					Value: []string{"a", "b", "c"},
+				},
+			},
+			Expected: "abc",
+		},
+		EvaluationTest{
+
+			Name:  "filter with a sub-expression using an accessor",
+			Input: "reduce(map(filter(users, 'it.Age >= 18'), 'it.Name'), 'acc .. it', '')",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name: "users",
+					Value: []struct {
+						Name string
+						Age  int
+					}{{"alice", 30}, {"bob", 12}},
+				},
+			},
+			Expected: "alice",
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+
+	failureTests := []EvaluationFailureTest{
+
+		EvaluationFailureTest{
+
+			Name:     "filter with an invalid sub-expression",
+			Input:    "filter(items, 'it >')",
+			Expected: "Function 'filter' was given an invalid expression 'it >'",
+			Parameters: map[string]interface{}{
+				"items": []interface{}{1.0},
+			},
+		},
+		EvaluationFailureTest{
+
+			Name:     "filter with a sub-expression using an outer parameter",
+			Input:    "filter(items, 'it > limit')",
+			Expected: "No parameter 'limit' found.",
+			Parameters: map[string]interface{}{
+				"items": []interface{}{1.0},
+				"limit": 0.0,
+			},
+		},
+	}
+
+	runEvaluationFailureTests(failureTests, test)
+}
+
+func TestSubExpressionCacheIsBounded(test *testing.T) {
+
+	cache := newSubExpressionCache()
+	compiled := 0
+
+	compile := func(expression string) (*EvaluableExpression, error) {
+		compiled++
+		return NewEvaluableExpression(expression)
+	}
+
+	// sub-expressions built from parameters can all be different.
+	for i := 0; i <= maxCompiledSubExpressions; i++ {
+
+		expression, err := cache.get(fmt.Sprintf("it > %d", i), compile)
+		if err != nil {
+			test.Fatalf("Unexpected error compiling a sub-expression: %v", err)
+		}
+
+		result, err := subExpressionFunction(expression, nil)(float64(i))
+		if err != nil || result != false {
+			test.Logf("Expected 'it > %d' to be false for %d, got %v, %v", i, i, result, err)
+			test.Fail()
+		}
+	}
+
+	if cache.recent.Len() != maxCompiledSubExpressions || len(cache.elements) != maxCompiledSubExpressions {
+		test.Logf("Expected at most %d sub-expressions to be kept, got %d", maxCompiledSubExpressions, cache.recent.Len())
+		test.Fail()
+	}
+
+	// the most recently used are kept, but the oldest has been forgotten, and is compiled again.
+	compiled = 0
+	cache.get(fmt.Sprintf("it > %d", maxCompiledSubExpressions), compile)
+
+	if compiled != 0 {
+		test.Logf("Expected a recently used sub-expression to be kept")
+		test.Fail()
+	}
+
+	expression, _ := cache.get("it > 0", compile)
+
+	if compiled != 1 {
+		test.Logf("Expected the least recently used sub-expression to have been forgotten")
+		test.Fail()
+	}
+
+	result, err := subExpressionFunction(expression, nil)(1.0)
+	if err != nil || result != true {
+		test.Logf("Expected a recompiled sub-expression to work, got %v, %v", result, err)
+		test.Fail()
+	}
+}
+
+func TestSubExpressionsUseOuterEvaluation(test *testing.T) {
+
+	// each step doubles the accumulated string, so only a limit checked within the sub-expression stops it early.
+	expression, _ := NewEvaluableExpression("reduce(split(s, ''), 'acc + acc', 'x')")
+	expression.MaxStringBytes = 1000
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	_, err := expression.Evaluate(map[string]interface{}{"s": "abcdefghijklmnopqrstuvwxyz"})
+
+	runtime.ReadMemStats(&after)
+
+	if !errors.Is(err, ErrStringBudgetExceeded) {
+		test.Logf("Expected MaxStringBytes to apply within a sub-expression, got %v", err)
+		test.Fail()
+	}
+
+	if after.TotalAlloc-before.TotalAlloc > 1<<20 {
+		test.Logf("Expected the limit to stop the sub-expression before it built large strings, but %d bytes were allocated", after.TotalAlloc-before.TotalAlloc)
+		test.Fail()
+	}
+
+	// other options apply too.
+	expression, _ = NewEvaluableExpression("map(items, 'it / 3')")
+	expression.PrecisionMode = RoundDivision
+	expression.ResultPrecision = 2
+
+	result, err := expression.Evaluate(map[string]interface{}{"items": []interface{}{1, 2}})
+	if err != nil || !reflect.DeepEqual(result, []interface{}{0.33, 0.67}) {
+		test.Logf("Expected the options of the outer expression to apply within a sub-expression, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	// as does cancellation.
+	expression, _ = NewEvaluableExpressionWithFunctions("map(items, 'slow(it)')", map[string]ExpressionFunction{
+		"slow": func(arguments ...interface{}) (interface{}, error) {
+			time.Sleep(10 * time.Millisecond)
+			return arguments[0], nil
+		},
+	})
+
+	items := make([]interface{}, 100)
+	for i := range items {
+		items[i] = float64(i)
+	}
+
+	start := time.Now()
+	_, err = expression.EvaluateWithTimeout(map[string]interface{}{"items": items}, 50*time.Millisecond)
+
+	if err == nil || time.Since(start) > 500*time.Millisecond {
+		test.Logf("Expected a timeout to stop a sub-expression, got %v after %v", err, time.Since(start))
+		test.Fail()
+	}
+}
@@ -0,0 +1,57 @@
+package govaluate
+
+import (
+	"sync"
+)
+
+/*
+	StringInterner is a table of strings which lets many expressions share a single copy of each string literal they contain,
+	used with `ParsingOptions.Interner`. This can save a good deal of memory when many expressions with the same literals
+	are kept around at once (such as a large set of rules).
+
+	The zero value is an empty table, ready to use. It is safe for concurrent use, and must not be copied after first use.
+	Strings are never removed from the table, so it should be discarded along with the expressions which use it.
+*/
+type StringInterner struct {
+	strings sync.Map
+}
+
+/*
+	Returns the copy of [value] held by this table, adding [value] to the table if it isn't already present.
+*/
+func (this *StringInterner) Intern(value string) string {
+
+	existing, _ := this.strings.LoadOrStore(value, value)
+	return existing.(string)
+}
+
+/*
+	Returns the number of distinct strings held by this table.
+*/
+func (this *StringInterner) Len() int {
+
+	count := 0
+	this.strings.Range(func(key, value interface{}) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+/*
+	Replaces the value of each string literal in [tokens] with its copy from the [interner].
+*/
+func internTokens(tokens []ExpressionToken, interner *StringInterner) {
+
+	for i, token := range tokens {
+
+		if token.Kind != STRING {
+			continue
+		}
+
+		value, ok := token.Value.(string)
+		if ok {
+			tokens[i].Value = interner.Intern(value)
+		}
+	}
+}
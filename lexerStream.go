@@ -4,20 +4,43 @@ type lexerStream struct {
 	source   []rune
 	position int
 	length   int
+
+	// the byte offset in the original string of each rune in [source], plus one for the end of the string.
+	offsets []int
+
+	// the kind of each clause which is open at the current position, innermost last.
+	openClauses []clauseKind
+
+	// the kind of the clause which was closed most recently.
+	lastClosed clauseKind
 }
 
+/*
+	The kinds of clause which the lexer tells apart, so that a bracket after a function call can be read as an index into its result.
+*/
+type clauseKind int
+
+const (
+	groupClause clauseKind = iota
+	argumentsClause
+	indexClause
+)
+
 func newLexerStream(source string) *lexerStream {
 
 	var ret *lexerStream
 	var runes []rune
+	var offsets []int
 
-	for _, character := range source {
+	for offset, character := range source {
 		runes = append(runes, character)
+		offsets = append(offsets, offset)
 	}
 
 	ret = new(lexerStream)
 	ret.source = runes
 	ret.length = len(runes)
+	ret.offsets = append(offsets, len(source))
 	return ret
 }
 
@@ -34,6 +57,43 @@ func (this *lexerStream) rewind(amount int) {
 	this.position -= amount
 }
 
+/*
+	Records that a clause of the given [kind] was opened at the current position.
+*/
+func (this *lexerStream) openClause(kind clauseKind) {
+	this.openClauses = append(this.openClauses, kind)
+}
+
+/*
+	Records that the innermost open clause was closed at the current position.
+*/
+func (this *lexerStream) closeClause() {
+
+	this.lastClosed = this.innermostClause()
+
+	if len(this.openClauses) > 0 {
+		this.openClauses = this.openClauses[:len(this.openClauses)-1]
+	}
+}
+
+/*
+	Returns the kind of the innermost clause which is open at the current position, or groupClause if there isn't one.
+*/
+func (this lexerStream) innermostClause() clauseKind {
+
+	if len(this.openClauses) == 0 {
+		return groupClause
+	}
+	return this.openClauses[len(this.openClauses)-1]
+}
+
 func (this lexerStream) canRead() bool {
 	return this.position < this.length
 }
+
+/*
+	Returns the byte offset in the original string of the rune at [position].
+*/
+func (this lexerStream) byteOffset(position int) int {
+	return this.offsets[position]
+}
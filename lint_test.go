@@ -0,0 +1,118 @@
+package govaluate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintCollectsAllErrors(test *testing.T) {
+
+	expression, err := NewEvaluableExpression("('x' - 1 > 0) && (foo * 2 > 0) && (unknown > 3 || bar =~ pattern)")
+	if err != nil {
+		test.Logf("Unable to parse expression: %v", err)
+		test.Fail()
+		return
+	}
+
+	parameters := map[string]interface{}{
+		"foo":     "not a number",
+		"bar":     "string",
+		"pattern": "[",
+	}
+
+	errs := expression.Lint(parameters)
+
+	expected := []string{
+		"Value 'x' cannot be used with the modifier '-'",
+		"Value 'not a number' cannot be used with the modifier '*'",
+		"Unable to compile regexp pattern",
+	}
+
+	if len(errs) != len(expected) {
+		test.Logf("Expected %d errors, got %d: %v", len(expected), len(errs), errs)
+		test.Fail()
+		return
+	}
+
+	for i, err := range errs {
+		if !strings.Contains(err.Error(), expected[i]) {
+			test.Logf("Expected error %d to contain '%s', got '%v'", i, expected[i], err)
+			test.Fail()
+		}
+	}
+}
+
+func TestLintValidExpressions(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"length": func(arguments ...interface{}) (interface{}, error) {
+			return float64(len(arguments[0].(string))), nil
+		},
+	}
+
+	expression, _ := NewEvaluableExpressionWithFunctions("length(name) > 3 && foo.Bar > 1 && (missing ? 1 : 2) < count", functions)
+
+	errs := expression.Lint(map[string]interface{}{"name": 5, "count": 10})
+	if errs != nil {
+		test.Logf("Expected no errors from an expression whose errors depend on unknown values, got %v", errs)
+		test.Fail()
+	}
+
+	errs = expression.Lint(nil)
+	if errs != nil {
+		test.Logf("Expected no errors without parameters, got %v", errs)
+		test.Fail()
+	}
+}
+
+func TestLintDeadLogic(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("(x > 5 || true) && (a && (1 > 2)) && (false ? b : c) && (b || c)")
+
+	expected := []string{
+		"x > 5 || true",
+		"a && (1 > 2)",
+		"false ? b",
+	}
+
+	findings := expression.LintDeadLogic()
+	if len(findings) != len(expected) {
+		test.Logf("Expected %d findings, got %d: %v", len(expected), len(findings), findings)
+		test.Fail()
+		return
+	}
+
+	for i, finding := range findings {
+
+		actual := expression.String()[finding.Start:finding.End]
+		if actual != expected[i] {
+			test.Logf("Expected finding %d to be about '%s', got '%s' (%s)", i, expected[i], actual, finding.Message)
+			test.Fail()
+		}
+	}
+
+	// literals calculated at parse time are still found.
+	expression, _ = NewEvaluableExpression("'x' ?? y")
+
+	findings = expression.LintDeadLogic()
+	if len(findings) != 1 || findings[0].Message != "Null coalescence always returns its left side, since it is a literal" {
+		test.Logf("Expected one finding for a literal null coalescence, got %v", findings)
+		test.Fail()
+	}
+
+	expression, _ = NewEvaluableExpression("true || false")
+
+	findings = expression.LintDeadLogic()
+	if len(findings) != 1 {
+		test.Logf("Expected one finding for an elided literal, got %v", findings)
+		test.Fail()
+	}
+
+	expression, _ = NewEvaluableExpression("(a && b) || c ? (x && true) : false")
+
+	findings = expression.LintDeadLogic()
+	if findings != nil {
+		test.Logf("Expected no findings, got %v", findings)
+		test.Fail()
+	}
+}
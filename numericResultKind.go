@@ -0,0 +1,63 @@
+package govaluate
+
+import (
+	"fmt"
+	"math"
+)
+
+/*
+	Determines the type of the numbers returned by evaluating an EvaluableExpression.
+	Numbers are always float64 while an expression is being evaluated; this only changes the final result.
+*/
+type NumericResultKind int
+
+const (
+	/*
+		Numeric results are returned as float64. This is the default.
+	*/
+	AlwaysFloat64 NumericResultKind = iota
+
+	/*
+		Numeric results which are whole numbers (and fit in an int) are returned as an int.
+		Any other numeric results are returned as float64.
+	*/
+	IntWhenWhole
+
+	/*
+		Numeric results are always returned as int64, with any fractional part truncated (as Go conversions do).
+		Results which can't be represented as an int64 (such as NaN, or anything too large) are an error.
+	*/
+	AlwaysInt64
+)
+
+// the range of an int, written out since math.MaxInt and math.MinInt need Go 1.17.
+const (
+	maxInt = int(^uint(0) >> 1)
+	minInt = -maxInt - 1
+)
+
+/*
+	Converts the given [value] to the given [kind], if it is a number. Any other value is returned unchanged.
+*/
+func convertNumericResult(value interface{}, kind NumericResultKind) (interface{}, error) {
+
+	number, ok := value.(float64)
+	if !ok {
+		return value, nil
+	}
+
+	switch kind {
+	case IntWhenWhole:
+		if number == math.Trunc(number) && number >= float64(minInt) && number < float64(maxInt) {
+			return int(number), nil
+		}
+	case AlwaysInt64:
+
+		// as a float64, math.MaxInt64 rounds up to 2^63, which doesn't fit.
+		if math.IsNaN(number) || number < math.MinInt64 || number >= math.MaxInt64 {
+			return nil, fmt.Errorf("Result '%v' cannot be represented as an int64", number)
+		}
+		return int64(number), nil
+	}
+	return number, nil
+}
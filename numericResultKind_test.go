@@ -0,0 +1,101 @@
+package govaluate
+
+import (
+	"math"
+	"testing"
+)
+
+type resultKindTest struct {
+	name     string
+	input    string
+	kind     NumericResultKind
+	expected interface{}
+}
+
+func TestNumericResultKind(test *testing.T) {
+
+	resultKindTests := []resultKindTest{
+		resultKindTest{
+			name:     "float64 by default",
+			input:    "6 / 2",
+			expected: 3.0,
+		},
+		resultKindTest{
+			name:     "Whole number as int",
+			input:    "6 / 2",
+			kind:     IntWhenWhole,
+			expected: 3,
+		},
+		resultKindTest{
+			name:     "Fraction stays float64",
+			input:    "5 / 2",
+			kind:     IntWhenWhole,
+			expected: 2.5,
+		},
+		resultKindTest{
+			name:     "Negative whole number as int",
+			input:    "-4",
+			kind:     IntWhenWhole,
+			expected: -4,
+		},
+		resultKindTest{
+			name:     "Whole number as int64",
+			input:    "6 / 2",
+			kind:     AlwaysInt64,
+			expected: int64(3),
+		},
+		resultKindTest{
+			name:     "Fraction truncated to int64",
+			input:    "-5 / 2",
+			kind:     AlwaysInt64,
+			expected: int64(-2),
+		},
+		resultKindTest{
+			name:     "Non-numeric results are unchanged",
+			input:    "1 > 0",
+			kind:     AlwaysInt64,
+			expected: true,
+		},
+	}
+
+	for _, resultKindTest := range resultKindTests {
+
+		expression, err := NewEvaluableExpression(resultKindTest.input)
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %v", resultKindTest.name, err)
+			test.Fail()
+			continue
+		}
+
+		expression.NumericResultKind = resultKindTest.kind
+
+		result, err := expression.Evaluate(nil)
+		if err != nil {
+			test.Logf("Test '%s' failed: %v", resultKindTest.name, err)
+			test.Fail()
+			continue
+		}
+
+		if result != resultKindTest.expected {
+			test.Logf("Test '%s' expected '%v' (%T), got '%v' (%T)", resultKindTest.name, resultKindTest.expected, resultKindTest.expected, result, result)
+			test.Fail()
+		}
+	}
+
+	expression, _ := NewEvaluableExpression("10 ** 400")
+	expression.NumericResultKind = AlwaysInt64
+
+	_, err := expression.Evaluate(nil)
+	if err == nil {
+		test.Logf("Expected an error when the result can't be an int64")
+		test.Fail()
+	}
+
+	expression.NumericResultKind = IntWhenWhole
+
+	result, err := expression.Evaluate(nil)
+	if err != nil || result != math.Inf(1) {
+		test.Logf("Expected an infinite result to stay a float64, got %v (%T), %v", result, result, err)
+		test.Fail()
+	}
+}
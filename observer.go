@@ -0,0 +1,61 @@
+package govaluate
+
+import (
+	"time"
+)
+
+/*
+	Observer receives callbacks while an EvaluableExpression is evaluated, which is useful for gathering metrics
+	such as how long each expression takes, or how often a given function or parameter is used.
+
+	Callbacks are made synchronously, on the goroutine which is evaluating the expression.
+	If an expression's Observer is nil (the default) no timing is done at all.
+*/
+type Observer interface {
+
+	/*
+		Called immediately before an [expression] begins evaluating.
+	*/
+	EvaluationStarted(expression string)
+
+	/*
+		Called after an [expression] finished evaluating, with the time it took and the error it returned, if any.
+	*/
+	EvaluationFinished(expression string, elapsed time.Duration, err error)
+
+	/*
+		Called after each function call made by an expression, with the time it took and the error it returned, if any.
+		Expressions built from tokens (rather than parsed from a string) don't know the names of their functions,
+		and will report an empty [name].
+	*/
+	FunctionCalled(name string, elapsed time.Duration, err error)
+
+	/*
+		Called each time an expression reads a parameter (or a field/method of one).
+	*/
+	ParameterAccessed(name string)
+}
+
+/*
+	Runs the operator for a single [stage], notifying this expression's Observer about function calls and parameter access.
+*/
+func (this EvaluableExpression) observeStage(stage *evaluationStage, left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+	switch stage.symbol {
+
+	case FUNCTIONAL:
+
+		start := time.Now()
+		result, err := stage.operator(left, right, parameters)
+
+		this.Observer.FunctionCalled(stage.name, time.Since(start), err)
+		return result, err
+
+	case VALUE:
+		fallthrough
+	case ACCESS:
+		this.Observer.ParameterAccessed(stage.name)
+	}
+
+	return stage.operator(left, right, parameters)
+}
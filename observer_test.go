@@ -0,0 +1,112 @@
+package govaluate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+/*
+	An Observer which simply records everything it's told.
+*/
+type recordingObserver struct {
+	started    []string
+	finished   []string
+	errors     []error
+	functions  []string
+	parameters []string
+}
+
+func (this *recordingObserver) EvaluationStarted(expression string) {
+	this.started = append(this.started, expression)
+}
+
+func (this *recordingObserver) EvaluationFinished(expression string, elapsed time.Duration, err error) {
+	this.finished = append(this.finished, expression)
+	this.errors = append(this.errors, err)
+}
+
+func (this *recordingObserver) FunctionCalled(name string, elapsed time.Duration, err error) {
+	this.functions = append(this.functions, name)
+}
+
+func (this *recordingObserver) ParameterAccessed(name string) {
+	this.parameters = append(this.parameters, name)
+}
+
+func TestObserver(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"double": func(arguments ...interface{}) (interface{}, error) {
+			return arguments[0].(float64) * 2, nil
+		},
+	}
+
+	input := "double(foo) > 10 && foo.String == 'string!'"
+	expression, err := NewEvaluableExpressionWithFunctions(input, functions)
+	if err != nil {
+		test.Logf("Unable to parse expression: %v", err)
+		test.Fail()
+		return
+	}
+
+	observer := new(recordingObserver)
+	expression.Observer = observer
+
+	// double(6) > 10, so the accessor is evaluated too - and fails, since 6 isn't a struct.
+	_, err = expression.Evaluate(map[string]interface{}{"foo": 6})
+	if err == nil {
+		test.Logf("Expected an error from accessing a field on a number")
+		test.Fail()
+	}
+
+	// double(4) < 10, so the accessor is short-circuited.
+	_, err = expression.Eval(MapParameters(map[string]interface{}{"foo": 4}))
+	if err != nil {
+		test.Logf("Unexpected error: %v", err)
+		test.Fail()
+	}
+
+	if !reflect.DeepEqual(observer.started, []string{input, input}) ||
+		!reflect.DeepEqual(observer.finished, []string{input, input}) {
+
+		test.Logf("Expected two evaluations of '%s', got starts %v and finishes %v", input, observer.started, observer.finished)
+		test.Fail()
+	}
+
+	if len(observer.errors) != 2 || observer.errors[0] == nil || observer.errors[1] != nil {
+		test.Logf("Expected the observer to be told about the first evaluation's error only, got %v", observer.errors)
+		test.Fail()
+	}
+
+	if !reflect.DeepEqual(observer.functions, []string{"double", "double"}) {
+		test.Logf("Expected two calls to 'double', got %v", observer.functions)
+		test.Fail()
+	}
+
+	if !reflect.DeepEqual(observer.parameters, []string{"foo", "foo.String", "foo"}) {
+		test.Logf("Unexpected parameter accesses: %v", observer.parameters)
+		test.Fail()
+	}
+}
+
+func TestObserverFunctionErrors(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"broken": func(arguments ...interface{}) (interface{}, error) {
+			return nil, errors.New("broken")
+		},
+	}
+
+	expression, _ := NewEvaluableExpressionWithFunctions("broken()", functions)
+
+	observer := new(recordingObserver)
+	expression.Observer = observer
+
+	_, err := expression.Evaluate(nil)
+	if err == nil || len(observer.errors) != 1 || observer.errors[0] != err {
+		test.Logf("Expected the observer to receive the function error, got %v", observer.errors)
+		test.Fail()
+	}
+}
@@ -0,0 +1,213 @@
+package govaluate
+
+import (
+	"fmt"
+	"sort"
+)
+
+/*
+	Describes one of the binary operators whose precedence can be changed with `ParsingOptions.Precedence`.
+*/
+type configurableOperator struct {
+	text            string
+	kind            TokenKind
+	typeErrorFormat string
+}
+
+var configurableOperators = map[OperatorSymbol]configurableOperator{}
+
+func init() {
+
+	addConfigurableOperators(comparatorSymbols, COMPARATOR, comparatorErrorFormat)
+	addConfigurableOperators(logicalSymbols, LOGICALOP, logicalErrorFormat)
+	addConfigurableOperators(bitwiseSymbols, MODIFIER, modifierErrorFormat)
+	addConfigurableOperators(bitwiseShiftSymbols, MODIFIER, modifierErrorFormat)
+	addConfigurableOperators(additiveSymbols, MODIFIER, modifierErrorFormat)
+	addConfigurableOperators(multiplicativeSymbols, MODIFIER, modifierErrorFormat)
+	addConfigurableOperators(exponentialSymbolsS, MODIFIER, modifierErrorFormat)
+}
+
+func addConfigurableOperators(symbols map[string]OperatorSymbol, kind TokenKind, typeErrorFormat string) {
+
+	for text, symbol := range symbols {
+		configurableOperators[symbol] = configurableOperator{
+			text:            text,
+			kind:            kind,
+			typeErrorFormat: typeErrorFormat,
+		}
+	}
+}
+
+/*
+	Returns the precedence of every binary operator, as used when `ParsingOptions.Precedence` doesn't say otherwise.
+	Operators with a higher number bind more tightly, so "a + b * c" is "a + (b * c)" because MULTIPLY is higher than PLUS.
+	The returned map is a new copy each time, which can be changed and given to `ParsingOptions.Precedence`.
+*/
+func DefaultPrecedence() map[OperatorSymbol]int {
+
+	return map[OperatorSymbol]int{
+		OR:             1,
+		AND:            2,
+		EQ:             3,
+		NEQ:            3,
+		GT:             3,
+		LT:             3,
+		GTE:            3,
+		LTE:            3,
+		REQ:            3,
+		NREQ:           3,
+		IN:             3,
+		BITWISE_AND:    4,
+		BITWISE_OR:     4,
+		BITWISE_XOR:    4,
+		BITWISE_LSHIFT: 5,
+		BITWISE_RSHIFT: 5,
+		PLUS:           6,
+		MINUS:          6,
+		CONCAT:         6,
+		MULTIPLY:       7,
+		DIVIDE:         7,
+		MODULUS:        7,
+		EXPONENT:       8,
+	}
+}
+
+/*
+	Returns the full precedence table for the given [overrides], which are used in place of the defaults for the operators they have.
+*/
+func resolvePrecedence(overrides map[OperatorSymbol]int) (map[OperatorSymbol]int, error) {
+
+	table := DefaultPrecedence()
+
+	for symbol, level := range overrides {
+
+		_, found := configurableOperators[symbol]
+		if !found {
+			return nil, fmt.Errorf("The precedence of operator '%v' can't be changed", symbol)
+		}
+		if level < 1 {
+			return nil, fmt.Errorf("The precedence of operator '%v' must be at least 1, not %d", symbol, level)
+		}
+
+		table[symbol] = level
+	}
+	return table, nil
+}
+
+/*
+	Creates a `precedent` which plans a whole expression (like `planSeparator` does), but with the binary operators planned
+	according to the given precedence [table]. Ternaries, separators, and prefixes keep their usual precedence.
+*/
+func makePrecedencePlan(table map[OperatorSymbol]int) precedent {
+
+	var levels []int
+	symbolsByLevel := make(map[int]map[string]OperatorSymbol)
+
+	for symbol, level := range table {
+
+		symbols, found := symbolsByLevel[level]
+		if !found {
+			symbols = make(map[string]OperatorSymbol)
+			symbolsByLevel[level] = symbols
+			levels = append(levels, level)
+		}
+		symbols[configurableOperators[symbol].text] = symbol
+	}
+
+	sort.Ints(levels)
+
+	// build up from the tightest-binding level, since each level defers to the one above it.
+	next := planFunction
+	for i := len(levels) - 1; i >= 0; i-- {
+		next = makeConfiguredPrecedent(symbolsByLevel[levels[i]], next)
+	}
+
+	ternary := makePrecedentFromPlanner(&precedencePlanner{
+		validSymbols:    ternarySymbols,
+		validKinds:      []TokenKind{TERNARY},
+		typeErrorFormat: ternaryErrorFormat,
+		next:            next,
+	})
+	return makePrecedentFromPlanner(&precedencePlanner{
+		validSymbols: separatorSymbols,
+		validKinds:   []TokenKind{SEPARATOR},
+		next:         ternary,
+	})
+}
+
+/*
+	Creates a `precedent` for one level of a configured precedence table.
+	Operators of different kinds may share a level, so each stage gets the type error format for its own operator.
+*/
+func makeConfiguredPrecedent(symbols map[string]OperatorSymbol, next precedent) precedent {
+
+	var generated precedent
+	var kinds []TokenKind
+
+	for _, symbol := range symbols {
+		kinds = append(kinds, configurableOperators[symbol].kind)
+	}
+
+	generated = func(stream *tokenStream) (*evaluationStage, error) {
+
+		stage, err := planPrecedenceLevel(stream, "", symbols, kinds, generated, next)
+		if err != nil {
+			return nil, err
+		}
+
+		if stage != nil && stage.typeErrorFormat == "" {
+			stage.typeErrorFormat = configurableOperators[stage.symbol].typeErrorFormat
+		}
+		return stage, nil
+	}
+	return generated
+}
+
+/*
+	Returns an error if operators which were given the same precedence by [table] (but don't have the same precedence by default)
+	are used one after another without parentheses, such as "a && b || c" when AND and OR have the same precedence.
+	Since there's no obvious order to evaluate those in, the expression has to say which it means.
+	Must be called before `reorderStages`, while every chain of same-precedence operators is down the right of the tree.
+*/
+func checkPrecedenceMixing(stage *evaluationStage, table map[OperatorSymbol]int) error {
+
+	if stage == nil {
+		return nil
+	}
+
+	right := stage.rightStage
+	if right != nil {
+
+		level, found := table[stage.symbol]
+		rightLevel, rightFound := table[right.symbol]
+
+		if found && rightFound && level == rightLevel &&
+			findOperatorPrecedenceForSymbol(stage.symbol) != findOperatorPrecedenceForSymbol(right.symbol) {
+
+			return fmt.Errorf("Operators '%v' and '%v' have the same precedence, and need parentheses to say which is evaluated first",
+				configurableOperators[stage.symbol].text, configurableOperators[right.symbol].text)
+		}
+	}
+
+	err := checkPrecedenceMixing(stage.leftStage, table)
+	if err != nil {
+		return err
+	}
+	return checkPrecedenceMixing(right, table)
+}
+
+/*
+	Returns a function which gives the precedence of a symbol according to [table], for use with `reorderStages`.
+	Operators in the table are placed after all the built-in precedences, so that they never seem equal to something that isn't in the table.
+*/
+func makePrecedenceFinder(table map[OperatorSymbol]int) func(OperatorSymbol) operatorPrecedence {
+
+	return func(symbol OperatorSymbol) operatorPrecedence {
+
+		level, found := table[symbol]
+		if !found {
+			return findOperatorPrecedenceForSymbol(symbol)
+		}
+		return separatePrecedence + operatorPrecedence(level)
+	}
+}
@@ -0,0 +1,23 @@
+package govaluate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOperatorDescriptions(test *testing.T) {
+
+	for symbol := VALUE; symbol <= SEPARATE; symbol++ {
+
+		if symbol.Description() == "" {
+			test.Logf("Operator %d (%s) has no description", symbol, symbol.String())
+			test.Fail()
+		}
+	}
+
+	// modulus is frequently mistaken for percent-of, so its description needs to point people the right way.
+	if !strings.Contains(MODULUS.Description(), "percent(") {
+		test.Logf("Expected the description of '%%' to mention percent(), got '%s'", MODULUS.Description())
+		test.Fail()
+	}
+}
@@ -0,0 +1,125 @@
+package govaluate
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+/*
+	OrderedMap is a string-keyed map which remembers the order in which its keys were first set.
+	Iterating a Go map is nondeterministic, so any map value built by an expression uses this instead,
+	which keeps evaluation (and anything that serializes the result) reproducible.
+*/
+type OrderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+/*
+	Creates a new, empty OrderedMap.
+*/
+func NewOrderedMap() *OrderedMap {
+
+	return &OrderedMap{
+		values: make(map[string]interface{}),
+	}
+}
+
+/*
+	Sets the given [key] to [value].
+	Keys which are set for the first time are appended to the end of the ordering;
+	overwriting an existing key keeps its original position.
+*/
+func (this *OrderedMap) Set(key string, value interface{}) {
+
+	_, found := this.values[key]
+	if !found {
+		this.keys = append(this.keys, key)
+	}
+
+	this.values[key] = value
+}
+
+/*
+	Returns the value for [key], and whether or not it was present.
+*/
+func (this *OrderedMap) Get(key string) (interface{}, bool) {
+
+	value, found := this.values[key]
+	return value, found
+}
+
+/*
+	Returns the number of keys in this map.
+*/
+func (this *OrderedMap) Len() int {
+
+	return len(this.keys)
+}
+
+/*
+	Returns the keys of this map, in insertion order.
+*/
+func (this *OrderedMap) Keys() []string {
+
+	ret := make([]string, len(this.keys))
+	copy(ret, this.keys)
+	return ret
+}
+
+/*
+	Returns the values of this map, in the same order as `Keys()`.
+*/
+func (this *OrderedMap) Values() []interface{} {
+
+	ret := make([]interface{}, len(this.keys))
+	for i, key := range this.keys {
+		ret[i] = this.values[key]
+	}
+	return ret
+}
+
+/*
+	Returns a plain (unordered) copy of this map, for callers who don't care about ordering.
+*/
+func (this *OrderedMap) Map() map[string]interface{} {
+
+	ret := make(map[string]interface{}, len(this.keys))
+	for key, value := range this.values {
+		ret[key] = value
+	}
+	return ret
+}
+
+/*
+	Serializes this map as a JSON object whose members appear in insertion order.
+*/
+func (this *OrderedMap) MarshalJSON() ([]byte, error) {
+
+	var buffer bytes.Buffer
+
+	buffer.WriteString("{")
+	for i, key := range this.keys {
+
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+
+		encodedValue, err := json.Marshal(this.values[key])
+		if err != nil {
+			return nil, err
+		}
+
+		buffer.Write(encodedKey)
+		buffer.WriteString(":")
+		buffer.Write(encodedValue)
+	}
+	buffer.WriteString("}")
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestOrderedMapInsertionOrder(test *testing.T) {
+
+	ordered := NewOrderedMap()
+	ordered.Set("zeta", 1.0)
+	ordered.Set("alpha", 2.0)
+	ordered.Set("mu", 3.0)
+
+	// overwriting must not move the key.
+	ordered.Set("zeta", 4.0)
+
+	expectedKeys := []string{"zeta", "alpha", "mu"}
+	if !reflect.DeepEqual(ordered.Keys(), expectedKeys) {
+		test.Logf("Expected keys %v, got %v", expectedKeys, ordered.Keys())
+		test.Fail()
+	}
+
+	expectedValues := []interface{}{4.0, 2.0, 3.0}
+	if !reflect.DeepEqual(ordered.Values(), expectedValues) {
+		test.Logf("Expected values %v, got %v", expectedValues, ordered.Values())
+		test.Fail()
+	}
+
+	if ordered.Len() != 3 {
+		test.Logf("Expected length 3, got %d", ordered.Len())
+		test.Fail()
+	}
+
+	value, found := ordered.Get("alpha")
+	if !found || value != 2.0 {
+		test.Logf("Expected to find 'alpha' = 2, got %v (found: %v)", value, found)
+		test.Fail()
+	}
+
+	_, found = ordered.Get("missing")
+	if found {
+		test.Logf("Expected 'missing' to be absent")
+		test.Fail()
+	}
+}
+
+func TestOrderedMapSerialization(test *testing.T) {
+
+	ordered := NewOrderedMap()
+	ordered.Set("b", "second")
+	ordered.Set("a", true)
+	ordered.Set("c", 1.5)
+
+	// run it a few times, since an unordered implementation would only fail sometimes.
+	for i := 0; i < 10; i++ {
+
+		serialized, err := json.Marshal(ordered)
+		if err != nil {
+			test.Logf("Unable to serialize ordered map: %v", err)
+			test.Fail()
+			return
+		}
+
+		if string(serialized) != `{"b":"second","a":true,"c":1.5}` {
+			test.Logf("Serialized map was not in insertion order: %s", serialized)
+			test.Fail()
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(ordered.Map(), map[string]interface{}{"a": true, "b": "second", "c": 1.5}) {
+		test.Logf("Plain map copy did not match: %v", ordered.Map())
+		test.Fail()
+	}
+}
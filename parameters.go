@@ -2,6 +2,7 @@ package govaluate
 
 import (
 	"errors"
+	"sync"
 )
 
 /*
@@ -30,3 +31,123 @@ func (p MapParameters) Get(name string) (interface{}, error) {
 
 	return value, nil
 }
+
+/*
+	ConcurrentParameters is a set of parameters which is safe to change from one goroutine while
+	expressions are evaluated with it on others.
+	The zero value is an empty set of parameters, ready to use. It must not be copied after first use.
+*/
+type ConcurrentParameters struct {
+	values sync.Map
+}
+
+/*
+	Creates a new ConcurrentParameters, containing all of the given [parameters].
+*/
+func NewConcurrentParameters(parameters map[string]interface{}) *ConcurrentParameters {
+
+	ret := new(ConcurrentParameters)
+
+	for name, value := range parameters {
+		ret.Set(name, value)
+	}
+	return ret
+}
+
+func (p *ConcurrentParameters) Get(name string) (interface{}, error) {
+
+	value, found := p.values.Load(name)
+
+	if !found {
+		errorMessage := "No parameter '" + name + "' found."
+		return nil, errors.New(errorMessage)
+	}
+
+	return value, nil
+}
+
+/*
+	Sets the parameter of the given [name] to [value], replacing any previous value.
+*/
+func (p *ConcurrentParameters) Set(name string, value interface{}) {
+	p.values.Store(name, value)
+}
+
+/*
+	Removes the parameter of the given [name], if it exists.
+*/
+func (p *ConcurrentParameters) Delete(name string) {
+	p.values.Delete(name)
+}
+
+/*
+	ChainedParameters looks each parameter up in a series of other Parameters, in order, and uses the first one which has it.
+	This suits layered settings, such as ChainedParameters{request, session, defaults}, where earlier sources override later ones,
+	without merging them into one map for each evaluation.
+	A source which returns an error for a name is treated as not having it.
+*/
+type ChainedParameters []Parameters
+
+func (p ChainedParameters) Get(name string) (interface{}, error) {
+
+	for _, parameters := range p {
+
+		value, err := parameters.Get(name)
+		if err == nil {
+			return value, nil
+		}
+	}
+
+	return nil, errors.New("No parameter '" + name + "' found.")
+}
+
+/*
+	NamedExpressions is a set of expressions which other expressions can refer to by name, as if they were parameters.
+	For instance, with named expressions "isAdult" and "isVerified", the expression "isAdult && isVerified" evaluates
+	both of them against its own parameters.
+*/
+type NamedExpressions map[string]*EvaluableExpression
+
+/*
+	Returns Parameters which resolve each named expression by evaluating it against those same Parameters,
+	and resolve every other name from the given [parameters].
+	Named expressions are only evaluated when an expression uses them, and may refer to other named expressions.
+
+	The returned Parameters track which named expressions are being evaluated (so that circular references can be
+	reported as errors), and must not be used by multiple evaluations at once. Call `With` once per evaluation instead.
+*/
+func (this NamedExpressions) With(parameters Parameters) Parameters {
+
+	return &namedExpressionParameters{
+		expressions: this,
+		parameters:  parameters,
+		evaluating:  make(map[string]bool),
+	}
+}
+
+type namedExpressionParameters struct {
+	expressions NamedExpressions
+	parameters  Parameters
+	evaluating  map[string]bool
+}
+
+func (p *namedExpressionParameters) Get(name string) (interface{}, error) {
+
+	expression, found := p.expressions[name]
+	if !found {
+
+		if p.parameters == nil {
+			return nil, errors.New("No parameter '" + name + "' found.")
+		}
+		return p.parameters.Get(name)
+	}
+
+	if p.evaluating[name] {
+		return nil, errors.New("Named expression '" + name + "' refers to itself")
+	}
+
+	p.evaluating[name] = true
+	defer delete(p.evaluating, name)
+
+	return expression.Eval(p)
+}
@@ -0,0 +1,488 @@
+package govaluate
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentParameters(test *testing.T) {
+
+	parameters := NewConcurrentParameters(map[string]interface{}{"foo": 1})
+
+	expression, _ := NewEvaluableExpression("foo + 1")
+
+	result, err := expression.Eval(parameters)
+	if err != nil || result != 2.0 {
+		test.Logf("Expected 2, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	parameters.Delete("foo")
+
+	_, err = expression.Eval(parameters)
+	if err == nil {
+		test.Logf("Expected an error after deleting the parameter")
+		test.Fail()
+	}
+
+	// write from one goroutine while evaluating from others.
+	var waitGroup sync.WaitGroup
+	parameters.Set("foo", 0)
+
+	waitGroup.Add(1)
+	go func() {
+
+		defer waitGroup.Done()
+		for i := 0; i < 1000; i++ {
+			parameters.Set("foo", i)
+		}
+	}()
+
+	for i := 0; i < 4; i++ {
+
+		waitGroup.Add(1)
+		go func() {
+
+			defer waitGroup.Done()
+			for j := 0; j < 1000; j++ {
+
+				_, err := expression.Eval(parameters)
+				if err != nil {
+					test.Errorf("Unexpected error during concurrent evaluation: %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	waitGroup.Wait()
+}
+
+func TestChainedParameters(test *testing.T) {
+
+	request := MapParameters{"limit": 5}
+	session := NewConcurrentParameters(map[string]interface{}{"limit": 10, "user": "ada"})
+	defaults := MapParameters{"limit": 100, "user": "anonymous", "region": "eu"}
+
+	parameters := ChainedParameters{request, session, defaults}
+
+	expression, _ := NewEvaluableExpression("limit .. ',' .. user .. ',' .. region")
+
+	result, err := expression.Eval(parameters)
+	if err != nil || result != "5,ada,eu" {
+		test.Logf("Expected each parameter from the first source which has it, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	// sources are looked up as they are at the time, so changes to them are seen.
+	session.Delete("user")
+
+	result, err = expression.Eval(parameters)
+	if err != nil || result != "5,anonymous,eu" {
+		test.Logf("Expected a deleted parameter to fall back to the next source, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	expression, _ = NewEvaluableExpression("missing")
+
+	_, err = expression.Eval(parameters)
+	if err == nil || err.Error() != "No parameter 'missing' found." {
+		test.Logf("Expected a parameter missing from every source to be an error, got %v", err)
+		test.Fail()
+	}
+}
+
+func TestNamedExpressions(test *testing.T) {
+
+	isAdult, _ := NewEvaluableExpression("age >= 18")
+	isVerified, _ := NewEvaluableExpression("verified == true")
+	canVote, _ := NewEvaluableExpression("isAdult && isVerified")
+	loops, _ := NewEvaluableExpression("loops || false")
+
+	named := NamedExpressions{
+		"isAdult":    isAdult,
+		"isVerified": isVerified,
+		"canVote":    canVote,
+		"loops":      loops,
+	}
+
+	expression, _ := NewEvaluableExpression("canVote && name != ''")
+
+	parameters := MapParameters(map[string]interface{}{
+		"age":      21,
+		"verified": true,
+		"name":     "foo",
+	})
+
+	result, err := expression.Eval(named.With(parameters))
+	if err != nil || result != true {
+		test.Logf("Expected true, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	parameters["age"] = 12
+
+	result, err = expression.Eval(named.With(parameters))
+	if err != nil || result != false {
+		test.Logf("Expected false after changing a parameter, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	expression, _ = NewEvaluableExpression("loops")
+
+	_, err = expression.Eval(named.With(parameters))
+	if err == nil || err.Error() != "Named expression 'loops' refers to itself" {
+		test.Logf("Expected an error for a circular reference, got %v", err)
+		test.Fail()
+	}
+
+	expression, _ = NewEvaluableExpression("isAdult && missing")
+
+	parameters["age"] = 30
+	_, err = expression.Eval(named.With(parameters))
+	if err == nil {
+		test.Logf("Expected an error for a missing parameter")
+		test.Fail()
+	}
+}
+
+func TestPositionalParameters(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("a * b + a < foo.Int")
+
+	slots := expression.ParameterSlots()
+	if !reflect.DeepEqual(slots, []string{"a", "b", "foo"}) {
+		test.Logf("Expected parameter slots [a b foo], got %v", slots)
+		test.Fail()
+	}
+
+	// numbers are converted to float64 once, when the parameters are made.
+	parameters, err := expression.PositionalParameters(2, int64(3), dummyParameterInstance)
+	if err != nil {
+		test.Logf("Expected positional parameters to be made, got %v", err)
+		test.Fail()
+		return
+	}
+
+	result, err := expression.Eval(parameters)
+	if err != nil || result != true {
+		test.Logf("Expected positional evaluation to give true, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	_, err = expression.PositionalParameters(1.0, 2.0)
+	if err == nil {
+		test.Logf("Expected the wrong number of positional parameters to fail")
+		test.Fail()
+	}
+
+	// other expressions look the parameters up by name, with different positions.
+	other, _ := NewEvaluableExpression("b - a")
+
+	result, err = other.Eval(parameters)
+	if err != nil || result != 1.0 {
+		test.Logf("Expected positional parameters to work by name with another expression, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	bound := expression.BindParameter("a", 1.0)
+
+	slots = bound.ParameterSlots()
+	if !reflect.DeepEqual(slots, []string{"b", "foo"}) {
+		test.Logf("Expected a bound parameter to have no slot, got %v", slots)
+		test.Fail()
+	}
+
+	parameters, _ = bound.PositionalParameters(3.0, dummyParameterInstance)
+
+	result, err = bound.Eval(parameters)
+	if err != nil || result != true {
+		test.Logf("Expected bound positional evaluation to give true, got %v, %v", result, err)
+		test.Fail()
+	}
+}
+
+func TestCompileWithSchema(test *testing.T) {
+
+	schema := []SchemaField{
+		SchemaField{Name: "score", Type: "number"},
+		SchemaField{Name: "name", Type: "string"},
+		SchemaField{Name: "foo"},
+	}
+
+	expression, err := CompileWithSchema("name == 'x' && score > foo.Int", schema)
+	if err != nil {
+		test.Logf("Expected the expression to compile, got %v", err)
+		test.Fail()
+		return
+	}
+
+	// slots follow the schema, not the order the expression uses them.
+	slots := expression.ParameterSlots()
+	if !reflect.DeepEqual(slots, []string{"score", "name", "foo"}) {
+		test.Logf("Expected parameter slots [score name foo], got %v", slots)
+		test.Fail()
+	}
+
+	result, err := expression.EvaluatePositional([]interface{}{200, "x", dummyParameterInstance})
+	if err != nil || result != true {
+		test.Logf("Expected positional evaluation to give true, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	_, err = expression.EvaluatePositional([]interface{}{200, "x"})
+	if err == nil {
+		test.Logf("Expected the wrong number of positional values to fail")
+		test.Fail()
+	}
+
+	// an expression which doesn't use every field still takes a value for each.
+	other, _ := CompileWithSchema("score * 2", schema)
+
+	result, err = other.EvaluatePositional([]interface{}{21, "unused", nil})
+	if err != nil || result != 42.0 {
+		test.Logf("Expected 42, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	failures := []struct {
+		input    string
+		schema   []SchemaField
+		expected string
+	}{
+		{"missing > 1", schema, "Parameter 'missing' is not in the schema"},
+		{"bar.Int > 1", schema, "Parameter 'bar' is not in the schema"},
+		{"name > 1", schema, "cannot be used with the operator '>'"},
+		{"score", append(schema, SchemaField{Name: "score"}), "in the schema more than once"},
+	}
+
+	for _, failure := range failures {
+
+		_, err = CompileWithSchema(failure.input, failure.schema)
+		if err == nil || !strings.Contains(err.Error(), failure.expected) {
+			test.Logf("Expected '%s' to fail with '%s', got %v", failure.input, failure.expected, err)
+			test.Fail()
+		}
+	}
+}
+
+func TestLazyParameters(test *testing.T) {
+
+	calls := 0
+	expensive := func() (interface{}, error) {
+		calls++
+		return 10, nil
+	}
+
+	expression, _ := NewEvaluableExpression("cheap || expensive > 5 && expensive < 20")
+
+	result, err := expression.Evaluate(map[string]interface{}{"cheap": true, "expensive": expensive})
+	if err != nil || result != true || calls != 0 {
+		test.Logf("Expected a short-circuited thunk not to be called, got %v, %v, with %d calls", result, err, calls)
+		test.Fail()
+	}
+
+	// used twice, but only called once; and numbers it returns are converted like any other parameter.
+	result, err = expression.Evaluate(map[string]interface{}{"cheap": false, "expensive": expensive})
+	if err != nil || result != true || calls != 1 {
+		test.Logf("Expected a thunk to be called once, got %v, %v, with %d calls", result, err, calls)
+		test.Fail()
+	}
+
+	// results are only kept for one evaluation.
+	expression.Evaluate(map[string]interface{}{"cheap": false, "expensive": expensive})
+	if calls != 2 {
+		test.Logf("Expected a thunk to be called again by the next evaluation, got %d calls", calls)
+		test.Fail()
+	}
+
+	failing := func() (interface{}, error) {
+		return nil, errors.New("Unable to load")
+	}
+
+	_, err = expression.Evaluate(map[string]interface{}{"cheap": false, "expensive": failing})
+	if err == nil || !strings.Contains(err.Error(), "Unable to load") {
+		test.Logf("Expected the thunk's error, got %v", err)
+		test.Fail()
+	}
+
+	// the Coercer is given the thunk's result, not the thunk.
+	expression, _ = NewEvaluableExpression("count + 1")
+	expression.Coercer = func(name string, value interface{}) (interface{}, error) {
+		return strconv.ParseFloat(value.(string), 64)
+	}
+
+	lazyString := func() (interface{}, error) {
+		return "41", nil
+	}
+
+	result, err = expression.Evaluate(map[string]interface{}{"count": lazyString})
+	if err != nil || result != 42.0 {
+		test.Logf("Expected 42, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	// positional parameters call their thunks too, once per evaluation.
+	calls = 0
+	expression, _ = NewEvaluableExpression("cheap || expensive > 5 && expensive < 20")
+
+	result, err = expression.EvaluatePositional([]interface{}{false, expensive})
+	if err != nil || result != true || calls != 1 {
+		test.Logf("Expected a positional thunk to be called once, got %v, %v, with %d calls", result, err, calls)
+		test.Fail()
+	}
+
+	lazyBool := func() (interface{}, error) {
+		return true, nil
+	}
+
+	expression, _ = CompileWithSchema("a || b", []SchemaField{{Name: "a", Type: "bool"}, {Name: "b", Type: "bool"}})
+
+	result, err = expression.EvaluatePositional([]interface{}{lazyBool, lazyBool})
+	if err != nil || result != true {
+		test.Logf("Expected true from positional thunks, got %v, %v", result, err)
+		test.Fail()
+	}
+}
+
+type dummyColor int
+type dummyWeight float32
+
+const (
+	dummyRed dummyColor = iota
+	dummyGreen
+	dummyBlue
+)
+
+func TestNamedNumericParameters(test *testing.T) {
+
+	evaluationTests := []EvaluationTest{
+
+		EvaluationTest{
+
+			Name:  "Named integer equality",
+			Input: "color == 2",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "color",
+					Value: dummyBlue,
+				},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:  "Named integer comparison",
+			Input: "color < maxColor",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "color",
+					Value: dummyGreen,
+				},
+				EvaluationParameter{
+					Name:  "maxColor",
+					Value: dummyBlue,
+				},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:  "Named integer arithmetic",
+			Input: "color + 1",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "color",
+					Value: dummyGreen,
+				},
+			},
+			Expected: 2.0,
+		},
+		EvaluationTest{
+
+			Name:  "Named float arithmetic",
+			Input: "weight * 2",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "weight",
+					Value: dummyWeight(1.5),
+				},
+			},
+			Expected: 3.0,
+		},
+		EvaluationTest{
+
+			Name:  "Named integer membership",
+			Input: "color in (0, 1)",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "color",
+					Value: dummyRed,
+				},
+			},
+			Expected: true,
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+}
+
+func TestMessageGetters(test *testing.T) {
+
+	message := EvaluationParameter{
+		Name:  "msg",
+		Value: dummyMessageInstance,
+	}
+
+	evaluationTests := []EvaluationTest{
+
+		EvaluationTest{
+
+			Name:       "Getter for snake_case field",
+			Input:      "msg.user_id == 'u-1'",
+			Parameters: []EvaluationParameter{message},
+			Expected:   true,
+		},
+		EvaluationTest{
+
+			Name:       "Getter for camelCase field",
+			Input:      "msg.userId",
+			Parameters: []EvaluationParameter{message},
+			Expected:   "u-1",
+		},
+		EvaluationTest{
+
+			Name:       "Nested getters",
+			Input:      "msg.profile.display_name",
+			Parameters: []EvaluationParameter{message},
+			Expected:   "Ada",
+		},
+		EvaluationTest{
+
+			Name:       "Field preferred over getter",
+			Input:      "msg.profile.Age + 1",
+			Parameters: []EvaluationParameter{message},
+			Expected:   37.0,
+		},
+		EvaluationTest{
+
+			Name:       "Getter called explicitly",
+			Input:      "msg.GetUserId()",
+			Parameters: []EvaluationParameter{message},
+			Expected:   "u-1",
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+
+	expression, _ := NewEvaluableExpression("msg.nickname")
+	_, err := expression.Evaluate(map[string]interface{}{"msg": dummyMessageInstance})
+	if err == nil || !strings.Contains(err.Error(), "GetNickname") {
+		test.Logf("Expected a missing getter to name the getter which was tried, got '%v'", err)
+		test.Fail()
+	}
+}
@@ -2,6 +2,7 @@ package govaluate
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -11,24 +12,48 @@ import (
 	"unicode"
 )
 
-func parseTokens(expression string, functions map[string]ExpressionFunction) ([]ExpressionToken, error) {
+/*
+	Information about a parsed token which is only known when an expression is parsed from a string,
+	and so isn't part of the public ExpressionToken.
+*/
+type tokenMetadata struct {
+
+	// the name used to call a FUNCTION token, since the token itself only holds the function.
+	functionName string
+
+	// for FUNCTION tokens which call one of `ParsingOptions.ContextFunctions`, that function.
+	// The token itself holds a version of it which is always given context.Background().
+	contextFunction ContextFunction
+
+	// for FUNCTION tokens which call a built-in that calls other functions (like "map"), that built-in, made for each call.
+	// See `builtinFunction.bindWithin`.
+	subExpressionFunction func(outer *outerEvaluation) ExpressionFunction
+
+	// the byte offsets in the expression string of the first character of the token, and just past its last character.
+	start, end int
+}
+
+func parseTokens(expression string, functions map[string]ExpressionFunction, options ParsingOptions) ([]ExpressionToken, []tokenMetadata, error) {
 
 	var ret []ExpressionToken
+	var metadata []tokenMetadata
 	var token ExpressionToken
 	var stream *lexerStream
 	var state lexerState
 	var err error
 	var found bool
+	var start int
 
 	stream = newLexerStream(expression)
 	state = validLexerStates[0]
 
 	for stream.canRead() {
 
-		token, err, found = readToken(stream, state, functions)
+		start = stream.position
+		token, err, found = readToken(stream, state, functions, options)
 
 		if err != nil {
-			return ret, err
+			return ret, metadata, err
 		}
 
 		if !found {
@@ -37,22 +62,59 @@ func parseTokens(expression string, functions map[string]ExpressionFunction) ([]
 
 		state, err = getLexerStateForToken(token.Kind)
 		if err != nil {
-			return ret, err
+			return ret, metadata, err
 		}
 
 		// append this valid token
+		tokenMetadata := readTokenMetadata(stream, token, start)
+		if token.Kind == FUNCTION {
+			tokenMetadata.contextFunction = findContextFunction(tokenMetadata.functionName, functions, options)
+			tokenMetadata.subExpressionFunction = findSubExpressionFunction(tokenMetadata.functionName, functions, options)
+		}
+
 		ret = append(ret, token)
+		metadata = append(metadata, tokenMetadata)
+
+		if options.MaxTokens > 0 && len(ret) > options.MaxTokens {
+			return nil, nil, ErrTooManyTokens
+		}
 	}
 
 	err = checkBalance(ret)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return ret, nil
+	return ret, metadata, nil
 }
 
-func readToken(stream *lexerStream, state lexerState, functions map[string]ExpressionFunction) (ExpressionToken, error, bool) {
+/*
+	Builds the metadata for a [token] which was just read from the [stream], starting at [start].
+*/
+func readTokenMetadata(stream *lexerStream, token ExpressionToken, start int) tokenMetadata {
+
+	var ret tokenMetadata
+
+	end := stream.position
+
+	// the token's position in the stream includes any whitespace that was skipped before it (or, for functions, after it).
+	for start < end && unicode.IsSpace(stream.source[start]) {
+		start++
+	}
+	for end > start && unicode.IsSpace(stream.source[end-1]) {
+		end--
+	}
+
+	if token.Kind == FUNCTION {
+		ret.functionName = string(stream.source[start:end])
+	}
+
+	ret.start = stream.byteOffset(start)
+	ret.end = stream.byteOffset(end)
+	return ret
+}
+
+func readToken(stream *lexerStream, state lexerState, functions map[string]ExpressionFunction, options ParsingOptions) (ExpressionToken, error, bool) {
 
 	var function ExpressionFunction
 	var ret ExpressionToken
@@ -81,8 +143,8 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 
 		kind = UNKNOWN
 
-		// numeric constant
-		if isNumeric(character) {
+		// numeric constant (unless this is the start of the ".." operator)
+		if isNumeric(character) && !isConcatenation(stream, character) {
 
 			if stream.canRead() && character == '0' {
 				character = stream.readCharacter()
@@ -105,12 +167,39 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			}
 
 			tokenString = readTokenUntilFalse(stream, isNumeric)
+			tokenString = unreadConcatenation(stream, tokenString)
+
+			// a number followed immediately by a unit (like "5m") is a duration.
+			if isFollowedByLetter(stream) {
+
+				position := stream.position
+				stream.rewind(len([]rune(tokenString)) - 1)
+
+				durationString := readTokenUntilFalse(stream, isDurationCharacter)
+				durationString = unreadConcatenation(stream, durationString)
+
+				tokenValue, err = parseDuration(durationString)
+				if err == nil {
+					kind = NUMERIC
+					break
+				}
+
+				// not a valid duration, so leave the letters to be read as the next token, as they always have been.
+				stream.position = position
+			}
+
 			tokenValue, err = strconv.ParseFloat(tokenString, 64)
 
 			if err != nil {
 				errorMsg := fmt.Sprintf("Unable to parse numeric value '%v' to float64\n", tokenString)
 				return ExpressionToken{}, errors.New(errorMsg), false
 			}
+
+			// a number followed immediately by a "%" which has nothing to operate on (like "15%") is a percentage.
+			if isPercentLiteral(stream, options) {
+				stream.position++
+				tokenValue = tokenValue.(float64) / 100
+			}
 			kind = NUMERIC
 			break
 		}
@@ -123,6 +212,23 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			break
 		}
 
+		// a bracket right after a function call (or after another index) is an index into its result, like "split(s, ',')[0]".
+		if character == '[' && state.kind == CLAUSE_CLOSE && stream.lastClosed != groupClause {
+
+			tokenValue = character
+			kind = CLAUSE
+			stream.openClause(indexClause)
+			break
+		}
+
+		if character == ']' && stream.innermostClause() == indexClause {
+
+			tokenValue = character
+			kind = CLAUSE_CLOSE
+			stream.closeClause()
+			break
+		}
+
 		// escaped variable
 		if character == '[' {
 
@@ -138,10 +244,42 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			break
 		}
 
+		// backtick-quoted variable, which works just like a bracketed one.
+		if character == '`' {
+
+			tokenValue, completed = readUntilFalse(stream, true, false, true, isNotBacktick)
+			kind = VARIABLE
+
+			if !completed {
+				return ExpressionToken{}, errors.New("Unclosed parameter backtick"), false
+			}
+
+			stream.rewind(-1)
+			break
+		}
+
+		// raw string, like r"\d+", in which backslashes are just backslashes.
+		if character == 'r' && isFollowedByQuote(stream) {
+
+			quote := stream.readCharacter()
+			tokenValue, completed = readUntilFalse(stream, true, false, false, func(character rune) bool {
+				return character != quote
+			})
+
+			if !completed {
+				return ExpressionToken{}, errors.New("Unclosed raw string literal"), false
+			}
+
+			stream.rewind(-1)
+			kind = STRING
+			break
+		}
+
 		// regular variable - or function?
 		if unicode.IsLetter(character) {
 
 			tokenString = readTokenUntilFalse(stream, isVariableName)
+			tokenString = unreadConcatenation(stream, tokenString)
 
 			tokenValue = tokenString
 			kind = VARIABLE
@@ -168,11 +306,45 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 				kind = COMPARATOR
 			}
 
+			// word operator? "not" is only a prefix where one could go, otherwise it's still read as a parameter.
+			if options.WordOperators {
+
+				symbol, found := wordOperatorSymbols[tokenString]
+				if found {
+
+					_, isPrefix := prefixSymbols[symbol]
+					if !isPrefix {
+						kind = LOGICALOP
+						tokenValue = symbol
+					} else if state.canTransitionTo(PREFIX) {
+						kind = PREFIX
+						tokenValue = symbol
+					}
+				}
+			}
+
 			// function?
 			function, found = functions[tokenString]
+			contextFunction := findContextFunction(tokenString, functions, options)
+
 			if found {
 				kind = FUNCTION
 				tokenValue = function
+			} else if contextFunction != nil && isFollowedByClause(stream) {
+				kind = FUNCTION
+				tokenValue = contextFunction.withContext(context.Background())
+			} else {
+
+				// built-ins only count as functions when they're actually called,
+				// so that expressions which already use their names as parameters keep working.
+				builtin, found := builtinFunctions[tokenString]
+				if found && isFollowedByClause(stream) {
+					kind = FUNCTION
+					tokenValue = builtin.bind(functions)
+				} else if options.UnknownFunction != nil && kind == VARIABLE && isFollowedByClause(stream) {
+					kind = FUNCTION
+					tokenValue = makeUnknownFunction(tokenString, options.UnknownFunction)
+				}
 			}
 
 			// accessor?
@@ -185,20 +357,10 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 					return ExpressionToken{}, errors.New(errorMsg), false
 				}
 
+				// whether or not each part can be accessed (such as unexported struct fields, or keys of maps)
+				// depends on the parameter, so it's only checked during evaluation.
 				kind = ACCESSOR
-				splits := strings.Split(tokenString, ".")
-				tokenValue = splits
-
-				// check that none of them are unexported
-				for i := 1; i < len(splits); i++ {
-
-					firstCharacter := getFirstRune(splits[i])
-
-					if unicode.ToUpper(firstCharacter) != firstCharacter {
-						errorMsg := fmt.Sprintf("Unable to access unexported field '%s' in token '%s'", splits[i], tokenString)
-						return ExpressionToken{}, errors.New(errorMsg), false
-					}
-				}
+				tokenValue = strings.Split(tokenString, ".")
 			}
 			break
 		}
@@ -225,14 +387,27 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 		}
 
 		if character == '(' {
+
 			tokenValue = character
 			kind = CLAUSE
+
+			if state.kind == FUNCTION || state.kind == ACCESSOR {
+				stream.openClause(argumentsClause)
+			} else {
+				stream.openClause(groupClause)
+			}
 			break
 		}
 
 		if character == ')' {
+
+			if stream.innermostClause() == indexClause {
+				return ExpressionToken{}, errors.New("Index bracket closed by ')'"), false
+			}
+
 			tokenValue = character
 			kind = CLAUSE_CLOSE
+			stream.closeClause()
 			break
 		}
 
@@ -392,6 +567,7 @@ func checkBalance(tokens []ExpressionToken) error {
 	var stream *tokenStream
 	var token ExpressionToken
 	var parens int
+	var indexes []bool
 
 	stream = newTokenStream(tokens)
 
@@ -400,9 +576,20 @@ func checkBalance(tokens []ExpressionToken) error {
 		token = stream.next()
 		if token.Kind == CLAUSE {
 			parens++
+			indexes = append(indexes, token.Value == '[')
 			continue
 		}
 		if token.Kind == CLAUSE_CLOSE {
+
+			// index brackets have to be closed by brackets, and parenthesis by parenthesis.
+			if len(indexes) > 0 {
+
+				if indexes[len(indexes)-1] != (token.Value == ']') {
+					return errors.New("Unbalanced index bracket")
+				}
+				indexes = indexes[:len(indexes)-1]
+			}
+
 			parens--
 			continue
 		}
@@ -414,6 +601,130 @@ func checkBalance(tokens []ExpressionToken) error {
 	return nil
 }
 
+/*
+	Returns the function of `ParsingOptions.ContextFunctions` which is called by [name], or nil if there isn't one,
+	or if it's overridden by one of the given [functions].
+*/
+func findContextFunction(name string, functions map[string]ExpressionFunction, options ParsingOptions) ContextFunction {
+
+	_, found := functions[name]
+	if found {
+		return nil
+	}
+	return options.ContextFunctions[name]
+}
+
+/*
+	Returns the built-in of the given [name], made for each call (see `builtinFunction.bindWithin`), if it's a built-in which calls other functions
+	and isn't replaced by one of the given [functions] or `ParsingOptions.ContextFunctions`. Returns nil otherwise.
+*/
+func findSubExpressionFunction(name string, functions map[string]ExpressionFunction, options ParsingOptions) func(outer *outerEvaluation) ExpressionFunction {
+
+	_, found := functions[name]
+	if found || options.ContextFunctions[name] != nil {
+		return nil
+	}
+
+	builtin, found := builtinFunctions[name]
+	if !found {
+		return nil
+	}
+	return builtin.bindWithin(functions)
+}
+
+/*
+	Returns a function which calls the given [handler] (see `ParsingOptions.UnknownFunction`) with the [name] it was called by.
+*/
+func makeUnknownFunction(name string, handler func(name string, arguments []interface{}) (interface{}, error)) ExpressionFunction {
+
+	return func(arguments ...interface{}) (interface{}, error) {
+		return handler(name, arguments)
+	}
+}
+
+/*
+	Returns true if the next non-whitespace character in the [stream] opens a clause.
+	Does not advance the stream.
+*/
+func isFollowedByClause(stream *lexerStream) bool {
+
+	for i := stream.position; i < stream.length; i++ {
+
+		if unicode.IsSpace(stream.source[i]) {
+			continue
+		}
+		return stream.source[i] == '('
+	}
+
+	return false
+}
+
+/*
+	Returns true if the next character of the [stream] is a letter, immediately after the last character which was read.
+*/
+func isFollowedByLetter(stream *lexerStream) bool {
+
+	return stream.canRead() &&
+		!unicode.IsSpace(stream.source[stream.position-1]) &&
+		unicode.IsLetter(stream.source[stream.position])
+}
+
+/*
+	Returns true if the next character of the [stream] is a quote, immediately after the character which was just read.
+*/
+func isFollowedByQuote(stream *lexerStream) bool {
+	return stream.canRead() && !isNotQuote(stream.source[stream.position])
+}
+
+/*
+	Returns true if the next character of the [stream] is a "%" immediately after the number which was just read,
+	and it isn't followed by anything which could be the right side of a modulus (like "50%3", "50% -y", or "50% - y").
+*/
+func isPercentLiteral(stream *lexerStream, options ParsingOptions) bool {
+
+	if !stream.canRead() || unicode.IsSpace(stream.source[stream.position-1]) || stream.source[stream.position] != '%' {
+		return false
+	}
+
+	position := stream.position + 1
+	for position < stream.length && unicode.IsSpace(stream.source[position]) {
+		position++
+	}
+
+	if position >= stream.length {
+		return true
+	}
+
+	character := stream.source[position]
+
+	// a word is an operand, unless it's an operator (like "in").
+	if unicode.IsLetter(character) {
+
+		end := position
+		for end < stream.length && isVariableName(stream.source[end]) && stream.source[end] != '.' {
+			end++
+		}
+
+		word := string(stream.source[position:end])
+		if word == "in" || word == "IN" {
+			return true
+		}
+
+		if options.WordOperators {
+			symbol, found := wordOperatorSymbols[word]
+			_, isPrefix := prefixSymbols[symbol]
+			return found && !isPrefix
+		}
+		return false
+	}
+
+	switch character {
+	case '\'', '"', '`', '(', '[', '!', '~', '-':
+		return false
+	}
+	return !isNumeric(character)
+}
+
 func isDigit(character rune) bool {
 	return unicode.IsDigit(character)
 }
@@ -431,6 +742,29 @@ func isHexDigit(character rune) bool {
 		character == 'f'
 }
 
+/*
+	Returns true if the given [character], which was just read from the [stream], begins the ".." operator.
+*/
+func isConcatenation(stream *lexerStream, character rune) bool {
+	return character == '.' && stream.canRead() && stream.source[stream.position] == '.'
+}
+
+/*
+	Numbers and variable names may contain periods, so "foo..bar" would otherwise be read as one (invalid) token.
+	If the given [tokenString] contains a ".." operator, rewinds the [stream] to the start of it,
+	and returns only the part of [tokenString] before it.
+*/
+func unreadConcatenation(stream *lexerStream, tokenString string) string {
+
+	index := strings.Index(tokenString, "..")
+	if index < 0 {
+		return tokenString
+	}
+
+	stream.rewind(len([]rune(tokenString[index:])))
+	return tokenString[:index]
+}
+
 func isNumeric(character rune) bool {
 
 	return unicode.IsDigit(character) || character == '.'
@@ -441,6 +775,10 @@ func isNotQuote(character rune) bool {
 	return character != '\'' && character != '"'
 }
 
+func isDurationCharacter(character rune) bool {
+	return isNumeric(character) || unicode.IsLetter(character)
+}
+
 func isNotAlphanumeric(character rune) bool {
 
 	return !(unicode.IsDigit(character) ||
@@ -449,6 +787,7 @@ func isNotAlphanumeric(character rune) bool {
 		character == ')' ||
 		character == '[' ||
 		character == ']' || // starting to feel like there needs to be an `isOperation` func (#59)
+		character == '`' ||
 		!isNotQuote(character))
 }
 
@@ -465,6 +804,11 @@ func isNotClosingBracket(character rune) bool {
 	return character != ']'
 }
 
+func isNotBacktick(character rune) bool {
+
+	return character != '`'
+}
+
 /*
 	Attempts to parse the [candidate] as a Time.
 	Tries a series of standardized date formats, returns the Time if one applies,
@@ -515,12 +859,3 @@ func tryParseExactTime(candidate string, format string) (time.Time, bool) {
 
 	return ret, true
 }
-
-func getFirstRune(candidate string) rune {
-
-	for _, character := range candidate {
-		return character
-	}
-
-	return 0
-}
@@ -13,11 +13,11 @@ const (
 	INVALID_TOKEN_KIND              = "Invalid token"
 	UNCLOSED_QUOTES                 = "Unclosed string literal"
 	UNCLOSED_BRACKETS               = "Unclosed parameter bracket"
+	UNCLOSED_BACKTICKS              = "Unclosed parameter backtick"
 	UNBALANCED_PARENTHESIS          = "Unbalanced parenthesis"
 	INVALID_NUMERIC                 = "Unable to parse numeric value"
 	UNDEFINED_FUNCTION              = "Undefined function"
 	HANGING_ACCESSOR                = "Hanging accessor on token"
-	UNEXPORTED_ACCESSOR             = "Unable to access unexported"
 	INVALID_HEX                     = "Unable to parse hex value"
 )
 
@@ -142,6 +142,12 @@ func TestParsingFailure(test *testing.T) {
 			Input:    "[foo bar",
 			Expected: UNCLOSED_BRACKETS,
 		},
+		ParsingFailureTest{
+
+			Name:     "Unclosed backtick",
+			Input:    "`foo bar",
+			Expected: UNCLOSED_BACKTICKS,
+		},
 		ParsingFailureTest{
 
 			Name:     "Unclosed quote",
@@ -178,13 +184,6 @@ func TestParsingFailure(test *testing.T) {
 			Input:    "foo.Bar.",
 			Expected: HANGING_ACCESSOR,
 		},
-		ParsingFailureTest{
-
-			// this is expected to change once there are structtags in place that allow aliasing of fields
-			Name:     "Unexported parameter access",
-			Input:    "foo.bar",
-			Expected: UNEXPORTED_ACCESSOR,
-		},
 		ParsingFailureTest{
 			Name:     "Incomplete Hex",
 			Input:    "0x",
@@ -0,0 +1,316 @@
+package govaluate
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*
+	Options which change how an expression is parsed, used with `NewEvaluableExpressionWithOptions`.
+	The zero value parses expressions exactly as `NewEvaluableExpressionWithFunctions` does.
+*/
+type ParsingOptions struct {
+
+	/*
+		If true, a ternary whose branches are both literals of different types (such as "cond ? 1 : 'x'") is a parsing error.
+		Branches which aren't literals can't be known until evaluation, so this is best-effort; those are always allowed.
+	*/
+	StrictTernaryTypes bool
+
+	/*
+		If true, every ternary "?" must have a matching ":", so that "cond ? value" (which is nil when cond is false) is a parsing error.
+	*/
+	ForbidDanglingTernary bool
+
+	/*
+		If set, every string literal in the expression is replaced with the copy held by this table,
+		so that many expressions parsed with the same Interner share the memory for identical literals.
+	*/
+	Interner *StringInterner
+
+	/*
+		If true, the words "and", "or", and "not" (or "AND", "OR", and "NOT") are operators which mean exactly the same as "&&", "||", and "!".
+		Otherwise they're parameter names, like any other word.
+	*/
+	WordOperators bool
+
+	/*
+		If true, an empty (or whitespace-only) expression is allowed, and always evaluates to EmptyResult.
+		Otherwise (by default) parsing an empty expression returns ErrEmptyExpression.
+		Useful for expressions from user-editable settings, where an empty rule should mean something like "allow everything".
+	*/
+	AllowEmpty  bool
+	EmptyResult interface{}
+
+	/*
+		If set, reads the expression string into tokens instead of govaluate's own syntax. See `Tokenizer`.
+		Options which change how the expression is read (like WordOperators) are then up to the Tokenizer,
+		and errors aren't wrapped with positions in the expression string, since it can't say where each token came from.
+	*/
+	Tokenizer Tokenizer
+
+	/*
+		The names of functions whose results depend only on their arguments (and which have no side effects).
+		Their results are cached, so each is only called once for each set of arguments during an evaluation,
+		or for as long as the expression's `FunctionCache` keeps them, if it has one.
+	*/
+	PureFunctions []string
+
+	/*
+		The most tokens (numbers, parameters, operators, parentheses, and so on) the expression may have.
+		Longer expressions stop being read as soon as they go over, and return ErrTooManyTokens.
+		Zero (the default) means there is no limit.
+	*/
+	MaxTokens int
+
+	/*
+		If true, "+" never concatenates strings; it only adds numbers (or times and durations), and a string on either side is an error.
+		Adding a string literal, or using the ".." operator at all, is then a parsing error.
+		Useful for calculator-style expressions, where text should never quietly turn a sum into a string.
+	*/
+	DisableStringConcat bool
+
+	/*
+		Functions which are given the context that the expression is evaluated with (see `EvaluateWithContext`),
+		as well as their arguments, so that (for instance) a function which queries a database can respect the same deadline.
+		These are called in the same way as any other function, and take priority over built-ins, but not over functions given to the expression.
+		Only expressions parsed from a string (rather than with a `Tokenizer`) pass the context on; otherwise they're given context.Background().
+	*/
+	ContextFunctions map[string]ContextFunction
+
+	/*
+		If set, a call to a function which is neither given to the expression nor built in (like "lookup(x)") calls this instead,
+		with the name the function was called by and its arguments, rather than being a parsing error.
+		Useful for routing calls to functions which are only found at evaluation, such as those from plugins.
+		Only direct calls use it; functions named by built-ins like "map" must still be given to the expression or built in.
+	*/
+	UnknownFunction func(name string, arguments []interface{}) (interface{}, error)
+
+	/*
+		If true, an expression whose result can't be a bool (such as "a + b", or "x > 1 ? 'yes' : 'no'") is a parsing error.
+		Results which can't be known until evaluation (like parameters and function calls) are allowed, so this is best-effort,
+		but it catches rules which are certainly not conditions, for filters where every rule must be true or false.
+	*/
+	RequireBoolResult bool
+
+	/*
+		If set, changes the precedence of binary operators; a higher number binds more tightly (see `DefaultPrecedence` for the usual table).
+		Operators which aren't in this map keep their default precedence.
+		Operators given the same precedence are evaluated left-to-right, but if they didn't already share a precedence
+		(such as "&&" and "||" being set to the same number) then using them together without parentheses is a parsing error.
+		Ternaries, "??", and prefixes always keep their usual precedence.
+	*/
+	Precedence map[OperatorSymbol]int
+}
+
+/*
+	Returned when parsing an empty (or whitespace-only) expression, unless `ParsingOptions.AllowEmpty` is set.
+*/
+var ErrEmptyExpression = errors.New("Empty expression")
+
+/*
+	Returned when parsing an expression with more tokens than `ParsingOptions.MaxTokens`.
+*/
+var ErrTooManyTokens = errors.New("Expression has too many tokens")
+
+/*
+	Checks the planned [stage] (and all of its children) against any options which restrict what expressions are valid.
+*/
+func (this ParsingOptions) checkStages(stage *evaluationStage) error {
+
+	if stage == nil {
+		return nil
+	}
+
+	if this.StrictTernaryTypes {
+		err := checkTernaryTypes(stage)
+		if err != nil {
+			return err
+		}
+	}
+
+	if this.ForbidDanglingTernary {
+		err := checkDanglingTernaries(stage, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	if this.DisableStringConcat {
+		err := disableStringConcat(stage)
+		if err != nil {
+			return err
+		}
+	}
+
+	if this.RequireBoolResult {
+
+		reason := findNonBoolResult(stage)
+		if reason != "" {
+			return fmt.Errorf("Expression must result in a bool, but %s", reason)
+		}
+	}
+
+	return nil
+}
+
+/*
+	Returns an error if any ternary within the given [stage] has two literal branches of different types.
+*/
+func checkTernaryTypes(stage *evaluationStage) error {
+
+	// literals calculated at parse time are checked as they were written, so that "true ? 1 : 'x'" is still caught.
+	if stage.elided != nil {
+		return checkTernaryTypes(stage.elided)
+	}
+
+	// "a ? b : c" is planned as a TERNARY_FALSE, whose left side is the TERNARY_TRUE "a ? b".
+	if stage.symbol == TERNARY_FALSE && stage.leftStage != nil && stage.rightStage != nil {
+
+		condition := originalStage(stage.leftStage)
+
+		if condition.symbol == TERNARY_TRUE &&
+			condition.rightStage != nil && condition.rightStage.symbol == LITERAL &&
+			stage.rightStage.symbol == LITERAL {
+
+			then, _ := condition.rightStage.operator(nil, nil, nil)
+			otherwise, _ := stage.rightStage.operator(nil, nil, nil)
+
+			thenType := friendlyTypeName(then)
+			otherwiseType := friendlyTypeName(otherwise)
+
+			if thenType != otherwiseType {
+				return fmt.Errorf("Ternary branches have different types: '%v' is a %s, but '%v' is a %s", then, thenType, otherwise, otherwiseType)
+			}
+		}
+	}
+
+	if stage.leftStage != nil {
+		err := checkTernaryTypes(stage.leftStage)
+		if err != nil {
+			return err
+		}
+	}
+
+	if stage.rightStage != nil {
+		return checkTernaryTypes(stage.rightStage)
+	}
+	return nil
+}
+
+/*
+	Returns an error if any ternary "?" within the given [stage] isn't the condition of a ternary ":".
+	[isCondition] is true if the stage is the left side of a ternary ":".
+*/
+func checkDanglingTernaries(stage *evaluationStage, isCondition bool) error {
+
+	if stage.elided != nil {
+		return checkDanglingTernaries(stage.elided, isCondition)
+	}
+
+	if stage.symbol == TERNARY_TRUE && !isCondition {
+		return errors.New("Ternary '?' has no matching ':'")
+	}
+
+	if stage.leftStage != nil {
+		err := checkDanglingTernaries(stage.leftStage, stage.symbol == TERNARY_FALSE)
+		if err != nil {
+			return err
+		}
+	}
+
+	if stage.rightStage != nil {
+		return checkDanglingTernaries(stage.rightStage, false)
+	}
+	return nil
+}
+
+/*
+	Returns an error if the given [stage] concatenates strings with ".." or by adding a string literal.
+	Otherwise, makes every "+" within it require that neither side is a string.
+*/
+func disableStringConcat(stage *evaluationStage) error {
+
+	if stage.elided != nil {
+		return disableStringConcat(stage.elided)
+	}
+
+	switch stage.symbol {
+	case CONCAT:
+		return errors.New("String concatenation is disabled, so '..' can't be used")
+	case PLUS:
+
+		for _, side := range []*evaluationStage{stage.leftStage, stage.rightStage} {
+
+			if side != nil && side.symbol == LITERAL {
+
+				value, _ := side.operator(nil, nil, nil)
+				if isString(value) {
+					return fmt.Errorf("String concatenation is disabled, so '%v' can't be added", value)
+				}
+			}
+		}
+
+		stage.operator = numericAddStage
+		stage.typeCheck = numericAdditionTypeCheck
+	}
+
+	if stage.leftStage != nil {
+		err := disableStringConcat(stage.leftStage)
+		if err != nil {
+			return err
+		}
+	}
+
+	if stage.rightStage != nil {
+		return disableStringConcat(stage.rightStage)
+	}
+	return nil
+}
+
+/*
+	Returns why the result of the given [stage] can't be a bool, or an empty string if it might be.
+	Ternaries can't be a bool if either of their branches can't be.
+*/
+func findNonBoolResult(stage *evaluationStage) string {
+
+	if stage == nil {
+		return ""
+	}
+
+	switch stage.symbol {
+	case NOOP, TERNARY_TRUE:
+		return findNonBoolResult(stage.rightStage)
+	case TERNARY_FALSE, COALESCE:
+
+		reason := findNonBoolResult(stage.leftStage)
+		if reason != "" {
+			return reason
+		}
+		return findNonBoolResult(stage.rightStage)
+	case LITERAL:
+
+		// literals calculated at parse time (like "1 + 2") are checked by what they were calculated to be.
+		value, _ := stage.operator(nil, nil, nil)
+		if !isBool(value) {
+			return fmt.Sprintf("'%v' is a %s", value, friendlyTypeName(value))
+		}
+	case PLUS, MINUS, MULTIPLY, DIVIDE, MODULUS, EXPONENT, CONCAT,
+		BITWISE_AND, BITWISE_OR, BITWISE_XOR, BITWISE_LSHIFT, BITWISE_RSHIFT, NEGATE, BITWISE_NOT:
+		return fmt.Sprintf("the result of '%v' isn't a bool", stage.symbol)
+	case SEPARATE:
+		return "a list isn't a bool"
+	}
+	return ""
+}
+
+/*
+	Returns the stage which the given [stage] was calculated from at parse time, if it was, or [stage] itself otherwise.
+*/
+func originalStage(stage *evaluationStage) *evaluationStage {
+
+	if stage.elided != nil {
+		return stage.elided
+	}
+	return stage
+}
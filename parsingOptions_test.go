@@ -0,0 +1,533 @@
+package govaluate
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStrictTernaryTypes(test *testing.T) {
+
+	strict := ParsingOptions{StrictTernaryTypes: true}
+
+	failures := []string{
+		"cond ? 1 : 'x'",
+		"true ? 1 : 'x'",
+		"cond ? 1 + 1 : true",
+		"a ? (b ? 'y' : 2) : 3",
+		"cond ? 'a' : 1 > 2",
+	}
+
+	for _, input := range failures {
+
+		_, err := NewEvaluableExpressionWithOptions(input, nil, strict)
+		if err == nil || !strings.Contains(err.Error(), "Ternary branches have different types") {
+			test.Logf("Expected '%s' to fail parsing with strict ternary types, got %v", input, err)
+			test.Fail()
+		}
+
+		_, err = NewEvaluableExpression(input)
+		if err != nil {
+			test.Logf("Expected '%s' to parse without strict ternary types, got %v", input, err)
+			test.Fail()
+		}
+	}
+
+	successes := []string{
+		"cond ? 1 : 2",
+		"cond ? 'a' : 'b'",
+		"cond ? foo : 'x'",
+		"cond ? 1 : foo ? 2 : 3",
+	}
+
+	for _, input := range successes {
+
+		_, err := NewEvaluableExpressionWithOptions(input, nil, strict)
+		if err != nil {
+			test.Logf("Expected '%s' to parse with strict ternary types, got %v", input, err)
+			test.Fail()
+		}
+	}
+}
+
+func TestForbidDanglingTernary(test *testing.T) {
+
+	options := ParsingOptions{ForbidDanglingTernary: true}
+
+	failures := []string{
+		"cond ? 1",
+		"true ? 1",
+		"(cond ? 1) ?? 2",
+		"a ? (b ? 1) : 2",
+		"a ? 1 : (b ? 2)",
+	}
+
+	for _, input := range failures {
+
+		_, err := NewEvaluableExpressionWithOptions(input, nil, options)
+		if err == nil || !strings.Contains(err.Error(), "has no matching ':'") {
+			test.Logf("Expected '%s' to fail parsing with dangling ternaries forbidden, got %v", input, err)
+			test.Fail()
+		}
+	}
+
+	successes := []string{
+		"cond ? 1 : 2",
+		"true ? 1 : 2",
+		"a ? (b ? 1 : 2) : 3",
+		"a ? 1 : b ? 2 : 3",
+		"(a ? 1 : 2) > 1 ? 'x' : 'y'",
+	}
+
+	for _, input := range successes {
+
+		_, err := NewEvaluableExpressionWithOptions(input, nil, options)
+		if err != nil {
+			test.Logf("Expected '%s' to parse with dangling ternaries forbidden, got %v", input, err)
+			test.Fail()
+		}
+	}
+}
+
+func TestStringInterner(test *testing.T) {
+
+	interner := new(StringInterner)
+	options := ParsingOptions{Interner: interner}
+
+	first, _ := NewEvaluableExpressionWithOptions("name == 'administrator' || role == 'administrator'", nil, options)
+	second, _ := NewEvaluableExpressionWithOptions("group == 'administrator' && name != 'guest'", nil, options)
+
+	if interner.Len() != 2 {
+		test.Logf("Expected 2 interned strings, got %d", interner.Len())
+		test.Fail()
+	}
+
+	literals := make([]string, 0)
+	for _, expression := range []*EvaluableExpression{first, second} {
+		for _, token := range expression.Tokens() {
+			if token.Value == "administrator" {
+				literals = append(literals, token.Value.(string))
+			}
+		}
+	}
+
+	if len(literals) != 3 {
+		test.Logf("Expected 3 'administrator' literals, got %d", len(literals))
+		test.Fail()
+		return
+	}
+
+	for _, literal := range literals {
+		if literal != "administrator" {
+			test.Logf("Expected interned literals to keep their values, got '%s'", literal)
+			test.Fail()
+		}
+	}
+
+	// another copy of a string which is already held is found, rather than added.
+	interned := interner.Intern(strings.ToLower("ADMINISTRATOR"))
+	if interned != "administrator" || interner.Len() != 2 {
+		test.Logf("Expected an interned string to be found again, got '%s' with %d interned strings", interned, interner.Len())
+		test.Fail()
+	}
+
+	result, err := second.Evaluate(map[string]interface{}{"group": "administrator", "name": "foo"})
+	if err != nil || result != true {
+		test.Logf("Expected interned expression to evaluate to true, got %v, %v", result, err)
+		test.Fail()
+	}
+}
+
+func TestWordOperators(test *testing.T) {
+
+	options := ParsingOptions{WordOperators: true}
+
+	// each pair should be read as exactly the same tokens.
+	equivalents := [][2]string{
+		[2]string{"a > 1 and b", "a > 1 && b"},
+		[2]string{"a OR not b", "a || !b"},
+		[2]string{"not (a and b) or c", "!(a && b) || c"},
+		[2]string{"a and -x > 0", "a && -x > 0"},
+	}
+
+	for _, pair := range equivalents {
+
+		words, err := NewEvaluableExpressionWithOptions(pair[0], nil, options)
+		if err != nil {
+			test.Logf("Expected '%s' to parse with word operators, got %v", pair[0], err)
+			test.Fail()
+			continue
+		}
+
+		symbols, _ := NewEvaluableExpression(pair[1])
+
+		if !reflect.DeepEqual(words.Tokens(), symbols.Tokens()) {
+			test.Logf("Expected '%s' to have the same tokens as '%s', got %v", pair[0], pair[1], words.Tokens())
+			test.Fail()
+		}
+	}
+
+	expression, _ := NewEvaluableExpressionWithOptions("age >= 18 and not banned", nil, options)
+
+	result, err := expression.Evaluate(map[string]interface{}{"age": 20, "banned": false})
+	if err != nil || result != true {
+		test.Logf("Expected word operators to evaluate to true, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	// without the option, they're still parameter names.
+	expression, err = NewEvaluableExpression("and == not")
+	if err != nil {
+		test.Logf("Expected 'and' and 'not' to be parameters by default, got %v", err)
+		test.Fail()
+		return
+	}
+
+	result, err = expression.Evaluate(map[string]interface{}{"and": 1, "not": 1})
+	if err != nil || result != true {
+		test.Logf("Expected parameters named 'and' and 'not' to evaluate to true, got %v, %v", result, err)
+		test.Fail()
+	}
+}
+
+func TestEmptyExpressions(test *testing.T) {
+
+	inputs := []string{"", "   ", "\t\n"}
+
+	for _, input := range inputs {
+
+		_, err := NewEvaluableExpression(input)
+		if err != ErrEmptyExpression {
+			test.Logf("Expected '%s' to fail parsing with ErrEmptyExpression, got %v", input, err)
+			test.Fail()
+		}
+
+		expression, err := NewEvaluableExpressionWithOptions(input, nil, ParsingOptions{AllowEmpty: true, EmptyResult: true})
+		if err != nil {
+			test.Logf("Expected '%s' to parse when empty expressions are allowed, got %v", input, err)
+			test.Fail()
+			continue
+		}
+
+		result, err := expression.Evaluate(map[string]interface{}{"foo": 1})
+		if err != nil || result != true {
+			test.Logf("Expected '%s' to evaluate to the empty result, got %v, %v", input, result, err)
+			test.Fail()
+		}
+	}
+
+	// without a result, empty expressions evaluate to nil.
+	expression, _ := NewEvaluableExpressionWithOptions("", nil, ParsingOptions{AllowEmpty: true})
+
+	result, err := expression.Evaluate(nil)
+	if err != nil || result != nil {
+		test.Logf("Expected an empty expression to evaluate to nil, got %v, %v", result, err)
+		test.Fail()
+	}
+}
+
+func TestMaxTokens(test *testing.T) {
+
+	options := ParsingOptions{MaxTokens: 5}
+
+	// "(a + 1) * 2" has 7 tokens.
+	_, err := NewEvaluableExpressionWithOptions("a + 1 * 2", nil, options)
+	if err != nil {
+		test.Logf("Expected an expression within the token limit to parse, got %v", err)
+		test.Fail()
+	}
+
+	_, err = NewEvaluableExpressionWithOptions("(a + 1) * 2", nil, options)
+	if err != ErrTooManyTokens {
+		test.Logf("Expected an expression over the token limit to fail with ErrTooManyTokens, got %v", err)
+		test.Fail()
+	}
+
+	// the limit is checked before the rest of the expression is read, so later syntax errors aren't found.
+	_, err = NewEvaluableExpressionWithOptions("1 + 2 + 3 + 'unclosed", nil, options)
+	if err != ErrTooManyTokens {
+		test.Logf("Expected the token limit to be checked while reading, got %v", err)
+		test.Fail()
+	}
+
+	options.Tokenizer = DefaultTokenizer
+
+	_, err = NewEvaluableExpressionWithOptions("(a + 1) * 2", nil, options)
+	if err != ErrTooManyTokens {
+		test.Logf("Expected the token limit to apply to a custom tokenizer, got %v", err)
+		test.Fail()
+	}
+}
+
+func TestDisableStringConcat(test *testing.T) {
+
+	options := ParsingOptions{DisableStringConcat: true}
+
+	failures := []string{
+		"1 + 'a'",
+		"'a' + 'b'",
+		"x + (1 + '2')",
+		"a .. b",
+		"1 .. 2",
+	}
+
+	for _, input := range failures {
+
+		_, err := NewEvaluableExpressionWithOptions(input, nil, options)
+		if err == nil || !strings.Contains(err.Error(), "String concatenation is disabled") {
+			test.Logf("Expected '%s' to fail parsing with string concatenation disabled, got %v", input, err)
+			test.Fail()
+		}
+	}
+
+	expression, _ := NewEvaluableExpressionWithOptions("a + b", nil, options)
+
+	result, err := expression.Evaluate(map[string]interface{}{"a": 1, "b": 2})
+	if err != nil || result != 3.0 {
+		test.Logf("Expected numbers to still be added, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	result, err = expression.Evaluate(map[string]interface{}{"a": time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), "b": time.Hour})
+	if err != nil || result != time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC) {
+		test.Logf("Expected durations to still be added to times, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	_, err = expression.Evaluate(map[string]interface{}{"a": "1", "b": 2})
+	if err == nil {
+		test.Logf("Expected adding a string parameter to be an error with string concatenation disabled")
+		test.Fail()
+	}
+
+	// without the option, the same expression concatenates.
+	expression, _ = NewEvaluableExpression("a + b")
+
+	result, err = expression.Evaluate(map[string]interface{}{"a": "1", "b": 2})
+	if err != nil || result != "12" {
+		test.Logf("Expected '12' by default, got %v, %v", result, err)
+		test.Fail()
+	}
+}
+
+func TestUnknownFunction(test *testing.T) {
+
+	var called []string
+
+	options := ParsingOptions{
+		UnknownFunction: func(name string, arguments []interface{}) (interface{}, error) {
+
+			called = append(called, name)
+			if name == "broken" {
+				return nil, errors.New("No plugin for 'broken'")
+			}
+			return float64(len(arguments)), nil
+		},
+	}
+
+	functions := map[string]ExpressionFunction{
+		"known": func(arguments ...interface{}) (interface{}, error) {
+			return 10.0, nil
+		},
+	}
+
+	expression, err := NewEvaluableExpressionWithOptions("plugin(1, 2, 3) + known() + round(2.4, 0) + other()", functions, options)
+	if err != nil {
+		test.Logf("Expected unknown functions to parse, got %v", err)
+		test.Fail()
+		return
+	}
+
+	// given and built-in functions are still used when they exist.
+	result, err := expression.Evaluate(nil)
+	if err != nil || result != 15.0 {
+		test.Logf("Expected 15, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	if !reflect.DeepEqual(called, []string{"plugin", "other"}) {
+		test.Logf("Expected the handler to be called for 'plugin' and 'other', got %v", called)
+		test.Fail()
+	}
+
+	// names which aren't called are still parameters.
+	expression, _ = NewEvaluableExpressionWithOptions("plugin + 1", nil, options)
+
+	result, err = expression.Evaluate(map[string]interface{}{"plugin": 2})
+	if err != nil || result != 3.0 {
+		test.Logf("Expected an uncalled name to be a parameter, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	expression, _ = NewEvaluableExpressionWithOptions("broken(1)", nil, options)
+
+	_, err = expression.Evaluate(nil)
+	if err == nil || !strings.Contains(err.Error(), "No plugin for 'broken'") {
+		test.Logf("Expected the handler's error, got %v", err)
+		test.Fail()
+	}
+
+	// without a handler, calling an unknown function is a parsing error.
+	_, err = NewEvaluableExpression("plugin(1)")
+	if err == nil {
+		test.Logf("Expected an unknown function to fail parsing without a handler")
+		test.Fail()
+	}
+}
+
+func TestRequireBoolResult(test *testing.T) {
+
+	options := ParsingOptions{RequireBoolResult: true}
+
+	failures := []struct {
+		input    string
+		expected string
+	}{
+		{"a + b", "the result of '+' isn't a bool"},
+		{"(a - 1)", "the result of '-' isn't a bool"},
+		{"1 + 2", "'3' is a number"},
+		{"'yes'", "'yes' is a string"},
+		{"x > 1 ? 'yes' : 'no'", "'yes' is a string"},
+		{"x > 1 ? true : 0", "'0' is a number"},
+		{"flag ?? 1", "'1' is a number"},
+		{"a, b", "a list isn't a bool"},
+		{"-a", "the result of '-' isn't a bool"},
+	}
+
+	for _, failure := range failures {
+
+		_, err := NewEvaluableExpressionWithOptions(failure.input, nil, options)
+		if err == nil || !strings.Contains(err.Error(), failure.expected) {
+			test.Logf("Expected '%s' to fail with '%s', got %v", failure.input, failure.expected, err)
+			test.Fail()
+		}
+	}
+
+	// anything which might be a bool is allowed, including results which can't be known until evaluation.
+	successes := []string{
+		"a > b",
+		"a + b > 1 && !c",
+		"(a =~ 'x') || b in (1, 2)",
+		"true",
+		"1 < 2",
+		"flag",
+		"flag ?? false",
+		"x > 1 ? a : b == c",
+		"isValid(a)",
+	}
+
+	functions := map[string]ExpressionFunction{
+		"isValid": func(arguments ...interface{}) (interface{}, error) {
+			return true, nil
+		},
+	}
+
+	for _, input := range successes {
+
+		_, err := NewEvaluableExpressionWithOptions(input, functions, options)
+		if err != nil {
+			test.Logf("Expected '%s' to be allowed, got %v", input, err)
+			test.Fail()
+		}
+	}
+}
+
+func TestPrecedence(test *testing.T) {
+
+	additionFirst := DefaultPrecedence()
+	additionFirst[PLUS] = 10
+	additionFirst[MINUS] = 10
+
+	rightShiftFirst := DefaultPrecedence()
+	rightShiftFirst[BITWISE_RSHIFT] = 9
+
+	cases := []struct {
+		input      string
+		precedence map[OperatorSymbol]int
+		expected   interface{}
+	}{
+		{"2 + 3 * 4", DefaultPrecedence(), 14.0},
+		{"2 + 3 * 4 - 1", additionFirst, 15.0},
+		{"10 - 2 - 3", additionFirst, 5.0},
+		{"(2 + 3) * 4 == 20 && true", additionFirst, true},
+		{"max(1 + 1 * 3, 2)", additionFirst, 6.0},
+		{"16 >> 1 + 1", rightShiftFirst, 9.0},
+		{"2 * 3 ** 2", map[OperatorSymbol]int{MULTIPLY: 9}, 36.0},
+		{"true || false && false", map[OperatorSymbol]int{OR: 3}, false},
+		{"1 + 2 * 3 > 6 ? 'a' : 'b'", map[OperatorSymbol]int{}, "a"},
+	}
+
+	functions := map[string]ExpressionFunction{
+		"max": func(arguments ...interface{}) (interface{}, error) {
+			return math.Max(arguments[0].(float64), arguments[1].(float64)), nil
+		},
+	}
+
+	for _, testCase := range cases {
+
+		expression, err := NewEvaluableExpressionWithOptions(testCase.input, functions, ParsingOptions{Precedence: testCase.precedence})
+		if err != nil {
+			test.Logf("Unable to parse '%s': %v", testCase.input, err)
+			test.Fail()
+			continue
+		}
+
+		result, err := expression.Evaluate(nil)
+		if err != nil || result != testCase.expected {
+			test.Logf("Expected '%s' to be %v, got %v, %v", testCase.input, testCase.expected, result, err)
+			test.Fail()
+		}
+	}
+
+	// giving && and || the same precedence means that mixing them requires parentheses.
+	sameLogical := map[OperatorSymbol]int{AND: 1, OR: 1}
+
+	_, err := NewEvaluableExpressionWithOptions("a && b || c", nil, ParsingOptions{Precedence: sameLogical})
+	if err == nil || !strings.Contains(err.Error(), "need parentheses") {
+		test.Logf("Expected mixing operators of the same configured precedence to fail, got %v", err)
+		test.Fail()
+	}
+
+	expression, err := NewEvaluableExpressionWithOptions("(a && b) || (c && (d || e))", nil, ParsingOptions{Precedence: sameLogical})
+	if err != nil {
+		test.Logf("Expected parenthesized operators of the same precedence to parse, got %v", err)
+		test.Fail()
+	} else {
+
+		result, err := expression.Evaluate(map[string]interface{}{"a": false, "b": true, "c": true, "d": false, "e": true})
+		if err != nil || result != true {
+			test.Logf("Expected parenthesized logic to be true, got %v, %v", result, err)
+			test.Fail()
+		}
+	}
+
+	expression, err = NewEvaluableExpressionWithOptions("a || b || c", nil, ParsingOptions{Precedence: sameLogical})
+	if err != nil {
+		test.Logf("Expected repeating one operator to parse, got %v", err)
+		test.Fail()
+	}
+
+	_, err = NewEvaluableExpressionWithOptions("a ? b : c", nil, ParsingOptions{Precedence: map[OperatorSymbol]int{TERNARY_TRUE: 1}})
+	if err == nil || !strings.Contains(err.Error(), "can't be changed") {
+		test.Logf("Expected changing a ternary's precedence to fail, got %v", err)
+		test.Fail()
+	}
+
+	_, err = NewEvaluableExpressionWithOptions("a + b", nil, ParsingOptions{Precedence: map[OperatorSymbol]int{PLUS: 0}})
+	if err == nil || !strings.Contains(err.Error(), "at least 1") {
+		test.Logf("Expected a precedence below 1 to fail, got %v", err)
+		test.Fail()
+	}
+
+	// a modifier sharing a level with comparators still reports its own kind of type error.
+	expression, _ = NewEvaluableExpressionWithOptions("a - b", nil, ParsingOptions{Precedence: map[OperatorSymbol]int{MINUS: 3}})
+
+	_, err = expression.Evaluate(map[string]interface{}{"a": true, "b": 1})
+	if err == nil || !strings.Contains(err.Error(), "modifier") {
+		test.Logf("Expected a modifier type error, got %v", err)
+		test.Fail()
+	}
+}
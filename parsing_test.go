@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 	"unicode"
@@ -281,6 +283,34 @@ func TestConstantParsing(test *testing.T) {
 				},
 			},
 		},
+		TokenParsingTest{
+			Name:      "Function with whitespace before parenthesis",
+			Input:     "foo  ('bar', 1.0)",
+			Functions: map[string]ExpressionFunction{"foo": noop},
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  FUNCTION,
+					Value: noop,
+				},
+				ExpressionToken{
+					Kind: CLAUSE,
+				},
+				ExpressionToken{
+					Kind:  STRING,
+					Value: "bar",
+				},
+				ExpressionToken{
+					Kind: SEPARATOR,
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 1.0,
+				},
+				ExpressionToken{
+					Kind: CLAUSE_CLOSE,
+				},
+			},
+		},
 		TokenParsingTest{
 			Name:      "Nested function",
 			Input:     "foo(foo('bar'), 1.0, foo(2.0))",
@@ -1141,12 +1171,275 @@ func TestModifierParsing(test *testing.T) {
 				},
 			},
 		},
+		TokenParsingTest{
+
+			Name:  "Numeric CONCAT",
+			Input: "1.5 .. 2",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 1.5,
+				},
+				ExpressionToken{
+					Kind:  MODIFIER,
+					Value: "..",
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 2.0,
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Variable CONCAT",
+			Input: "foo.Bar .. baz",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  ACCESSOR,
+					Value: []string{"foo", "Bar"},
+				},
+				ExpressionToken{
+					Kind:  MODIFIER,
+					Value: "..",
+				},
+				ExpressionToken{
+					Kind:  VARIABLE,
+					Value: "baz",
+				},
+			},
+		},
 	}
 
 	tokenParsingTests = combineWhitespaceExpressions(tokenParsingTests)
 	runTokenParsingTest(tokenParsingTests, test)
 }
 
+/*
+	Tests that a "%" directly after a number, with nothing to operate on, is read as a percentage rather than a modulus.
+*/
+func TestPercentLiterals(test *testing.T) {
+
+	tokenParsingTests := []TokenParsingTest{
+
+		TokenParsingTest{
+
+			Name:  "Percentage",
+			Input: "50%",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 0.5,
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Percentage in an expression",
+			Input: "price * (1 - 15%) > 10.5%",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  VARIABLE,
+					Value: "price",
+				},
+				ExpressionToken{
+					Kind:  MODIFIER,
+					Value: "*",
+				},
+				ExpressionToken{
+					Kind: CLAUSE,
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 1.0,
+				},
+				ExpressionToken{
+					Kind:  MODIFIER,
+					Value: "-",
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 0.15,
+				},
+				ExpressionToken{
+					Kind: CLAUSE_CLOSE,
+				},
+				ExpressionToken{
+					Kind:  COMPARATOR,
+					Value: ">",
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 0.105,
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Modulus by a negated number",
+			Input: "15% - 2",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 15.0,
+				},
+				ExpressionToken{
+					Kind:  MODIFIER,
+					Value: "%",
+				},
+				ExpressionToken{
+					Kind:  PREFIX,
+					Value: "-",
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 2.0,
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Modulus with a parameter",
+			Input: "x % 3",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  VARIABLE,
+					Value: "x",
+				},
+				ExpressionToken{
+					Kind:  MODIFIER,
+					Value: "%",
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 3.0,
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Modulus without whitespace",
+			Input: "50%3",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 50.0,
+				},
+				ExpressionToken{
+					Kind:  MODIFIER,
+					Value: "%",
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 3.0,
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Modulus by a parameter",
+			Input: "50% x",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 50.0,
+				},
+				ExpressionToken{
+					Kind:  MODIFIER,
+					Value: "%",
+				},
+				ExpressionToken{
+					Kind:  VARIABLE,
+					Value: "x",
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Modulus by a negative number",
+			Input: "50% -3",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 50.0,
+				},
+				ExpressionToken{
+					Kind:  MODIFIER,
+					Value: "%",
+				},
+				ExpressionToken{
+					Kind:  PREFIX,
+					Value: "-",
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 3.0,
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Percentage in a list",
+			Input: "rate in (5%, 10%)",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  VARIABLE,
+					Value: "rate",
+				},
+				ExpressionToken{
+					Kind:  COMPARATOR,
+					Value: "in",
+				},
+				ExpressionToken{
+					Kind: CLAUSE,
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 0.05,
+				},
+				ExpressionToken{
+					Kind:  SEPARATOR,
+					Value: ",",
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 0.1,
+				},
+				ExpressionToken{
+					Kind: CLAUSE_CLOSE,
+				},
+			},
+		},
+	}
+
+	runTokenParsingTest(tokenParsingTests, test)
+
+	// a "%" followed by a "-" was always a modulus, so these keep the results they had before percentages.
+	evaluationTests := []EvaluationTest{
+
+		EvaluationTest{
+
+			Name:     "Modulus by a negated number",
+			Input:    "100% - 5",
+			Expected: 0.0,
+		},
+		EvaluationTest{
+
+			Name:     "Modulus by a negative number",
+			Input:    "100 % -5",
+			Expected: 0.0,
+		},
+		EvaluationTest{
+
+			Name:     "Modulus by a negated number with a remainder",
+			Input:    "7% - 5",
+			Expected: 2.0,
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+}
+
 func TestPrefixParsing(test *testing.T) {
 
 	testCases := []TokenParsingTest{
@@ -1284,6 +1577,44 @@ func TestEscapedParameters(test *testing.T) {
 				},
 			},
 		},
+		TokenParsingTest{
+
+			Name:  "Backtick-quoted parameter with dots and spaces",
+			Input: "`user.id` > `first name`",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  VARIABLE,
+					Value: "user.id",
+				},
+				ExpressionToken{
+					Kind:  COMPARATOR,
+					Value: ">",
+				},
+				ExpressionToken{
+					Kind:  VARIABLE,
+					Value: "first name",
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Backtick-quoted parameter with escaped backtick",
+			Input: "`foo\\`bar`==1",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  VARIABLE,
+					Value: "foo`bar",
+				},
+				ExpressionToken{
+					Kind:  COMPARATOR,
+					Value: "==",
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 1.0,
+				},
+			},
+		},
 		TokenParsingTest{
 
 			Name:  "Escaped parameters and unescaped parameters",
@@ -1516,6 +1847,81 @@ func TestOriginalVars(test *testing.T) {
 	}
 }
 
+func TestLiterals(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("name =~ '^adm.*' && age >= 18 && (token == 'hunter2' || debug == true) && elapsed < 5m")
+
+	literals := expression.Literals()
+	if len(literals) != 5 {
+		test.Logf("Expected 5 literals, got %v", literals)
+		test.FailNow()
+	}
+
+	pattern, ok := literals[0].(*regexp.Regexp)
+	if !ok || pattern.String() != "^adm.*" {
+		test.Logf("Expected the first literal to be the pattern '^adm.*', got %v", literals[0])
+		test.Fail()
+	}
+
+	expected := []interface{}{18.0, "hunter2", true, 5 * time.Minute}
+	for i, value := range expected {
+
+		if literals[i+1] != value {
+			test.Logf("Expected literal %d to be %v, got %v", i+1, value, literals[i+1])
+			test.Fail()
+		}
+	}
+
+	expression, _ = NewEvaluableExpression("foo + bar")
+	if len(expression.Literals()) != 0 {
+		test.Logf("Expected no literals, got %v", expression.Literals())
+		test.Fail()
+	}
+}
+
+func TestIsConstant(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"square": func(arguments ...interface{}) (interface{}, error) {
+			return arguments[0].(float64) * arguments[0].(float64), nil
+		},
+		"random": func(arguments ...interface{}) (interface{}, error) {
+			return 4.0, nil
+		},
+	}
+	options := ParsingOptions{PureFunctions: []string{"square"}}
+
+	constantTests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 + 2 * 3", true},
+		{"'a' .. 'b' == 'ab' ? (1, 2) : (3)", true},
+		{"square(2) > 3", true},
+		{"try(square('x'), 0)", true},
+		{"foo + 1", false},
+		{"1 > 2 && foo.Bar", false},
+		{"square(foo)", false},
+		{"random() > 3", false},
+		{"upper('a')", false},
+	}
+
+	for _, constantTest := range constantTests {
+
+		expression, err := NewEvaluableExpressionWithOptions(constantTest.input, functions, options)
+		if err != nil {
+			test.Logf("Unable to parse '%s': %v", constantTest.input, err)
+			test.Fail()
+			continue
+		}
+
+		if expression.IsConstant() != constantTest.expected {
+			test.Logf("Expected IsConstant() of '%s' to be %v", constantTest.input, constantTest.expected)
+			test.Fail()
+		}
+	}
+}
+
 func combineWhitespaceExpressions(testCases []TokenParsingTest) []TokenParsingTest {
 
 	var currentCase, strippedCase TokenParsingTest
@@ -1668,3 +2074,89 @@ func runTokenParsingTest(tokenParsingTests []TokenParsingTest, test *testing.T)
 func noop(arguments ...interface{}) (interface{}, error) {
 	return nil, nil
 }
+
+func TestRawStrings(test *testing.T) {
+
+	tokenParsingTests := []TokenParsingTest{
+
+		TokenParsingTest{
+
+			Name:  "Raw string",
+			Input: `r"\d+"`,
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  STRING,
+					Value: `\d+`,
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Raw single-quoted string",
+			Input: `r'a\'`,
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  STRING,
+					Value: `a\`,
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Raw string containing the other quote",
+			Input: `r"it's"`,
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  STRING,
+					Value: "it's",
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Escaped string",
+			Input: `"a\"b"`,
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  STRING,
+					Value: `a"b`,
+				},
+			},
+		},
+		TokenParsingTest{
+
+			Name:  "Parameter named r",
+			Input: "r + 1",
+			Expected: []ExpressionToken{
+				ExpressionToken{
+					Kind:  VARIABLE,
+					Value: "r",
+				},
+				ExpressionToken{
+					Kind:  MODIFIER,
+					Value: "+",
+				},
+				ExpressionToken{
+					Kind:  NUMERIC,
+					Value: 1.0,
+				},
+			},
+		},
+	}
+
+	runTokenParsingTest(tokenParsingTests, test)
+
+	expression, _ := NewEvaluableExpression(`name =~ r"^\d+\.\d+$"`)
+
+	result, err := expression.Evaluate(map[string]interface{}{"name": "3.14"})
+	if err != nil || result != true {
+		test.Logf("Expected a raw string to be usable as a regex, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	_, err = NewEvaluableExpression(`r"unclosed`)
+	if err == nil || !strings.Contains(err.Error(), "Unclosed raw string literal") {
+		test.Logf("Expected an unclosed raw string to fail parsing, got %v", err)
+		test.Fail()
+	}
+}
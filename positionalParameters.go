@@ -0,0 +1,231 @@
+package govaluate
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*
+	The position of each parameter used by an expression, as used by PositionalParameters.
+*/
+type parameterSlots struct {
+	names   []string
+	indices map[string]int
+}
+
+/*
+	PositionalParameters holds the value of each parameter used by one expression, in the order given by that expression's `ParameterSlots()`.
+	When evaluating that expression, each parameter is found by its position, rather than by looking up its name,
+	and numeric values have already been converted to float64, so it's the fastest way to evaluate an expression many times.
+	Create them with `EvaluableExpression.PositionalParameters`.
+
+	They can still be used with other expressions (or accessed by name, like any other Parameters),
+	but then each parameter is looked up by name as usual.
+*/
+type PositionalParameters struct {
+	slots  *parameterSlots
+	values []interface{}
+
+	// whether any of the values are thunks, which have to be called (once per evaluation) when they're used.
+	lazy bool
+}
+
+/*
+	Returns the names of the parameters used by this expression, in the order that `PositionalParameters` expects their values.
+	Each name is only given once, in the order it first appears in the expression.
+*/
+func (this EvaluableExpression) ParameterSlots() []string {
+
+	if this.parameterSlots == nil {
+		return []string{}
+	}
+
+	ret := make([]string, len(this.parameterSlots.names))
+	copy(ret, this.parameterSlots.names)
+	return ret
+}
+
+/*
+	Creates parameters for evaluating this expression, with one of the given [values] for each of its `ParameterSlots()`, in that order.
+	Returns an error if the wrong number of values are given.
+	As with any other parameters, values may be thunks (`func() (interface{}, error)`), which are called once per evaluation that uses them.
+*/
+func (this EvaluableExpression) PositionalParameters(values ...interface{}) (*PositionalParameters, error) {
+
+	slots := this.parameterSlots
+	if slots == nil {
+		slots = new(parameterSlots)
+	}
+
+	if len(values) != len(slots.names) {
+		return nil, fmt.Errorf("Expected %d positional parameters, got %d", len(slots.names), len(values))
+	}
+
+	ret := &PositionalParameters{
+		slots:  slots,
+		values: make([]interface{}, len(values)),
+	}
+
+	for i, value := range values {
+
+		_, isThunk := value.(func() (interface{}, error))
+		if isThunk {
+			ret.lazy = true
+		}
+		ret.values[i] = castToFloat64(value)
+	}
+	return ret, nil
+}
+
+func (this *PositionalParameters) Get(name string) (interface{}, error) {
+
+	index, found := this.slots.indices[name]
+	if !found {
+		return nil, errors.New("No parameter '" + name + "' found.")
+	}
+	return this.values[index], nil
+}
+
+/*
+	One parameter in a schema given to `CompileWithSchema`; its name, and the name of its type (as `TypeCheck` names them).
+	An empty Type is the same as "any".
+*/
+type SchemaField struct {
+	Name string
+	Type string
+}
+
+/*
+	Parses the given [expression], with each parameter given the position it has in [schema], rather than the order the expression uses them.
+	So every expression compiled with the same schema takes the same values, in the same order, with `EvaluatePositional`.
+
+	Returns an error if the expression uses a parameter which isn't in the schema,
+	or if it would have a type error given the schema's types (see `TypeCheck`).
+*/
+func CompileWithSchema(expression string, schema []SchemaField) (*EvaluableExpression, error) {
+
+	ret, err := NewEvaluableExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	slots := &parameterSlots{
+		indices: make(map[string]int),
+	}
+	types := make(map[string]string)
+
+	for _, field := range schema {
+
+		_, found := slots.indices[field.Name]
+		if found {
+			return nil, fmt.Errorf("Parameter '%s' is in the schema more than once", field.Name)
+		}
+
+		slots.indices[field.Name] = len(slots.names)
+		slots.names = append(slots.names, field.Name)
+
+		types[field.Name] = field.Type
+		if field.Type == "" {
+			types[field.Name] = anyType
+		}
+	}
+
+	// parameters which are only used through accessors aren't type checked, so make sure they're in the schema here.
+	for _, name := range ret.parameterSlots.names {
+
+		_, found := slots.indices[name]
+		if !found {
+			return nil, fmt.Errorf("Parameter '%s' is not in the schema", name)
+		}
+	}
+
+	err = ret.TypeCheck(types)
+	if err != nil {
+		return nil, err
+	}
+
+	ret.parameterSlots = slots
+	assignParameterSlots(ret.evaluationStages, slots)
+	return ret, nil
+}
+
+/*
+	Evaluates this expression with one of the given [values] for each of its `ParameterSlots()`, in that order.
+	The same as making `PositionalParameters` and evaluating with them, for expressions that are only evaluated once with each set of values.
+*/
+func (this EvaluableExpression) EvaluatePositional(values []interface{}) (interface{}, error) {
+
+	parameters, err := this.PositionalParameters(values...)
+	if err != nil {
+		return nil, err
+	}
+	return this.Eval(parameters)
+}
+
+/*
+	Finds the name of every parameter used by the given [tokens], and gives each a position.
+	Parameters which are only used through an accessor (like "foo" in "foo.Bar") get a position too.
+*/
+func findParameterSlots(tokens []ExpressionToken) *parameterSlots {
+
+	ret := &parameterSlots{
+		indices: make(map[string]int),
+	}
+
+	for _, token := range tokens {
+
+		var name string
+
+		switch token.Kind {
+		case VARIABLE:
+			name = token.Value.(string)
+		case ACCESSOR:
+			name = token.Value.([]string)[0]
+		default:
+			continue
+		}
+
+		_, found := ret.indices[name]
+		if !found {
+			ret.indices[name] = len(ret.names)
+			ret.names = append(ret.names, name)
+		}
+	}
+	return ret
+}
+
+/*
+	Makes every parameter stage within the given [stage] read its parameter by position, when evaluated with PositionalParameters for [slots].
+*/
+func assignParameterSlots(stage *evaluationStage, slots *parameterSlots) {
+
+	if stage == nil {
+		return
+	}
+
+	if stage.symbol == VALUE {
+
+		index, found := slots.indices[stage.name]
+		if found {
+			stage.operator = makeSlotParameterStage(stage.name, slots, index)
+		}
+	}
+
+	assignParameterSlots(stage.leftStage, slots)
+	assignParameterSlots(stage.rightStage, slots)
+}
+
+/*
+	Same as `makeParameterStage`, except that PositionalParameters made for [slots] are read directly from [index].
+*/
+func makeSlotParameterStage(parameterName string, slots *parameterSlots, index int) evaluationOperator {
+
+	return func(left interface{}, right interface{}, parameters Parameters) (interface{}, error) {
+
+		positional, ok := parameters.(*PositionalParameters)
+		if ok && positional.slots == slots {
+			return positional.values[index], nil
+		}
+		return parameters.Get(parameterName)
+	}
+}
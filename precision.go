@@ -0,0 +1,133 @@
+package govaluate
+
+import (
+	"math"
+	"math/big"
+)
+
+/*
+	Determines which numeric results of an EvaluableExpression are rounded to its ResultPrecision.
+*/
+type PrecisionMode int
+
+const (
+	/*
+		Nothing is rounded. This is the default.
+	*/
+	NoRounding PrecisionMode = iota
+
+	/*
+		Only the final result of evaluation is rounded (if it is a number), so intermediate results keep their full precision.
+	*/
+	RoundResult
+
+	/*
+		The result of every division is rounded, as it is calculated.
+	*/
+	RoundDivision
+)
+
+/*
+	If [value] is a float64, rounds it to the given number of decimal places, with halves rounded away from zero (see `roundDecimal`).
+	Any other value is returned unchanged, as are numbers when [precision] is more than maxRoundingPlaces, which rounding can't change.
+*/
+func roundToPrecision(value interface{}, precision int) interface{} {
+
+	number, ok := value.(float64)
+	if !ok || precision > maxRoundingPlaces {
+		return value
+	}
+
+	if precision < -maxRoundingPlaces {
+		precision = -maxRoundingPlaces
+	}
+	return roundDecimal(number, precision, false)
+}
+
+/*
+	Rounds [value] to the given number of decimal [places] (or to tens, hundreds, and so on, if negative).
+	The value is rounded as the decimal it's written as, so 2.675 is a half, even though as a float64 it's slightly less.
+	Halves are rounded to the nearest even digit if [halfEven] is set (banker's rounding), or away from zero otherwise.
+	Infinities and NaN are returned unchanged.
+*/
+func roundDecimal(value float64, places int, halfEven bool) float64 {
+
+	exact, ok := floatToRational(value, 64)
+	if !ok {
+		return value
+	}
+
+	exponent := places
+	if exponent < 0 {
+		exponent = -exponent
+	}
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil))
+
+	if places >= 0 {
+		exact.Mul(exact, scale)
+	} else {
+		exact.Quo(exact, scale)
+	}
+
+	// round the magnitude, so that halves of negative numbers go the same way as positive ones.
+	negative := exact.Sign() < 0
+	exact.Abs(exact)
+
+	whole, remainder := new(big.Int).QuoRem(exact.Num(), exact.Denom(), new(big.Int))
+
+	half := new(big.Int).Lsh(remainder, 1).Cmp(exact.Denom())
+	if half > 0 || (half == 0 && (!halfEven || whole.Bit(0) == 1)) {
+		whole.Add(whole, big.NewInt(1))
+	}
+
+	rounded := new(big.Rat).SetInt(whole)
+	if places >= 0 {
+		rounded.Quo(rounded, scale)
+	} else {
+		rounded.Mul(rounded, scale)
+	}
+
+	if negative {
+		rounded.Neg(rounded)
+	}
+
+	ret, _ := rounded.Float64()
+	return ret
+}
+
+/*
+	Returns positive zero if the given [value] is a zero float64 (including negative zero), or [value] unchanged otherwise.
+*/
+func normalizeZero(value interface{}) interface{} {
+
+	// negative zero is equal to zero, so this catches both.
+	if value == 0.0 {
+		return 0.0
+	}
+	return value
+}
+
+/*
+	A tolerance for `EvaluableExpression.EqualityTolerance` which is far larger than the error of float64 arithmetic,
+	but far smaller than any difference which is likely to matter.
+*/
+const DefaultEqualityTolerance = 1e-9
+
+/*
+	Returns true if [left] and [right] are within [tolerance] of each other,
+	relative to the larger of them (or absolutely, if both are between -1 and 1).
+*/
+func isNearlyEqual(left float64, right float64, tolerance float64) bool {
+
+	if left == right {
+		return true
+	}
+
+	// an infinity is only equal to itself, not to every number large enough.
+	if math.IsInf(left, 0) || math.IsInf(right, 0) {
+		return false
+	}
+
+	scale := math.Max(1, math.Max(math.Abs(left), math.Abs(right)))
+	return math.Abs(left-right) <= tolerance*scale
+}
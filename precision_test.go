@@ -0,0 +1,256 @@
+package govaluate
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+type precisionTest struct {
+	name      string
+	input     string
+	mode      PrecisionMode
+	precision int
+	expected  interface{}
+}
+
+func TestResultPrecision(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"one":   1,
+		"three": 3,
+		"large": 1e300,
+	}
+
+	precisionTests := []precisionTest{
+		precisionTest{
+			name:      "No rounding by default",
+			input:     "one / three",
+			precision: 2,
+			expected:  1.0 / 3.0,
+		},
+		precisionTest{
+			name:      "Rounded result",
+			input:     "one / three",
+			mode:      RoundResult,
+			precision: 2,
+			expected:  0.33,
+		},
+		precisionTest{
+			name:      "Rounded result keeps intermediate precision",
+			input:     "one / three * three",
+			mode:      RoundResult,
+			precision: 2,
+			expected:  1.0,
+		},
+		precisionTest{
+			name:      "Rounded divisions",
+			input:     "one / three * three",
+			mode:      RoundDivision,
+			precision: 2,
+			expected:  0.99,
+		},
+		precisionTest{
+			name:      "Rounded divisions of literals",
+			input:     "1 / 3 * 3",
+			mode:      RoundDivision,
+			precision: 2,
+			expected:  0.99,
+		},
+		precisionTest{
+			name:      "Rounded negative result",
+			input:     "-2 / three",
+			mode:      RoundResult,
+			precision: 1,
+			expected:  -0.7,
+		},
+		precisionTest{
+			name:      "Rounded to whole numbers",
+			input:     "5 / 2",
+			mode:      RoundResult,
+			precision: 0,
+			expected:  3.0,
+		},
+		precisionTest{
+			name:      "Rounded half, as written in decimal",
+			input:     "2.675",
+			mode:      RoundResult,
+			precision: 2,
+			expected:  2.68,
+		},
+		precisionTest{
+			name:      "Rounded large result doesn't overflow",
+			input:     "large / three",
+			mode:      RoundResult,
+			precision: 10,
+			expected:  1e300 / 3,
+		},
+		precisionTest{
+			name:      "Rounded to many places",
+			input:     "one / three",
+			mode:      RoundResult,
+			precision: 330,
+			expected:  1.0 / 3.0,
+		},
+		precisionTest{
+			name:      "Rounded to more places than can matter",
+			input:     "one / three",
+			mode:      RoundResult,
+			precision: 1000000,
+			expected:  1.0 / 3.0,
+		},
+		precisionTest{
+			name:      "Rounded to tens",
+			input:     "one / three * 500",
+			mode:      RoundResult,
+			precision: -1,
+			expected:  170.0,
+		},
+		precisionTest{
+			name:      "Non-numeric results are unchanged",
+			input:     "'a' + one / three",
+			mode:      RoundResult,
+			precision: 2,
+			expected:  "a0.3333333333333333",
+		},
+	}
+
+	for _, precisionTest := range precisionTests {
+
+		expression, err := NewEvaluableExpression(precisionTest.input)
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %v", precisionTest.name, err)
+			test.Fail()
+			continue
+		}
+
+		expression.PrecisionMode = precisionTest.mode
+		expression.ResultPrecision = precisionTest.precision
+
+		result, err := expression.Evaluate(parameters)
+		if err != nil {
+			test.Logf("Test '%s' failed: %v", precisionTest.name, err)
+			test.Fail()
+			continue
+		}
+
+		if result != precisionTest.expected {
+			test.Logf("Test '%s' expected '%v', got '%v'", precisionTest.name, precisionTest.expected, result)
+			test.Fail()
+		}
+	}
+}
+
+func TestNormalizesNegativeZero(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"zero":  0,
+		"small": -0.001,
+	}
+
+	zeroTests := []precisionTest{
+		precisionTest{
+			name:     "Negated zero",
+			input:    "-zero",
+			expected: "0",
+		},
+		precisionTest{
+			name:     "Negative product of zero",
+			input:    "zero * -1",
+			expected: "0",
+		},
+		precisionTest{
+			name:     "Concatenated negated zero",
+			input:    "'' + -zero",
+			expected: "0",
+		},
+		precisionTest{
+			name:     "Negated zero literal",
+			input:    "-0",
+			expected: "0",
+		},
+		precisionTest{
+			name:      "Rounded to zero",
+			input:     "small",
+			mode:      RoundResult,
+			precision: 2,
+			expected:  "0",
+		},
+		precisionTest{
+			name:      "Division rounded to zero",
+			input:     "1 / (small * 1000000)",
+			mode:      RoundDivision,
+			precision: 2,
+			expected:  "0",
+		},
+	}
+
+	for _, zeroTest := range zeroTests {
+
+		expression, _ := NewEvaluableExpression(zeroTest.input)
+		expression.PrecisionMode = zeroTest.mode
+		expression.ResultPrecision = zeroTest.precision
+		expression.NormalizesNegativeZero = true
+
+		result, err := expression.Evaluate(parameters)
+		if err != nil || fmt.Sprint(result) != zeroTest.expected {
+			test.Logf("Test '%s' expected %v, got %v, %v", zeroTest.name, zeroTest.expected, result, err)
+			test.Fail()
+		}
+	}
+
+	// without the option, negative zero is left alone.
+	expression, _ := NewEvaluableExpression("-zero")
+
+	result, _ := expression.Evaluate(parameters)
+	if fmt.Sprint(result) != "-0" {
+		test.Logf("Expected negative zero by default, got %v", result)
+		test.Fail()
+	}
+}
+
+func TestEqualityTolerance(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"total": 1e12,
+		"big":   1e308,
+		"inf":   math.Inf(1),
+	}
+
+	toleranceTests := []struct {
+		input    string
+		expected bool
+	}{
+		{"0.1 + 0.2 == 0.3", true},
+		{"0.1 + 0.2 != 0.3", false},
+		{"0.1 * 3 == 0.3", true},
+		{"0.3 == 0.3000001", false},
+		{"total + 0.0001 == total", true},
+		{"total + 10000 == total", false},
+		{"inf == big", false},
+		{"inf == inf", true},
+		{"0.1 + 0.2 > 0.3", true},
+		{"'a' == 'a'", true},
+	}
+
+	for _, toleranceTest := range toleranceTests {
+
+		expression, _ := NewEvaluableExpression(toleranceTest.input)
+		expression.EqualityTolerance = DefaultEqualityTolerance
+
+		result, err := expression.Evaluate(parameters)
+		if err != nil || result != toleranceTest.expected {
+			test.Logf("Expected '%s' to be %v, got %v, %v", toleranceTest.input, toleranceTest.expected, result, err)
+			test.Fail()
+		}
+	}
+
+	// without a tolerance, floats are compared exactly.
+	expression, _ := NewEvaluableExpression("0.1 + 0.2 == 0.3")
+
+	result, _ := expression.Evaluate(nil)
+	if result != false {
+		test.Logf("Expected exact comparison by default, got %v", result)
+		test.Fail()
+	}
+}
@@ -0,0 +1,109 @@
+package govaluate
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+/*
+	Evaluates the given operator [symbol] exactly, with *big.Rat values, for an expression with `ExactRationals` set.
+	Returns false if the operator can't be evaluated that way (such as one which isn't arithmetic or a comparison),
+	or if either side isn't a number, in which case it should be evaluated as usual.
+*/
+func evaluateRational(symbol OperatorSymbol, left interface{}, right interface{}) (interface{}, bool, error) {
+
+	if symbol == NEGATE {
+
+		rightRat, ok := toRational(right)
+		if !ok {
+			return nil, false, nil
+		}
+		return new(big.Rat).Neg(rightRat), true, nil
+	}
+
+	switch symbol {
+	case PLUS, MINUS, MULTIPLY, DIVIDE, EQ, NEQ, GT, GTE, LT, LTE:
+	default:
+		return nil, false, nil
+	}
+
+	leftRat, ok := toRational(left)
+	if !ok {
+		return nil, false, nil
+	}
+
+	rightRat, ok := toRational(right)
+	if !ok {
+		return nil, false, nil
+	}
+
+	switch symbol {
+	case PLUS:
+		return new(big.Rat).Add(leftRat, rightRat), true, nil
+	case MINUS:
+		return new(big.Rat).Sub(leftRat, rightRat), true, nil
+	case MULTIPLY:
+		return new(big.Rat).Mul(leftRat, rightRat), true, nil
+	case DIVIDE:
+		if rightRat.Sign() == 0 {
+			return nil, true, errors.New("Division by zero")
+		}
+		return new(big.Rat).Quo(leftRat, rightRat), true, nil
+	case EQ:
+		return leftRat.Cmp(rightRat) == 0, true, nil
+	case NEQ:
+		return leftRat.Cmp(rightRat) != 0, true, nil
+	case GT:
+		return leftRat.Cmp(rightRat) > 0, true, nil
+	case GTE:
+		return leftRat.Cmp(rightRat) >= 0, true, nil
+	case LT:
+		return leftRat.Cmp(rightRat) < 0, true, nil
+	}
+	return leftRat.Cmp(rightRat) <= 0, true, nil
+}
+
+/*
+	Returns the given [value] as a *big.Rat, if it's a number.
+	A float is read as the shortest decimal which it's the closest float64 to, so 0.1 is exactly 1/10
+	(rather than the binary fraction nearest to it), just as it was written in the expression.
+	Returns false for anything which isn't a number, or for NaN and infinities.
+*/
+func toRational(value interface{}) (*big.Rat, bool) {
+
+	switch value.(type) {
+	case *big.Rat:
+		return value.(*big.Rat), true
+	case *big.Int:
+		return new(big.Rat).SetInt(value.(*big.Int)), true
+	case float64:
+		return floatToRational(value.(float64), 64)
+	}
+
+	if !isNumber(value) {
+		return nil, false
+	}
+
+	reflected := reflect.ValueOf(value)
+
+	switch reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Rat).SetInt64(reflected.Int()), true
+	case reflect.Float32:
+		return floatToRational(reflected.Float(), 32)
+	case reflect.Float64:
+		return floatToRational(reflected.Float(), 64)
+	}
+	return new(big.Rat).SetInt(new(big.Int).SetUint64(reflected.Uint())), true
+}
+
+func floatToRational(value float64, bitSize int) (*big.Rat, bool) {
+
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(strconv.FormatFloat(value, 'g', -1, bitSize))
+}
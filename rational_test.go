@@ -0,0 +1,89 @@
+package govaluate
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestExactRationals(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"amount": 100,
+		"days":   31,
+		"rate":   0.1,
+		"third":  big.NewRat(1, 3),
+		"name":   "x",
+	}
+
+	rationalTests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1 / 3 * 3", big.NewRat(1, 1)},
+		{"amount / days", big.NewRat(100, 31)},
+		{"amount / days * days == amount", true},
+		{"0.1 + 0.2", big.NewRat(3, 10)},
+		{"0.1 + 0.2 == 0.3", true},
+		{"rate * 3", big.NewRat(3, 10)},
+		{"-third", big.NewRat(-1, 3)},
+		{"third + third + third", big.NewRat(1, 1)},
+		{"third > 0.333333", true},
+		{"third < 0.3333334", true},
+		{"third != 1", true},
+		{"amount > 50", true},
+		{"name + third", "x1/3"},
+		{"amount > 50 ? third : 0", big.NewRat(1, 3)},
+	}
+
+	for _, rationalTest := range rationalTests {
+
+		expression, err := NewEvaluableExpression(rationalTest.input)
+		if err != nil {
+			test.Logf("Failed to parse '%s': %v", rationalTest.input, err)
+			test.Fail()
+			continue
+		}
+		expression.ExactRationals = true
+
+		result, err := expression.Evaluate(parameters)
+		if err != nil {
+			test.Logf("Expected '%s' to evaluate to %v, got error %v", rationalTest.input, rationalTest.expected, err)
+			test.Fail()
+			continue
+		}
+
+		expectedRat, isRat := rationalTest.expected.(*big.Rat)
+		if isRat {
+
+			resultRat, ok := result.(*big.Rat)
+			if !ok || resultRat.Cmp(expectedRat) != 0 {
+				test.Logf("Expected '%s' to evaluate to %v, got %v", rationalTest.input, expectedRat, result)
+				test.Fail()
+			}
+			continue
+		}
+
+		if result != rationalTest.expected {
+			test.Logf("Expected '%s' to evaluate to %v, got %v", rationalTest.input, rationalTest.expected, result)
+			test.Fail()
+		}
+	}
+
+	expression, _ := NewEvaluableExpression("amount / (days - 31)")
+	expression.ExactRationals = true
+
+	_, err := expression.Evaluate(parameters)
+	if err == nil {
+		test.Logf("Expected exact division by zero to be an error")
+		test.Fail()
+	}
+
+	// without the option, arithmetic is float64 as usual.
+	expression, _ = NewEvaluableExpression("1 / 3 * 3")
+
+	result, _ := expression.Evaluate(nil)
+	if !isFloat64(result) {
+		test.Logf("Expected a float64 by default, got %v (%T)", result, result)
+		test.Fail()
+	}
+}
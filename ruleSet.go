@@ -0,0 +1,141 @@
+package govaluate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+/*
+	A set of named expressions ("rules") which are parsed together, and can each use any of the others by name,
+	as if it were a parameter (see `NamedExpressions`). Any rule can then be evaluated by name.
+	A RuleSet can't be changed once it's made, so it's safe to evaluate from many goroutines at once.
+*/
+type RuleSet struct {
+	rules NamedExpressions
+}
+
+/*
+	Parses each of the given [rules] (a map of each rule's name to its expression), which may call any of the given [functions].
+	Returns an error if any rule fails to parse, or if any rules refer to each other in a circle.
+*/
+func NewRuleSet(rules map[string]string, functions map[string]ExpressionFunction) (*RuleSet, error) {
+	return NewRuleSetWithOptions(rules, functions, ParsingOptions{})
+}
+
+/*
+	Similar to [NewRuleSet], except that the given [options] change how every rule is parsed. See `ParsingOptions`.
+*/
+func NewRuleSetWithOptions(rules map[string]string, functions map[string]ExpressionFunction, options ParsingOptions) (*RuleSet, error) {
+
+	ret := &RuleSet{rules: make(NamedExpressions, len(rules))}
+
+	for name, expression := range rules {
+
+		parsed, err := NewEvaluableExpressionWithOptions(expression, functions, options)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse rule '%s': %w", name, err)
+		}
+		ret.rules[name] = parsed
+	}
+
+	// check in a consistent order, so that the same circle is always the one reported.
+	visited := make(map[string]bool)
+	for _, name := range ret.Names() {
+
+		err := ret.checkReferences(name, visited, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+/*
+	Returns an error if the rule of the given [name] refers (directly or through other rules) back to any rule in [path],
+	which are the rules that refer to it. Rules which have already been checked are in [visited].
+*/
+func (this *RuleSet) checkReferences(name string, visited map[string]bool, path []string) error {
+
+	for i, previous := range path {
+		if previous == name {
+			return fmt.Errorf("Rule '%s' refers to itself, through %v", name, append(path[i:], name))
+		}
+	}
+
+	if visited[name] {
+		return nil
+	}
+
+	path = append(path, name)
+	for _, reference := range this.rules[name].ParameterSlots() {
+
+		_, isRule := this.rules[reference]
+		if !isRule {
+			continue
+		}
+
+		err := this.checkReferences(reference, visited, path)
+		if err != nil {
+			return err
+		}
+	}
+
+	visited[name] = true
+	return nil
+}
+
+/*
+	Returns the names of every rule in this set, in alphabetical order.
+*/
+func (this *RuleSet) Names() []string {
+
+	ret := make([]string, 0, len(this.rules))
+	for name := range this.rules {
+		ret = append(ret, name)
+	}
+
+	sort.Strings(ret)
+	return ret
+}
+
+/*
+	Returns a copy of the parsed expression of the rule of the given [name], or false if there's no such rule.
+	Changing the copy (such as its options) doesn't change the rule in this set.
+	The expression refers to other rules as parameters, so evaluating it on its own (rather than through `Eval`) won't find them.
+*/
+func (this *RuleSet) Rule(name string) (*EvaluableExpression, bool) {
+
+	rule, found := this.rules[name]
+	if !found {
+		return nil, false
+	}
+
+	ret := *rule
+	return &ret, true
+}
+
+/*
+	Same as `Eval`, but automatically wraps a map of parameters into a `govalute.Parameters` structure.
+*/
+func (this *RuleSet) Evaluate(name string, parameters map[string]interface{}) (interface{}, error) {
+
+	if parameters == nil {
+		return this.Eval(name, nil)
+	}
+	return this.Eval(name, MapParameters(parameters))
+}
+
+/*
+	Evaluates the rule of the given [name] with the given [parameters].
+	Any other rules it uses are evaluated against the same parameters (only when they're needed), and take priority
+	over parameters of the same name.
+*/
+func (this *RuleSet) Eval(name string, parameters Parameters) (interface{}, error) {
+
+	rule, found := this.rules[name]
+	if !found {
+		return nil, errors.New("No rule '" + name + "' found.")
+	}
+	return rule.Eval(this.rules.With(parameters))
+}
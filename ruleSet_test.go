@@ -0,0 +1,107 @@
+package govaluate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRuleSet(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"strlen": func(arguments ...interface{}) (interface{}, error) {
+			return float64(len(arguments[0].(string))), nil
+		},
+	}
+
+	rules, err := NewRuleSet(map[string]string{
+		"isAdult":    "age >= 18",
+		"hasName":    "strlen(name) > 0",
+		"canSignUp":  "isAdult && hasName",
+		"greeting":   "canSignUp ? 'Welcome, ' + name : 'Sorry'",
+		"adultCount": "isAdult ? 1 : 0",
+	}, functions)
+
+	if err != nil {
+		test.Logf("Expected the rules to parse, got %v", err)
+		test.Fail()
+		return
+	}
+
+	parameters := map[string]interface{}{"age": 30, "name": "alice"}
+
+	result, err := rules.Evaluate("greeting", parameters)
+	if err != nil || result != "Welcome, alice" {
+		test.Logf("Expected a rule to use other rules, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	parameters["age"] = 12
+
+	result, err = rules.Evaluate("canSignUp", parameters)
+	if err != nil || result != false {
+		test.Logf("Expected rules to be evaluated against the given parameters, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	_, err = rules.Evaluate("missing", parameters)
+	if err == nil || err.Error() != "No rule 'missing' found." {
+		test.Logf("Expected evaluating an unknown rule to fail, got %v", err)
+		test.Fail()
+	}
+
+	names := strings.Join(rules.Names(), ",")
+	if names != "adultCount,canSignUp,greeting,hasName,isAdult" {
+		test.Logf("Expected the names of every rule, in order, got %s", names)
+		test.Fail()
+	}
+
+	rule, found := rules.Rule("isAdult")
+	if !found || rule.String() != "age >= 18" {
+		test.Logf("Expected to find a rule by its name")
+		test.Fail()
+	}
+
+	// the rule returned is a copy, so changing it doesn't change the set.
+	rule.MaxStringBytes = 1
+	rule.NilComparisonsAreFalse = true
+
+	again, _ := rules.Rule("isAdult")
+	if again.MaxStringBytes != 0 || again.NilComparisonsAreFalse {
+		test.Logf("Expected changing a rule returned by Rule() not to change the set")
+		test.Fail()
+	}
+}
+
+func TestRuleSetFailures(test *testing.T) {
+
+	_, err := NewRuleSet(map[string]string{
+		"valid":   "1 > 0",
+		"invalid": "1 >",
+	}, nil)
+
+	if err == nil || !strings.HasPrefix(err.Error(), "Unable to parse rule 'invalid'") {
+		test.Logf("Expected an invalid rule to fail to parse, got %v", err)
+		test.Fail()
+	}
+
+	_, err = NewRuleSet(map[string]string{
+		"a": "b && x",
+		"b": "c || y",
+		"c": "a",
+		"d": "a && c",
+	}, nil)
+
+	if err == nil || err.Error() != "Rule 'a' refers to itself, through [a b c a]" {
+		test.Logf("Expected circular rules to fail, got %v", err)
+		test.Fail()
+	}
+
+	_, err = NewRuleSet(map[string]string{
+		"self": "self.Name == 'x'",
+	}, nil)
+
+	if err == nil || err.Error() != "Rule 'self' refers to itself, through [self self]" {
+		test.Logf("Expected a rule which accesses itself to fail, got %v", err)
+		test.Fail()
+	}
+}
@@ -1,20 +1,66 @@
 package govaluate
 
+import (
+	"reflect"
+	"time"
+)
+
 // sanitizedParameters is a wrapper for Parameters that does sanitization as
-// parameters are accessed.
+// parameters are accessed. Parameters which are thunks (of type
+// `func() (interface{}, error)`) are called the first time they're accessed,
+// and their results are kept for the rest of the evaluation. If there's a
+// coercer, it's given each parameter (after calling any thunk) before it's
+// sanitized.
 type sanitizedParameters struct {
-	orig Parameters
+	orig     Parameters
+	coercer  func(name string, value interface{}) (interface{}, error)
+	resolved map[string]interface{}
 }
 
-func (p sanitizedParameters) Get(key string) (interface{}, error) {
+func (p *sanitizedParameters) Get(key string) (interface{}, error) {
 	value, err := p.orig.Get(key)
 	if err != nil {
 		return nil, err
 	}
 
+	thunk, ok := value.(func() (interface{}, error))
+	if ok {
+		value, err = p.resolve(key, thunk)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.coercer != nil {
+		value, err = p.coercer(key, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return castToFloat64(value), nil
 }
 
+// resolve calls the given thunk for the parameter [key], unless it's already
+// been called during this evaluation.
+func (p *sanitizedParameters) resolve(key string, thunk func() (interface{}, error)) (interface{}, error) {
+	value, found := p.resolved[key]
+	if found {
+		return value, nil
+	}
+
+	value, err := thunk()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.resolved == nil {
+		p.resolved = make(map[string]interface{})
+	}
+	p.resolved[key] = value
+	return value, nil
+}
+
 func castToFloat64(value interface{}) interface{} {
 	switch value.(type) {
 	case uint8:
@@ -37,6 +83,21 @@ func castToFloat64(value interface{}) interface{} {
 		return float64(value.(int))
 	case float32:
 		return float64(value.(float32))
+	case float64, string, bool, time.Duration:
+		// durations are integers too, but are kept as they are so that they can be added to times.
+		return value
+	}
+
+	// named numeric types, such as enums ("type Color int").
+	reflected := reflect.ValueOf(value)
+
+	switch reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(reflected.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(reflected.Uint())
+	case reflect.Float32, reflect.Float64:
+		return reflected.Float()
 	}
 
 	return value
@@ -0,0 +1,202 @@
+package govaluate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+	A version number, following semantic versioning (https://semver.org), such as "1.2.3-beta.1".
+	Build metadata (after a "+") doesn't affect precedence, so it isn't kept.
+*/
+type semanticVersion struct {
+	major, minor, patch uint64
+	prerelease          []string
+}
+
+/*
+	Parses the given [version], which may start with a "v" (as in "v1.2.3").
+*/
+func parseSemanticVersion(version string) (semanticVersion, error) {
+
+	var ret semanticVersion
+	var err error
+
+	text := strings.TrimPrefix(version, "v")
+
+	plus := strings.Index(text, "+")
+	if plus >= 0 {
+		text = text[:plus]
+	}
+
+	hyphen := strings.Index(text, "-")
+	if hyphen >= 0 {
+
+		ret.prerelease = strings.Split(text[hyphen+1:], ".")
+		text = text[:hyphen]
+
+		for _, identifier := range ret.prerelease {
+			if identifier == "" {
+				return ret, fmt.Errorf("Invalid version '%s': empty pre-release identifier", version)
+			}
+		}
+	}
+
+	parts := strings.Split(text, ".")
+	if len(parts) != 3 {
+		return ret, fmt.Errorf("Invalid version '%s': expected major.minor.patch", version)
+	}
+
+	numbers := []*uint64{&ret.major, &ret.minor, &ret.patch}
+	for i, part := range parts {
+
+		*numbers[i], err = strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return ret, fmt.Errorf("Invalid version '%s': '%s' is not a number", version, part)
+		}
+	}
+	return ret, nil
+}
+
+/*
+	Returns -1, 0, or 1 if [this] has lower, the same, or higher precedence than [other].
+*/
+func (this semanticVersion) compare(other semanticVersion) int {
+
+	switch {
+	case this.major != other.major:
+		return compareOrdered(this.major < other.major, this.major > other.major)
+	case this.minor != other.minor:
+		return compareOrdered(this.minor < other.minor, this.minor > other.minor)
+	case this.patch != other.patch:
+		return compareOrdered(this.patch < other.patch, this.patch > other.patch)
+	}
+
+	// a pre-release comes before the release itself.
+	if len(this.prerelease) == 0 || len(other.prerelease) == 0 {
+		return compareOrdered(len(this.prerelease) > len(other.prerelease), len(this.prerelease) < len(other.prerelease))
+	}
+
+	for i := 0; i < len(this.prerelease) && i < len(other.prerelease); i++ {
+
+		comparison := comparePrereleaseIdentifiers(this.prerelease[i], other.prerelease[i])
+		if comparison != 0 {
+			return comparison
+		}
+	}
+	return compareOrdered(len(this.prerelease) < len(other.prerelease), len(this.prerelease) > len(other.prerelease))
+}
+
+/*
+	Compares two pre-release identifiers; numerically if both are numbers, otherwise as text.
+	Numbers always come before text.
+*/
+func comparePrereleaseIdentifiers(left string, right string) int {
+
+	leftNumber, leftErr := strconv.ParseUint(left, 10, 64)
+	rightNumber, rightErr := strconv.ParseUint(right, 10, 64)
+
+	switch {
+	case leftErr == nil && rightErr == nil:
+		return compareOrdered(leftNumber < rightNumber, leftNumber > rightNumber)
+	case leftErr == nil:
+		return -1
+	case rightErr == nil:
+		return 1
+	}
+	return strings.Compare(left, right)
+}
+
+/*
+	Returns true if the given [version] satisfies the [constraint].
+	A constraint is one or more comparisons separated by spaces (like ">=1.2.0 <2.0.0"), all of which must be true.
+	Several such constraints can be separated by "||", only one of which must be true.
+
+	Each comparison is an operator (one of "=", "==", "!=", ">", ">=", "<", "<=", "^", or "~") followed by a version.
+	A version on its own must be equal. "^1.2.3" allows any version from 1.2.3 up to (but not including) 2.0.0,
+	or for versions before 1.0.0, up to the next minor version (so "^0.2.3" is below 0.3.0).
+	"~1.2.3" allows any version from 1.2.3 up to (but not including) 1.3.0.
+*/
+func satisfiesSemanticConstraint(version semanticVersion, constraint string) (bool, error) {
+
+	if strings.TrimSpace(constraint) == "" {
+		return false, fmt.Errorf("Invalid version constraint '%s'", constraint)
+	}
+
+	for _, alternative := range strings.Split(constraint, "||") {
+
+		comparisons := strings.Fields(alternative)
+		if len(comparisons) == 0 {
+			return false, fmt.Errorf("Invalid version constraint '%s': empty alternative", constraint)
+		}
+
+		satisfied := true
+		for _, comparison := range comparisons {
+
+			matches, err := satisfiesSemanticComparison(version, comparison)
+			if err != nil {
+				return false, fmt.Errorf("Invalid version constraint '%s': %w", constraint, err)
+			}
+			satisfied = satisfied && matches
+		}
+
+		if satisfied {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// the pre-release which comes before all others, so that the upper bound of a range like "^1.2.3" excludes "2.0.0-beta" as well as "2.0.0".
+var lowestPrerelease = []string{"0"}
+
+/*
+	Returns true if the given [version] satisfies a single [comparison], like ">=1.2.0".
+*/
+func satisfiesSemanticComparison(version semanticVersion, comparison string) (bool, error) {
+
+	split := strings.IndexFunc(comparison, func(character rune) bool {
+		return !strings.ContainsRune("<>=!^~", character)
+	})
+	if split < 0 {
+		return false, fmt.Errorf("'%s' has no version", comparison)
+	}
+
+	operator := comparison[:split]
+
+	target, err := parseSemanticVersion(comparison[split:])
+	if err != nil {
+		return false, err
+	}
+
+	comparisonResult := version.compare(target)
+
+	switch operator {
+	case "", "=", "==":
+		return comparisonResult == 0, nil
+	case "!=":
+		return comparisonResult != 0, nil
+	case ">":
+		return comparisonResult > 0, nil
+	case ">=":
+		return comparisonResult >= 0, nil
+	case "<":
+		return comparisonResult < 0, nil
+	case "<=":
+		return comparisonResult <= 0, nil
+	case "^":
+
+		upper := semanticVersion{major: target.major + 1, prerelease: lowestPrerelease}
+		if target.major == 0 {
+			upper = semanticVersion{minor: target.minor + 1, prerelease: lowestPrerelease}
+		}
+		return comparisonResult >= 0 && version.compare(upper) < 0, nil
+
+	case "~":
+
+		upper := semanticVersion{major: target.major, minor: target.minor + 1, prerelease: lowestPrerelease}
+		return comparisonResult >= 0 && version.compare(upper) < 0, nil
+	}
+	return false, fmt.Errorf("unknown operator '%s'", operator)
+}
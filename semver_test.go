@@ -0,0 +1,134 @@
+package govaluate
+
+import (
+	"testing"
+)
+
+func TestSemanticVersions(test *testing.T) {
+
+	evaluationTests := []EvaluationTest{
+
+		EvaluationTest{
+
+			Name:     "semverCompare by minor version",
+			Input:    "semverCompare('1.10.0', '1.9.0')",
+			Expected: 1.0,
+		},
+		EvaluationTest{
+
+			Name:     "semverCompare with equal versions",
+			Input:    "semverCompare('v1.2.3', '1.2.3+build.5')",
+			Expected: 0.0,
+		},
+		EvaluationTest{
+
+			Name:     "semverCompare with a pre-release",
+			Input:    "semverCompare('1.0.0-rc.1', '1.0.0')",
+			Expected: -1.0,
+		},
+		EvaluationTest{
+
+			Name:     "semverCompare with numeric pre-release identifiers",
+			Input:    "semverCompare('1.0.0-beta.11', '1.0.0-beta.2')",
+			Expected: 1.0,
+		},
+		EvaluationTest{
+
+			Name:     "semverCompare with numeric and textual pre-release identifiers",
+			Input:    "semverCompare('1.0.0-alpha.1', '1.0.0-alpha.beta')",
+			Expected: -1.0,
+		},
+		EvaluationTest{
+
+			Name:     "semverCompare with a longer pre-release",
+			Input:    "semverCompare('1.0.0-alpha', '1.0.0-alpha.1')",
+			Expected: -1.0,
+		},
+		EvaluationTest{
+
+			Name:  "semverSatisfies with a range",
+			Input: "semverSatisfies(version, '>=1.2.0 <2.0.0')",
+			Parameters: []EvaluationParameter{
+				EvaluationParameter{
+					Name:  "version",
+					Value: "1.10.4",
+				},
+			},
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:     "semverSatisfies outside a range",
+			Input:    "semverSatisfies('2.0.0', '>=1.2.0 <2.0.0')",
+			Expected: false,
+		},
+		EvaluationTest{
+
+			Name:     "semverSatisfies with alternatives",
+			Input:    "semverSatisfies('3.1.0', '<2.0.0 || >=3.0.0')",
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:     "semverSatisfies with an exact version",
+			Input:    "semverSatisfies('1.2.3', '1.2.3') && !semverSatisfies('1.2.3', '!=1.2.3')",
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:     "semverSatisfies with a caret",
+			Input:    "semverSatisfies('1.9.9', '^1.2.3') && !semverSatisfies('2.0.0-beta', '^1.2.3')",
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:     "semverSatisfies with a caret before 1.0.0",
+			Input:    "semverSatisfies('0.2.9', '^0.2.3') && !semverSatisfies('0.3.0', '^0.2.3')",
+			Expected: true,
+		},
+		EvaluationTest{
+
+			Name:     "semverSatisfies with a tilde",
+			Input:    "semverSatisfies('1.2.9', '~1.2.3') && !semverSatisfies('1.3.0', '~1.2.3')",
+			Expected: true,
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+
+	failureTests := []EvaluationFailureTest{
+
+		EvaluationFailureTest{
+
+			Name:     "semverCompare with an invalid version",
+			Input:    "semverCompare('1.2', '1.2.0')",
+			Expected: "Function 'semverCompare' failed: Invalid version '1.2': expected major.minor.patch",
+		},
+		EvaluationFailureTest{
+
+			Name:     "semverCompare with a non-numeric version",
+			Input:    "semverCompare('1.x.0', '1.2.0')",
+			Expected: "Invalid version '1.x.0': 'x' is not a number",
+		},
+		EvaluationFailureTest{
+
+			Name:     "semverCompare with a number",
+			Input:    "semverCompare(1, '1.2.0')",
+			Expected: "expects string arguments",
+		},
+		EvaluationFailureTest{
+
+			Name:     "semverSatisfies with an unknown operator",
+			Input:    "semverSatisfies('1.2.0', '=>1.0.0')",
+			Expected: "Invalid version constraint '=>1.0.0': unknown operator '=>'",
+		},
+		EvaluationFailureTest{
+
+			Name:     "semverSatisfies with an empty constraint",
+			Input:    "semverSatisfies('1.2.0', '')",
+			Expected: "Invalid version constraint ''",
+		},
+	}
+
+	runEvaluationFailureTests(failureTests, test)
+}
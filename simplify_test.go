@@ -0,0 +1,69 @@
+package govaluate
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestSimplify(test *testing.T) {
+
+	calls := 0
+	functions := map[string]ExpressionFunction{
+		"round": func(arguments ...interface{}) (interface{}, error) {
+			calls++
+			scale := math.Pow(10, arguments[1].(float64))
+			return math.Round(arguments[0].(float64)*scale) / scale, nil
+		},
+		"impure": func(arguments ...interface{}) (interface{}, error) {
+			calls++
+			return 1.0, nil
+		},
+		"failing": func(arguments ...interface{}) (interface{}, error) {
+			calls++
+			return nil, errors.New("failed")
+		},
+	}
+	options := ParsingOptions{PureFunctions: []string{"round", "failing"}}
+
+	expression, _ := NewEvaluableExpressionWithOptions("x * round(3.14159, 2) + round(round(1.55, 1) * 10, 0)", functions, options)
+	simplified := expression.Simplify()
+
+	if calls != 3 {
+		test.Logf("Expected Simplify to call each pure function of literals once, got %d calls", calls)
+		test.Fail()
+	}
+
+	calls = 0
+	result, err := simplified.Evaluate(map[string]interface{}{"x": 2})
+	if err != nil || result != 22.28 || calls != 0 {
+		test.Logf("Expected 22.28 without calling any functions, got %v, %v, after %d calls", result, err, calls)
+		test.Fail()
+	}
+
+	if simplified.String() != expression.String() {
+		test.Logf("Expected the simplified expression to keep its text")
+		test.Fail()
+	}
+
+	// calls with parameters, or to impure functions, are still made on every evaluation.
+	expression, _ = NewEvaluableExpressionWithOptions("round(x, 1) + impure(1) + round(1.25, 1)", functions, options)
+	simplified = expression.Simplify()
+
+	calls = 0
+	result, err = simplified.Evaluate(map[string]interface{}{"x": 1.04})
+	if err != nil || result != 3.3 || calls != 2 {
+		test.Logf("Expected 3.3 after 2 calls, got %v, %v, after %d calls", result, err, calls)
+		test.Fail()
+	}
+
+	// calls which fail are left for evaluation to report.
+	expression, _ = NewEvaluableExpressionWithOptions("failing(1) ?? 2", functions, options)
+	simplified = expression.Simplify()
+
+	_, err = simplified.Evaluate(nil)
+	if err == nil || err.Error() != "failed" {
+		test.Logf("Expected a failing call to still fail when evaluated, got %v", err)
+		test.Fail()
+	}
+}
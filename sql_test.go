@@ -150,6 +150,12 @@ func TestSQLSerialization(test *testing.T) {
 			Input:    "10 % 2",
 			Expected: "MOD(10, 2)",
 		},
+		QueryTest{
+
+			Name:     "Concatenation",
+			Input:    "foo .. 'bar'",
+			Expected: "CONCAT([foo], 'bar')",
+		},
 		QueryTest{
 
 			Name:     "Membership operator",
@@ -3,6 +3,8 @@ package govaluate
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 	"time"
 )
 
@@ -29,6 +31,7 @@ var stageSymbolMap = map[OperatorSymbol]evaluationOperator{
 	DIVIDE:         divideStage,
 	MODULUS:        modulusStage,
 	EXPONENT:       exponentStage,
+	CONCAT:         concatStage,
 	NEGATE:         negateStage,
 	INVERT:         invertStage,
 	BITWISE_NOT:    bitwiseNotStage,
@@ -178,18 +181,44 @@ func makePrecedentFromPlanner(planner *precedencePlanner) precedent {
 	which is used to completely evaluate a set of tokens at evaluation-time.
 	The three stages of evaluation can be thought of as parsing strings to tokens, then tokens to a stage list, then evaluation with parameters.
 */
-func planStages(tokens []ExpressionToken) (*evaluationStage, error) {
+func planStages(tokens []ExpressionToken, metadata []tokenMetadata, precedence map[OperatorSymbol]int) (*evaluationStage, error) {
+
+	var table map[OperatorSymbol]int
+	var err error
 
 	stream := newTokenStream(tokens)
+	stream.metadata = metadata
+
+	findPrecedence := findOperatorPrecedenceForSymbol
+
+	if precedence != nil {
+
+		table, err = resolvePrecedence(precedence)
+		if err != nil {
+			return nil, err
+		}
+
+		stream.plan = makePrecedencePlan(table)
+		findPrecedence = makePrecedenceFinder(table)
+	}
 
 	stage, err := planTokens(stream)
 	if err != nil {
 		return nil, err
 	}
 
+	if table != nil {
+		err = checkPrecedenceMixing(stage, table)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// while we're now fully-planned, we now need to re-order same-precedence operators.
 	// this could probably be avoided with a different planning method
-	reorderStages(stage)
+	reorderStages(stage, findPrecedence)
+	chainSeparators(stage)
+	measureStages(stage)
 
 	stage = elideLiterals(stage)
 	return stage, nil
@@ -201,6 +230,9 @@ func planTokens(stream *tokenStream) (*evaluationStage, error) {
 		return nil, nil
 	}
 
+	if stream.plan != nil {
+		return stream.plan(stream)
+	}
 	return planSeparator(stream)
 }
 
@@ -217,6 +249,7 @@ func planPrecedenceLevel(
 	leftPrecedent precedent) (*evaluationStage, error) {
 
 	var token ExpressionToken
+	var metadata tokenMetadata
 	var symbol OperatorSymbol
 	var leftStage, rightStage *evaluationStage
 	var checks typeChecks
@@ -262,6 +295,8 @@ func planPrecedenceLevel(
 			}
 		}
 
+		metadata = stream.lastMetadata()
+
 		if rightPrecedent != nil {
 			rightStage, err = rightPrecedent(stream)
 			if err != nil {
@@ -282,6 +317,9 @@ func planPrecedenceLevel(
 			rightTypeCheck:  checks.right,
 			typeCheck:       checks.combined,
 			typeErrorFormat: typeErrorFormat,
+
+			tokenStart: metadata.start,
+			tokenEnd:   metadata.end,
 		}, nil
 	}
 
@@ -301,22 +339,143 @@ func planFunction(stream *tokenStream) (*evaluationStage, error) {
 	token = stream.next()
 
 	if token.Kind != FUNCTION {
+
 		stream.rewind()
-		return planAccessor(stream)
+
+		// method calls can be indexed too.
+		stage, err := planAccessor(stream)
+		if err != nil || stage == nil || stage.symbol != ACCESS {
+			return stage, err
+		}
+		return planIndexes(stream, stage)
 	}
 
+	metadata := stream.lastMetadata()
+
 	rightStage, err = planAccessor(stream)
 	if err != nil {
 		return nil, err
 	}
 
-	return &evaluationStage{
+	function := token.Value.(ExpressionFunction)
+	operator := makeFunctionStage(function, findArgumentStyle(rightStage))
+
+	if metadata.contextFunction != nil {
+		operator = makeContextFunctionStage(metadata.contextFunction, findArgumentStyle(rightStage))
+	}
+
+	if metadata.subExpressionFunction != nil {
+		operator = makeSubExpressionFunctionStage(metadata.subExpressionFunction, findArgumentStyle(rightStage))
+	}
+
+	return planIndexes(stream, &evaluationStage{
 
 		symbol:          FUNCTIONAL,
+		name:            metadata.functionName,
 		rightStage:      rightStage,
-		operator:        makeFunctionStage(token.Value.(ExpressionFunction)),
+		operator:        operator,
 		typeErrorFormat: "Unable to run function '%v': %v",
-	}, nil
+		catchesErrors:   isTryFunction(function),
+		stringSize:      findStringSize(metadata.functionName, function, findArgumentStyle(rightStage)),
+
+		evaluatesSubExpressions: metadata.subExpressionFunction != nil,
+
+		tokenStart: metadata.start,
+		tokenEnd:   metadata.end,
+	})
+}
+
+/*
+	Plans any indexes (like the "[0]" of "split(s, ',')[0]") which come right after the call that was just planned as [stage].
+	Each index becomes an INDEX stage, whose left side is what's being indexed.
+*/
+func planIndexes(stream *tokenStream, stage *evaluationStage) (*evaluationStage, error) {
+
+	for stream.hasNext() {
+
+		token := stream.next()
+		if token.Kind != CLAUSE || token.Value != '[' {
+			stream.rewind()
+			break
+		}
+
+		index, err := planTokens(stream)
+		if err != nil {
+			return nil, err
+		}
+
+		// advance past the closing bracket, which we know is there because brackets are balanced at parse-time.
+		stream.next()
+
+		if index == nil {
+			return nil, errors.New("Index brackets must contain an index")
+		}
+
+		// like parenthesis, the index is wrapped so that it's never reordered with the stages around it.
+		index = &evaluationStage{
+			rightStage: index,
+			operator:   noopStageRight,
+			symbol:     NOOP,
+
+			tokenStart: index.tokenStart,
+			tokenEnd:   index.tokenEnd,
+		}
+
+		stage = &evaluationStage{
+
+			symbol:     INDEX,
+			leftStage:  stage,
+			rightStage: index,
+			operator:   indexStage,
+
+			tokenStart: stage.tokenStart,
+			tokenEnd:   stream.lastMetadata().end,
+		}
+	}
+	return stage, nil
+}
+
+/*
+	Returns true if the given [function] is the built-in "try", whose arguments aren't evaluated before it's called.
+	Functions can't be compared, so this compares where their code is.
+*/
+func isTryFunction(function ExpressionFunction) bool {
+	return reflect.ValueOf(function).Pointer() == reflect.ValueOf(tryFunction).Pointer()
+}
+
+/*
+	Returns the number of arguments given to a function, given the stage of its parenthesized arguments.
+	Only correct once stages are reordered (see `chainSeparators`).
+*/
+func findArgumentCount(stage *evaluationStage) int {
+
+	switch findArgumentStyle(stage) {
+	case noArguments:
+		return 0
+	case singleArgument:
+		return 1
+	}
+
+	count := 1
+	for arguments := stage.rightStage; arguments.symbol == SEPARATE; arguments = arguments.leftStage {
+		count++
+	}
+	return count
+}
+
+/*
+	Determines how the arguments were written for a function, given the stage of its parenthesized arguments.
+*/
+func findArgumentStyle(stage *evaluationStage) argumentStyle {
+
+	if stage == nil || stage.rightStage == nil {
+		return noArguments
+	}
+
+	if stage.rightStage.symbol == SEPARATE {
+		return argumentList
+	}
+	return singleArgument
 }
 
 func planAccessor(stream *tokenStream) (*evaluationStage, error) {
@@ -336,6 +495,8 @@ func planAccessor(stream *tokenStream) (*evaluationStage, error) {
 		return planValue(stream)
 	}
 
+	metadata := stream.lastMetadata()
+
 	// check if this is meant to be a function or a field.
 	// fields have a clause next to them, functions do not.
 	// if it's a function, parse the arguments. Otherwise leave the right stage null.
@@ -358,9 +519,13 @@ func planAccessor(stream *tokenStream) (*evaluationStage, error) {
 	return &evaluationStage{
 
 		symbol:          ACCESS,
+		name:            strings.Join(token.Value.([]string), "."),
 		rightStage:      rightStage,
 		operator:        makeAccessorStage(token.Value.([]string)),
 		typeErrorFormat: "Unable to access parameter field or method '%v': %v",
+
+		tokenStart: metadata.start,
+		tokenEnd:   metadata.end,
 	}, nil
 }
 
@@ -371,9 +536,11 @@ func planAccessor(stream *tokenStream) (*evaluationStage, error) {
 func planValue(stream *tokenStream) (*evaluationStage, error) {
 
 	var token ExpressionToken
+	var metadata tokenMetadata
 	var symbol OperatorSymbol
 	var ret *evaluationStage
 	var operator evaluationOperator
+	var name string
 	var err error
 
 	if !stream.hasNext() {
@@ -381,6 +548,7 @@ func planValue(stream *tokenStream) (*evaluationStage, error) {
 	}
 
 	token = stream.next()
+	metadata = stream.lastMetadata()
 
 	switch token.Kind {
 
@@ -401,6 +569,9 @@ func planValue(stream *tokenStream) (*evaluationStage, error) {
 			rightStage: ret,
 			operator:   noopStageRight,
 			symbol:     NOOP,
+
+			tokenStart: metadata.start,
+			tokenEnd:   stream.lastMetadata().end,
 		}
 
 		return ret, nil
@@ -413,7 +584,8 @@ func planValue(stream *tokenStream) (*evaluationStage, error) {
 		return nil, nil
 
 	case VARIABLE:
-		operator = makeParameterStage(token.Value.(string))
+		name = token.Value.(string)
+		operator = makeParameterStage(name)
 
 	case NUMERIC:
 		fallthrough
@@ -440,7 +612,11 @@ func planValue(stream *tokenStream) (*evaluationStage, error) {
 
 	return &evaluationStage{
 		symbol:   symbol,
+		name:     name,
 		operator: operator,
+
+		tokenStart: metadata.start,
+		tokenEnd:   metadata.end,
 	}, nil
 }
 
@@ -498,15 +674,18 @@ func findTypeChecks(symbol OperatorSymbol) typeChecks {
 		fallthrough
 	case BITWISE_XOR:
 		return typeChecks{
-			left:  isFloat64,
-			right: isFloat64,
+			left:  isBitwiseOperand,
+			right: isBitwiseOperand,
 		}
 	case PLUS:
 		return typeChecks{
 			combined: additionTypeCheck,
 		}
 	case MINUS:
-		fallthrough
+		return typeChecks{
+			left:  isSubtractable,
+			right: isSubtractable,
+		}
 	case MULTIPLY:
 		fallthrough
 	case DIVIDE:
@@ -528,7 +707,7 @@ func findTypeChecks(symbol OperatorSymbol) typeChecks {
 		}
 	case BITWISE_NOT:
 		return typeChecks{
-			right: isFloat64,
+			right: isBitwiseOperand,
 		}
 	case TERNARY_TRUE:
 		return typeChecks{
@@ -539,6 +718,8 @@ func findTypeChecks(symbol OperatorSymbol) typeChecks {
 	case EQ:
 		fallthrough
 	case NEQ:
+		fallthrough
+	case CONCAT:
 		return typeChecks{}
 	case TERNARY_FALSE:
 		fallthrough
@@ -553,7 +734,7 @@ func findTypeChecks(symbol OperatorSymbol) typeChecks {
 	During stage planning, stages of equal precedence are parsed such that they'll be evaluated in reverse order.
 	For commutative operators like "+" or "-", it's no big deal. But for order-specific operators, it ruins the expected result.
 */
-func reorderStages(rootStage *evaluationStage) {
+func reorderStages(rootStage *evaluationStage, findPrecedence func(OperatorSymbol) operatorPrecedence) {
 
 	// traverse every rightStage until we find multiples in a row of the same precedence.
 	var identicalPrecedences []*evaluationStage
@@ -561,7 +742,7 @@ func reorderStages(rootStage *evaluationStage) {
 	var precedence, currentPrecedence operatorPrecedence
 
 	nextStage = rootStage
-	precedence = findOperatorPrecedenceForSymbol(rootStage.symbol)
+	precedence = findPrecedence(rootStage.symbol)
 
 	for nextStage != nil {
 
@@ -570,10 +751,10 @@ func reorderStages(rootStage *evaluationStage) {
 
 		// left depth first, since this entire method only looks for precedences down the right side of the tree
 		if currentStage.leftStage != nil {
-			reorderStages(currentStage.leftStage)
+			reorderStages(currentStage.leftStage, findPrecedence)
 		}
 
-		currentPrecedence = findOperatorPrecedenceForSymbol(currentStage.symbol)
+		currentPrecedence = findPrecedence(currentStage.symbol)
 
 		if currentPrecedence == precedence {
 			identicalPrecedences = append(identicalPrecedences, currentStage)
@@ -642,6 +823,52 @@ func mirrorStageSubtree(stages []*evaluationStage) {
 	}
 }
 
+/*
+	Once stages are reordered, a list like "a, b, c" is a chain of separators down the left side of the tree.
+	Every separator in such a chain (except the deepest) appends to the list made by the one below it.
+	This is done after reordering, since before then the separators are chained down the right side.
+*/
+func chainSeparators(root *evaluationStage) {
+
+	if root.leftStage != nil {
+		chainSeparators(root.leftStage)
+	}
+
+	if root.rightStage != nil {
+		chainSeparators(root.rightStage)
+	}
+
+	if root.symbol == SEPARATE && root.leftStage != nil && root.leftStage.symbol == SEPARATE {
+		root.operator = appendSeparatorStage
+	}
+}
+
+/*
+	Sets the position of every stage in the tree to cover its own token and all of its children.
+	Like `chainSeparators`, this needs to be done once stages are reordered.
+*/
+func measureStages(root *evaluationStage) {
+
+	root.start = root.tokenStart
+	root.end = root.tokenEnd
+
+	for _, child := range []*evaluationStage{root.leftStage, root.rightStage} {
+
+		if child == nil {
+			continue
+		}
+
+		measureStages(child)
+
+		if child.start < root.start {
+			root.start = child.start
+		}
+		if child.end > root.end {
+			root.end = child.end
+		}
+	}
+}
+
 /*
 	Recurses through all operators in the entire tree, eliding operators where both sides are literals.
 */
@@ -720,5 +947,9 @@ func elideStage(root *evaluationStage) *evaluationStage {
 	return &evaluationStage{
 		symbol:   LITERAL,
 		operator: makeLiteralStage(result),
+		elided:   root,
+
+		start: root.start,
+		end:   root.end,
 	}
 }
@@ -0,0 +1,94 @@
+package govaluate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluateStream(test *testing.T) {
+
+	// later inputs finish sooner, so that results are only in order if they're put back in order.
+	functions := map[string]ExpressionFunction{
+		"wait": func(arguments ...interface{}) (interface{}, error) {
+			time.Sleep(time.Duration(10-arguments[0].(float64)) * time.Millisecond)
+			return arguments[0], nil
+		},
+	}
+	expression, _ := NewEvaluableExpressionWithFunctions("wait(x) * 2", functions)
+
+	for _, workers := range []int{0, 1, 4} {
+
+		expression.StreamWorkers = workers
+		expression.StreamUnordered = false
+
+		results := expression.EvaluateStream(streamInputs(10))
+
+		count := 0
+		for result := range results {
+
+			if result.Error != nil || result.Value != float64(count*2) || result.Parameters["x"] != count {
+				test.Logf("Expected result %d from %d workers to be %d, got %v, %v", count, workers, count*2, result.Value, result.Error)
+				test.Fail()
+			}
+			count++
+		}
+
+		if count != 10 {
+			test.Logf("Expected 10 results from %d workers, got %d", workers, count)
+			test.Fail()
+		}
+	}
+
+	// unordered results may arrive in any order, but each still belongs to its own parameters.
+	expression.StreamWorkers = 4
+	expression.StreamUnordered = true
+
+	seen := make(map[int]bool)
+	for result := range expression.EvaluateStream(streamInputs(10)) {
+
+		x := result.Parameters["x"].(int)
+		if result.Error != nil || result.Value != float64(x*2) || seen[x] {
+			test.Logf("Expected one result of %d for x = %d, got %v, %v", x*2, x, result.Value, result.Error)
+			test.Fail()
+		}
+		seen[x] = true
+	}
+
+	if len(seen) != 10 {
+		test.Logf("Expected 10 unordered results, got %d", len(seen))
+		test.Fail()
+	}
+
+	// errors are sent as results, without stopping the stream.
+	expression, _ = NewEvaluableExpression("x > 1")
+	in := make(chan map[string]interface{}, 2)
+	in <- map[string]interface{}{"x": "nope"}
+	in <- map[string]interface{}{"x": 2}
+	close(in)
+
+	results := expression.EvaluateStream(in)
+
+	result := <-results
+	if result.Error == nil {
+		test.Logf("Expected the first result to be an error")
+		test.Fail()
+	}
+
+	result = <-results
+	if result.Error != nil || result.Value != true {
+		test.Logf("Expected the second result to be true, got %v, %v", result.Value, result.Error)
+		test.Fail()
+	}
+}
+
+func streamInputs(count int) <-chan map[string]interface{} {
+
+	in := make(chan map[string]interface{})
+	go func() {
+		for i := 0; i < count; i++ {
+			in <- map[string]interface{}{"x": i}
+		}
+		close(in)
+	}()
+	return in
+}
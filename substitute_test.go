@@ -0,0 +1,59 @@
+package govaluate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubstitute(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("age >= 18 && region == 'eu' && foo.Int > limit")
+	renamed := expression.Substitute(map[string]string{"age": "user_age", "foo": "user", "limit": "age"})
+
+	parameters := map[string]interface{}{
+		"user_age": 30,
+		"region":   "eu",
+		"user":     dummyParameter{Int: 5},
+		"age":      1,
+	}
+
+	result, err := renamed.Evaluate(parameters)
+	if err != nil || result != true {
+		test.Logf("Expected the renamed expression to give true, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	vars := renamed.Vars()
+	if !reflect.DeepEqual(vars, []string{"user_age", "region", "age"}) {
+		test.Logf("Expected the renamed expression's variables to be [user_age region age], got %v", vars)
+		test.Fail()
+	}
+
+	slots := renamed.ParameterSlots()
+	if !reflect.DeepEqual(slots, []string{"user_age", "region", "user", "age"}) {
+		test.Logf("Expected the renamed expression's parameter slots to be [user_age region user age], got %v", slots)
+		test.Fail()
+	}
+
+	// the original expression still uses the old names.
+	vars = expression.Vars()
+	if !reflect.DeepEqual(vars, []string{"age", "region", "limit"}) {
+		test.Logf("Expected the original expression's variables to be unchanged, got %v", vars)
+		test.Fail()
+	}
+
+	result, err = expression.Evaluate(map[string]interface{}{"age": 30, "region": "eu", "foo": dummyParameter{Int: 5}, "limit": 1})
+	if err != nil || result != true {
+		test.Logf("Expected the original expression to give true, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	// accessors which were bound stay bound.
+	bound := expression.BindParameter("foo", dummyParameter{Int: 0}).Substitute(map[string]string{"foo": "user"})
+
+	result, err = bound.Evaluate(parameters)
+	if err != nil || result != false {
+		test.Logf("Expected the bound and renamed expression to give false, got %v, %v", result, err)
+		test.Fail()
+	}
+}
@@ -0,0 +1,140 @@
+package govaluate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEvaluateWithTimeout(test *testing.T) {
+
+	var calls int32
+
+	functions := map[string]ExpressionFunction{
+		"slow": func(arguments ...interface{}) (interface{}, error) {
+
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(20 * time.Millisecond)
+			return 1.0, nil
+		},
+	}
+
+	expression, _ := NewEvaluableExpressionWithFunctions("slow() + slow() + slow() + slow() + slow() + slow()", functions)
+
+	_, err := expression.EvaluateWithTimeout(nil, 30*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		test.Logf("Expected a timeout error, got %v", err)
+		test.Fail()
+	}
+
+	// the evaluation should stop once the function that was running when it timed out returns.
+	time.Sleep(100 * time.Millisecond)
+
+	if atomic.LoadInt32(&calls) > 3 {
+		test.Logf("Expected evaluation to stop after timing out, but the function was called %d times", calls)
+		test.Fail()
+	}
+
+	// the timeout only applies to that one evaluation.
+	result, err := expression.EvaluateWithTimeout(nil, time.Second)
+	if err != nil || result != 6.0 {
+		test.Logf("Expected 6 with a generous timeout, got %v, %v", result, err)
+		test.Fail()
+	}
+}
+
+func TestEvaluateWithContext(test *testing.T) {
+
+	type tagKey struct{}
+
+	options := ParsingOptions{
+		ContextFunctions: map[string]ContextFunction{
+			"tag": func(ctx context.Context, arguments ...interface{}) (interface{}, error) {
+
+				tag, _ := ctx.Value(tagKey{}).(string)
+				return tag + arguments[0].(string), nil
+			},
+			"wait": func(ctx context.Context, arguments ...interface{}) (interface{}, error) {
+
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(time.Second):
+					return 1.0, nil
+				}
+			},
+		},
+	}
+
+	expression, err := NewEvaluableExpressionWithOptions("tag(name)", nil, options)
+	if err != nil {
+		test.Fatalf("Unexpected parsing error: %v", err)
+	}
+
+	parameters := map[string]interface{}{"name": "bob"}
+
+	// the function is given the same context as the evaluation.
+	ctx := context.WithValue(context.Background(), tagKey{}, "user:")
+
+	result, err := expression.EvaluateWithContext(ctx, parameters)
+	if err != nil || result != "user:bob" {
+		test.Logf("Expected 'user:bob' with a context, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	// otherwise, it's given a background context.
+	result, err = expression.Evaluate(parameters)
+	if err != nil || result != "bob" {
+		test.Logf("Expected 'bob' without a context, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	// functions can stop early when the context is cancelled, and so does the rest of the evaluation.
+	expression, err = NewEvaluableExpressionWithOptions("wait() + wait() + wait()", nil, options)
+	if err != nil {
+		test.Fatalf("Unexpected parsing error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = expression.EvaluateWithContext(ctx, nil)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		test.Logf("Expected the context's deadline to be exceeded, got %v", err)
+		test.Fail()
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		test.Logf("Expected evaluation to stop at the deadline, but it took %v", time.Since(start))
+		test.Fail()
+	}
+
+	// an already-cancelled context doesn't evaluate at all.
+	_, err = expression.EvaluateWithContext(ctx, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		test.Logf("Expected an expired context to return its error, got %v", err)
+		test.Fail()
+	}
+
+	// functions given to the expression take priority.
+	functions := map[string]ExpressionFunction{
+		"tag": func(arguments ...interface{}) (interface{}, error) {
+			return "plain", nil
+		},
+	}
+
+	expression, err = NewEvaluableExpressionWithOptions("tag(name)", functions, options)
+	if err != nil {
+		test.Fatalf("Unexpected parsing error: %v", err)
+	}
+
+	result, err = expression.EvaluateWithContext(context.Background(), parameters)
+	if err != nil || result != "plain" {
+		test.Logf("Expected the expression's own function to be called, got %v, %v", result, err)
+		test.Fail()
+	}
+}
@@ -2,8 +2,12 @@ package govaluate
 
 type tokenStream struct {
 	tokens      []ExpressionToken
+	metadata    []tokenMetadata
 	index       int
 	tokenLength int
+
+	// if set, plans the whole stream (and each clause in it) instead of `planSeparator`. See `ParsingOptions.Precedence`.
+	plan precedent
 }
 
 func newTokenStream(tokens []ExpressionToken) *tokenStream {
@@ -16,6 +20,20 @@ func newTokenStream(tokens []ExpressionToken) *tokenStream {
 	return ret
 }
 
+/*
+	Returns the metadata for the token most recently returned by `next()`.
+	Tokens which weren't parsed from a string have no metadata, in which case this returns an empty one.
+*/
+func (this *tokenStream) lastMetadata() tokenMetadata {
+
+	index := this.index - 1
+
+	if index < 0 || index >= len(this.metadata) {
+		return tokenMetadata{}
+	}
+	return this.metadata[index]
+}
+
 func (this *tokenStream) rewind() {
 	this.index -= 1
 }
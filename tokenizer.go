@@ -0,0 +1,28 @@
+package govaluate
+
+/*
+	Turns an expression string into the tokens which make up the expression.
+	Giving a Tokenizer in `ParsingOptions` replaces govaluate's own syntax with any other which can be read into the same tokens,
+	while everything after that (checking, planning, and evaluating the expression) stays the same.
+
+	The tokens must be the same as govaluate's own would be for the equivalent expression (see `DefaultTokenizer`).
+	In particular, a FUNCTION token's Value is the ExpressionFunction to call, usually found by name in [functions],
+	and an ACCESSOR token's Value is a []string of the parameter name followed by each field or method name.
+*/
+type Tokenizer interface {
+	Tokenize(expression string, functions map[string]ExpressionFunction) ([]ExpressionToken, error)
+}
+
+/*
+	The Tokenizer which reads govaluate's own syntax, as documented in the manual.
+	Custom tokenizers may find it useful for reading parts of an expression which use the usual syntax.
+*/
+var DefaultTokenizer Tokenizer = defaultTokenizer{}
+
+type defaultTokenizer struct{}
+
+func (this defaultTokenizer) Tokenize(expression string, functions map[string]ExpressionFunction) ([]ExpressionToken, error) {
+
+	tokens, _, err := parseTokens(expression, functions, ParsingOptions{})
+	return tokens, err
+}
@@ -0,0 +1,88 @@
+package govaluate
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+/*
+	Reads a tiny syntax of words separated by spaces, where "plus" and "times" are operators.
+*/
+type wordTokenizer struct{}
+
+func (this wordTokenizer) Tokenize(expression string, functions map[string]ExpressionFunction) ([]ExpressionToken, error) {
+
+	var ret []ExpressionToken
+
+	for _, word := range strings.Fields(expression) {
+
+		number, err := strconv.ParseFloat(word, 64)
+
+		switch {
+		case err == nil:
+			ret = append(ret, ExpressionToken{Kind: NUMERIC, Value: number})
+		case word == "plus":
+			ret = append(ret, ExpressionToken{Kind: MODIFIER, Value: "+"})
+		case word == "times":
+			ret = append(ret, ExpressionToken{Kind: MODIFIER, Value: "*"})
+		case word == "?":
+			return nil, errors.New("Unexpected '?'")
+		default:
+			ret = append(ret, ExpressionToken{Kind: VARIABLE, Value: word})
+		}
+	}
+	return ret, nil
+}
+
+/*
+	Reads govaluate's usual syntax, except with "<>" meaning "!=".
+*/
+type inequalityTokenizer struct{}
+
+func (this inequalityTokenizer) Tokenize(expression string, functions map[string]ExpressionFunction) ([]ExpressionToken, error) {
+	return DefaultTokenizer.Tokenize(strings.Replace(expression, "<>", "!=", -1), functions)
+}
+
+func TestCustomTokenizer(test *testing.T) {
+
+	expression, err := NewEvaluableExpressionWithOptions("x plus 2 times 3", nil, ParsingOptions{Tokenizer: wordTokenizer{}})
+	if err != nil {
+		test.Logf("Expected the custom syntax to parse, got %v", err)
+		test.Fail()
+		return
+	}
+
+	result, err := expression.Evaluate(map[string]interface{}{"x": 1})
+	if err != nil || result != 7.0 {
+		test.Logf("Expected the custom syntax to evaluate to 7, got %v, %v", result, err)
+		test.Fail()
+	}
+
+	_, err = NewEvaluableExpressionWithOptions("x ? 2", nil, ParsingOptions{Tokenizer: wordTokenizer{}})
+	if err == nil || err.Error() != "Unexpected '?'" {
+		test.Logf("Expected the tokenizer's error to be returned, got %v", err)
+		test.Fail()
+	}
+
+	// functions are given to the tokenizer, and built-ins are still available through the default tokenizer.
+	functions := map[string]ExpressionFunction{
+		"double": func(arguments ...interface{}) (interface{}, error) {
+			return arguments[0].(float64) * 2, nil
+		},
+	}
+
+	expression, err = NewEvaluableExpressionWithOptions("double(x) <> 4 && upper(name) <> 'BOB'", functions, ParsingOptions{Tokenizer: inequalityTokenizer{}})
+	if err != nil {
+		test.Logf("Expected the wrapped syntax to parse, got %v", err)
+		test.Fail()
+		return
+	}
+
+	result, err = expression.Evaluate(map[string]interface{}{"x": 1, "name": "alice"})
+	if err != nil || result != true {
+		test.Logf("Expected the wrapped syntax to evaluate to true, got %v, %v", result, err)
+		test.Fail()
+	}
+}
@@ -0,0 +1,58 @@
+package govaluate
+
+/*
+	The truthiness most people expect, for use as an EvaluableExpression's Truthiness.
+	nil (including nil pointers, maps, and slices), false, 0, NaN, and "" are false. Everything else is true.
+*/
+func DefaultTruthiness(value interface{}) bool {
+
+	if isNil(value) {
+		return false
+	}
+
+	// numbers which weren't parameters (such as the results of functions) may not be float64 yet.
+	value = castToFloat64(value)
+
+	switch value.(type) {
+	case bool:
+		return value.(bool)
+	case float64:
+		number := value.(float64)
+		return number != 0 && number == number
+	case string:
+		return value.(string) != ""
+	}
+	return true
+}
+
+/*
+	Returns true if the left side of a stage with the given [symbol] is a condition, which Truthiness applies to.
+*/
+func hasTruthyLeft(symbol OperatorSymbol) bool {
+
+	switch symbol {
+	case AND:
+		fallthrough
+	case OR:
+		fallthrough
+	case TERNARY_TRUE:
+		return true
+	}
+	return false
+}
+
+/*
+	Returns true if the right side of a stage with the given [symbol] is a condition, which Truthiness applies to.
+*/
+func hasTruthyRight(symbol OperatorSymbol) bool {
+
+	switch symbol {
+	case AND:
+		fallthrough
+	case OR:
+		fallthrough
+	case INVERT:
+		return true
+	}
+	return false
+}
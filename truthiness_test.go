@@ -0,0 +1,80 @@
+package govaluate
+
+import (
+	"testing"
+)
+
+type truthinessTest struct {
+	input    string
+	expected interface{}
+}
+
+func TestTruthiness(test *testing.T) {
+
+	parameters := map[string]interface{}{
+		"zero":     0,
+		"one":      1,
+		"empty":    "",
+		"name":     "bob",
+		"nothing":  nil,
+		"nilSlice": []int(nil),
+	}
+
+	truthinessTests := []truthinessTest{
+		truthinessTest{"one && name", true},
+		truthinessTest{"one && zero", false},
+		truthinessTest{"empty || nothing", false},
+		truthinessTest{"empty || name", true},
+		truthinessTest{"!empty", true},
+		truthinessTest{"!nilSlice", true},
+		truthinessTest{"!(!name)", true},
+		truthinessTest{"name ? 'named' : 'anonymous'", "named"},
+		truthinessTest{"nothing ? 'named' : 'anonymous'", "anonymous"},
+		truthinessTest{"zero && fail('not short-circuited')", false},
+	}
+
+	for _, truthinessTest := range truthinessTests {
+
+		expression, err := NewEvaluableExpression(truthinessTest.input)
+		if err != nil {
+			test.Logf("Unable to parse '%s': %v", truthinessTest.input, err)
+			test.Fail()
+			continue
+		}
+
+		// strict by default.
+		_, err = expression.Evaluate(parameters)
+		if err == nil {
+			test.Logf("Expected '%s' to fail without truthiness", truthinessTest.input)
+			test.Fail()
+		}
+
+		expression.Truthiness = DefaultTruthiness
+
+		result, err := expression.Evaluate(parameters)
+		if err != nil {
+			test.Logf("Unexpected error evaluating '%s': %v", truthinessTest.input, err)
+			test.Fail()
+			continue
+		}
+
+		if result != truthinessTest.expected {
+			test.Logf("Expected '%s' to be '%v', got '%v'", truthinessTest.input, truthinessTest.expected, result)
+			test.Fail()
+		}
+	}
+}
+
+func TestCustomTruthiness(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("status && enabled")
+	expression.Truthiness = func(value interface{}) bool {
+		return value == "on" || value == true
+	}
+
+	result, err := expression.Evaluate(map[string]interface{}{"status": "on", "enabled": "off"})
+	if err != nil || result != false {
+		test.Logf("Expected custom truthiness to give false, got %v, %v", result, err)
+		test.Fail()
+	}
+}
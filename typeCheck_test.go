@@ -0,0 +1,87 @@
+package govaluate
+
+import (
+	"strings"
+	"testing"
+)
+
+type typeCheckTest struct {
+	input    string
+	expected string
+}
+
+func TestTypeCheck(test *testing.T) {
+
+	schema := map[string]string{
+		"age":     "number",
+		"name":    "string",
+		"active":  "bool",
+		"tags":    "array",
+		"profile": "any",
+		"broken":  "integer",
+	}
+
+	functions := map[string]ExpressionFunction{
+		"length": func(arguments ...interface{}) (interface{}, error) {
+			return 0.0, nil
+		},
+	}
+
+	typeCheckTests := []typeCheckTest{
+
+		// valid
+		typeCheckTest{input: "age >= 18 && active"},
+		typeCheckTest{input: "name + age == 'bob1'"},
+		typeCheckTest{input: "name =~ '^b' && 'admin' in tags"},
+		typeCheckTest{input: "(active ? age : 0) * 2 > 1"},
+		typeCheckTest{input: "profile.Level > 3 && length(name) > profile"},
+		typeCheckTest{input: "-age + ~age > 0 || !active"},
+		typeCheckTest{input: "name .. age .. active == ''"},
+
+		// invalid
+		typeCheckTest{input: "age > 18 && name", expected: "Type 'string' cannot be used on the right of the operator '&&', in 'age > 18 && name'"},
+		typeCheckTest{input: "age - name > 1", expected: "Type 'string' cannot be used on the right of the operator '-', in 'age - name'"},
+		typeCheckTest{input: "(age > 1) > 0", expected: "Types 'bool' and 'number' cannot be used with the operator '>'"},
+		typeCheckTest{input: "age ? 1 : 2", expected: "Type 'number' cannot be used on the left of the operator '?'"},
+		typeCheckTest{input: "(active ? age : name) * 2 > (active ? 1 : 2) + name", expected: "Types 'number' and 'string' cannot be used with the operator '>'"},
+		typeCheckTest{input: "name in age", expected: "Type 'number' cannot be used on the right of the operator 'in'"},
+		typeCheckTest{input: "missing > 1", expected: "Parameter 'missing' is not in the schema"},
+		typeCheckTest{input: "broken > 1", expected: "Parameter 'broken' has unknown type 'integer' in the schema"},
+		typeCheckTest{input: "length(age - name)", expected: "Type 'string' cannot be used on the right of the operator '-'"},
+	}
+
+	for _, typeCheckTest := range typeCheckTests {
+
+		expression, err := NewEvaluableExpressionWithFunctions(typeCheckTest.input, functions)
+		if err != nil {
+			test.Logf("Unable to parse '%s': %v", typeCheckTest.input, err)
+			test.Fail()
+			continue
+		}
+
+		err = expression.TypeCheck(schema)
+
+		if typeCheckTest.expected == "" {
+			if err != nil {
+				test.Logf("Expected '%s' to pass type checking, got %v", typeCheckTest.input, err)
+				test.Fail()
+			}
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), typeCheckTest.expected) {
+			test.Logf("Expected '%s' to fail type checking with '%s', got %v", typeCheckTest.input, typeCheckTest.expected, err)
+			test.Fail()
+		}
+	}
+
+	// conditions converted by truthiness can be of any type.
+	expression, _ := NewEvaluableExpression("age && name")
+	expression.Truthiness = DefaultTruthiness
+
+	err := expression.TypeCheck(schema)
+	if err != nil {
+		test.Logf("Expected truthy conditions to pass type checking, got %v", err)
+		test.Fail()
+	}
+}
@@ -0,0 +1,84 @@
+package govaluate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+/*
+	Returns the given type error [err], with a short suggestion added for how to fix it,
+	based on the operator [symbol] and the [value] (from the given [side] of the stage) which had the wrong type.
+	Returns [err] unchanged if there's nothing useful to suggest.
+*/
+func addTypeHint(err error, symbol OperatorSymbol, side *evaluationStage, value interface{}) error {
+
+	hint := typeHint(symbol, side, value)
+	if hint == "" {
+		return err
+	}
+	return errors.New(err.Error() + "; " + hint)
+}
+
+/*
+	Returns a suggestion for how to fix the use of the given [value] (from the given [side] of a stage) with the operator [symbol],
+	written for people writing expressions rather than Go programmers. Returns "" if there isn't one.
+*/
+func typeHint(symbol OperatorSymbol, side *evaluationStage, value interface{}) string {
+
+	subject := describeOperand(side, value)
+	example := "x"
+	if side != nil && side.symbol == VALUE {
+		example = side.name
+	}
+
+	switch symbol {
+	case AND, OR, INVERT, TERNARY_TRUE:
+
+		switch friendlyTypeName(value) {
+		case "number":
+			return fmt.Sprintf("%s is a number, so compare it to get a bool, like '%s != 0'", subject, example)
+		case "string":
+			return fmt.Sprintf("%s is a string, so compare it to get a bool, like \"%s == 'yes'\"", subject, example)
+		case "null":
+			return fmt.Sprintf("%s is null, so use '??' to give it a default, like '%s ?? false'", subject, example)
+		}
+
+	case REQ, NREQ:
+		return fmt.Sprintf("%s is a %s, but '%s' needs a string on its left and a pattern on its right", subject, friendlyTypeName(value), symbol.String())
+
+	case PLUS, MINUS, MULTIPLY, DIVIDE, MODULUS, EXPONENT, NEGATE,
+		BITWISE_AND, BITWISE_OR, BITWISE_XOR, BITWISE_LSHIFT, BITWISE_RSHIFT, BITWISE_NOT,
+		GT, GTE, LT, LTE:
+
+		switch friendlyTypeName(value) {
+		case "string":
+
+			_, err := strconv.ParseFloat(value.(string), 64)
+			if err != nil {
+				break
+			}
+
+			if side != nil && side.symbol == LITERAL {
+				return fmt.Sprintf("%s is a string, so remove the quotes to use it as the number %v", subject, value)
+			}
+			return fmt.Sprintf("%s is a string, so it needs to be given as a number instead", subject)
+		case "bool":
+			return fmt.Sprintf("%s is a bool, so use a ternary to turn it into a number, like '(%s ? 1 : 0)'", subject, example)
+		case "null":
+			return fmt.Sprintf("%s is null, so use '??' to give it a default, like '%s ?? 0'", subject, example)
+		}
+	}
+	return ""
+}
+
+/*
+	Returns how to refer to the given [value] (from the given [side] of a stage) in a hint.
+*/
+func describeOperand(side *evaluationStage, value interface{}) string {
+
+	if side != nil && side.symbol == VALUE {
+		return fmt.Sprintf("the parameter '%s'", side.name)
+	}
+	return fmt.Sprintf("'%v'", value)
+}
@@ -0,0 +1,107 @@
+package govaluate
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestWalkOrder(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"max": func(arguments ...interface{}) (interface{}, error) {
+			return nil, nil
+		},
+	}
+
+	expression, err := NewEvaluableExpressionWithFunctions("foo / (bar - 1) > max(baz, 2)", functions)
+	if err != nil {
+		test.Logf("Unable to parse expression: %v", err)
+		test.Fail()
+		return
+	}
+
+	var symbols []OperatorSymbol
+	var names []string
+
+	expression.Walk(func(stage StageInfo) bool {
+
+		symbols = append(symbols, stage.Symbol)
+		if stage.Name != "" {
+			names = append(names, stage.Name)
+		}
+		return true
+	})
+
+	expectedSymbols := []OperatorSymbol{
+		GT,
+		DIVIDE, VALUE, NOOP, MINUS, VALUE, LITERAL,
+		FUNCTIONAL, NOOP, SEPARATE, VALUE, LITERAL,
+	}
+
+	if !reflect.DeepEqual(symbols, expectedSymbols) {
+		test.Logf("Expected stages %v, got %v", expectedSymbols, symbols)
+		test.Fail()
+	}
+
+	expectedNames := []string{"foo", "bar", "max", "baz"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		test.Logf("Expected names %v, got %v", expectedNames, names)
+		test.Fail()
+	}
+}
+
+/*
+	Uses Walk() as a linter would - finding divisions by something other than a non-zero literal, and regex literals.
+*/
+func TestWalkAnalysis(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("(a / 2 > b / c) && (name =~ '^foo' || (d / e) == 0)")
+
+	var unsafeDivisions, patterns int
+
+	expression.Walk(func(stage StageInfo) bool {
+
+		if stage.Symbol == DIVIDE {
+			if stage.Right.Symbol != LITERAL || stage.Right.Value == 0.0 {
+				unsafeDivisions++
+			}
+		}
+
+		if stage.Symbol == LITERAL {
+			if _, isPattern := stage.Value.(*regexp.Regexp); isPattern {
+				patterns++
+			}
+		}
+		return true
+	})
+
+	if unsafeDivisions != 2 {
+		test.Logf("Expected 2 unsafe divisions, found %d", unsafeDivisions)
+		test.Fail()
+	}
+
+	if patterns != 1 {
+		test.Logf("Expected 1 regex literal, found %d", patterns)
+		test.Fail()
+	}
+}
+
+func TestWalkPruning(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("(a + b) * (c + d)")
+
+	var visited int
+
+	expression.Walk(func(stage StageInfo) bool {
+
+		visited++
+		return stage.Symbol != NOOP
+	})
+
+	// the multiplication and both parenthesis, but nothing inside them.
+	if visited != 3 {
+		test.Logf("Expected pruning to visit 3 stages, visited %d", visited)
+		test.Fail()
+	}
+}